@@ -1,13 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
 	"log"
+	stdhttp "net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/service"
+	"smart-weaver/internal/domain/agent/service/armory/factory"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+	"smart-weaver/internal/domain/agent/service/chat"
+	"smart-weaver/internal/domain/agent/service/prompt"
+	"smart-weaver/internal/domain/agent/service/rag"
+	"smart-weaver/internal/domain/agent/service/usage"
+	"smart-weaver/internal/infrastructure/adapter/repository"
 	"smart-weaver/internal/trigger/http"
 )
 
 func main() {
+	// 收到 SIGINT / SIGTERM 时取消，触发优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// 初始化配置
 	cfg := config.Load()
 
@@ -20,16 +40,98 @@ func main() {
 	// 初始化线程池
 	threadPool := config.InitThreadPool(cfg)
 
+	// 初始化知识库，向量存储不可用时知识库接口不开放
+	var ragService rag.IRagService
+	vectorStore, err := cfg.AiAgent.CreateVectorStore()
+	if err != nil {
+		log.Printf("向量存储初始化失败，知识库接口不可用: %v", err)
+	} else {
+		splitter := cfg.AiAgent.CreateTokenTextSplitter()
+		pipeline := rag.NewIngestionPipeline(splitter, vectorStore)
+		gitIngester := rag.NewGitRepositoryIngester(splitter, vectorStore, cfg.AiAgent.GitIngest.WorkDir, cfg.AiAgent.GitIngest.StateDir)
+		if cfg.AiAgent.GitIngest.MaxFileSize > 0 {
+			gitIngester.MaxFileSize = cfg.AiAgent.GitIngest.MaxFileSize
+		}
+		gitIngester.AllowLocalPath = cfg.AiAgent.GitIngest.AllowLocalPath
+		ragService = rag.NewRagService(pipeline, gitIngester)
+	}
+
+	// 初始化对话记忆存储，进程内存储由顾问节点默认注册
+	chatMemories := map[string]node.ChatMemory{
+		node.ChatMemoryStorageMysql: repository.NewMysqlChatMemory(db),
+	}
+	if cfg.AiAgent.Redis.ChatMemoryEnabled {
+		if redisClient, err := cfg.AiAgent.RedisClient(); err != nil {
+			log.Printf("Redis 初始化失败，redis 对话记忆不可用: %v", err)
+		} else {
+			chatMemories[node.ChatMemoryStorageRedis] = repository.NewRedisChatMemory(redisClient, time.Duration(cfg.AiAgent.Redis.ChatMemoryTTL)*time.Second)
+		}
+	}
+
+	// 初始化模型用量记录，每次模型调用的用量按模型价格计算费用后写入流水
+	usageService := usage.NewUsageService(repository.NewUsageRepository(db))
+
+	// 初始化智能体构建链，启动时构建所有启用的客户端，开启配置变更监听时由监听负责构建并重试失败的客户端
+	agentRepository := repository.NewAgentRepository(db)
+	armoryFactory := factory.NewArmoryStrategyFactory(agentRepository, vectorStore, chatMemories, usageService)
+	agentService := service.NewAgentService(armoryFactory)
+	if reloadInterval := cfg.AiAgent.Armory.ReloadInterval; reloadInterval > 0 {
+		watcher := service.NewAgentConfigWatcher(agentRepository, agentService, time.Duration(reloadInterval)*time.Second)
+		if err := watcher.Start(ctx); err != nil {
+			log.Printf("客户端构建失败，将在下次检查配置变更时重试: %v", err)
+		}
+	} else if clientIdList, err := agentRepository.QueryEnabledClientIds(); err != nil {
+		log.Printf("查询启用客户端失败: %v", err)
+	} else if len(clientIdList) > 0 {
+		if report, err := agentService.DoArmory(&entity.AiAgentEngineStarterEntity{ClientIDList: clientIdList}); err != nil {
+			log.Printf("客户端构建失败: %v", err)
+		} else {
+			for _, failure := range report.Failed {
+				log.Printf("Bean %s 构建失败: %s", failure.Bean, failure.Reason)
+			}
+		}
+	}
+	chatService := chat.NewChatService(armoryFactory)
+	promptService := prompt.NewPromptService(agentRepository)
+
 	// 启动HTTP服务器
-	router := http.SetupRouter(db, cache, threadPool)
+	router := http.SetupRouter(db, cache, threadPool, cfg.Server.AdminToken, ragService, agentService, chatService, promptService, usageService)
+	if cfg.Server.AdminToken == "" {
+		log.Printf("未配置 server.admin-token，管理接口不可用")
+	}
 
 	port := cfg.Server.Port
 	if port == "" {
 		port = "8091"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	server := &stdhttp.Server{Addr: ":" + port, Handler: router}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, stdhttp.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	case <-ctx.Done():
+		log.Printf("收到退出信号，开始优雅关闭")
+	}
+
+	// 先停止接收请求并等待处理中的请求结束，再写完用量流水和向量快照
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP 服务关闭超时: %v", err)
+	}
+	usageService.Close()
+	if closer, ok := vectorStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("向量存储关闭失败: %v", err)
+		}
 	}
+	log.Printf("服务已关闭")
 }
@@ -1,12 +1,16 @@
 package config
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql" // MySQL 驱动
 	_ "github.com/lib/pq"              // PostgreSQL 驱动
+	"github.com/redis/go-redis/v9"
 )
 
 // AiAgentConfig AI代理配置
@@ -35,13 +39,55 @@ type AiAgentConfig struct {
 		MaxOpenConns int    `yaml:"max_open_conns" default:"5"`
 		MaxIdleConns int    `yaml:"max_idle_conns" default:"2"`
 		MaxIdleTime  int    `yaml:"max_idle_time" default:"30"` // 秒
+		DistanceType string `yaml:"distance_type"`              // cosine / euclidean / inner_product
+		IndexType    string `yaml:"index_type"`                 // hnsw / ivfflat / none
 	} `yaml:"vector_db"`
 
+	// 向量存储配置
+	VectorStore struct {
+		Type          string `yaml:"type"`                       // pgvector / memory，默认 pgvector
+		SnapshotPath  string `yaml:"snapshot_path"`              // memory 类型的快照文件，为空时不落盘
+		SnapshotDelay int    `yaml:"snapshot_delay" default:"5"` // memory 类型变更后延迟写快照的秒数，期间的变更合并写入
+	} `yaml:"vector_store"`
+
 	// OpenAI 配置
 	OpenAI struct {
-		BaseUrl string `yaml:"base_url"`
-		ApiKey  string `yaml:"api_key"`
+		BaseUrl        string `yaml:"base_url"`
+		ApiKey         string `yaml:"api_key"`
+		EmbeddingsPath string `yaml:"embeddings_path"`
+		EmbeddingModel string `yaml:"embedding_model"`
 	} `yaml:"openai"`
+
+	// 文本分割配置
+	TextSplitter struct {
+		ChunkSize      int    `yaml:"chunk_size" default:"800"`       // 每块 token 数
+		ChunkOverlap   int    `yaml:"chunk_overlap" default:"0"`      // 相邻块重叠 token 数
+		MinChunkLength int    `yaml:"min_chunk_length" default:"5"`   // 短于该字符数的块丢弃
+		Boundary       string `yaml:"boundary" default:"paragraph"`   // paragraph / sentence / none
+		Encoding       string `yaml:"encoding" default:"cl100k_base"` // cl100k_base / o200k_base
+	} `yaml:"text_splitter"`
+
+	// 代码仓库入库配置
+	GitIngest struct {
+		WorkDir        string `yaml:"work_dir" default:"data/git/repos"`  // 远程仓库缓存目录
+		StateDir       string `yaml:"state_dir" default:"data/git/state"` // 增量入库状态目录
+		MaxFileSize    int64  `yaml:"max_file_size" default:"1048576"`    // 超过该字节数的文件跳过
+		AllowLocalPath bool   `yaml:"allow_local_path"`                   // 是否允许读取服务器本地仓库
+	} `yaml:"git_ingest"`
+
+	// 智能体构建配置
+	Armory struct {
+		ReloadInterval int `yaml:"reload_interval" default:"30"` // 配置变更检查间隔，秒，0 表示不检查
+	} `yaml:"armory"`
+
+	// Redis 配置，用于对话记忆等，Addr 为空时不启用
+	Redis struct {
+		Addr              string `yaml:"addr"`
+		Password          string `yaml:"password"`
+		DB                int    `yaml:"db"`
+		ChatMemoryTTL     int    `yaml:"chat_memory_ttl" default:"604800"` // 对话记忆过期时间，秒，0 表示不过期
+		ChatMemoryEnabled bool   `yaml:"chat_memory_enabled"`              // 是否注册 redis 对话记忆存储
+	} `yaml:"redis"`
 }
 
 // DataSource 数据源
@@ -52,24 +98,59 @@ type DataSource struct {
 
 // OpenAiApi OpenAI API 客户端
 type OpenAiApi struct {
-	BaseUrl string
-	ApiKey  string
+	BaseUrl        string
+	ApiKey         string
+	EmbeddingsPath string
 }
 
 // OpenAiEmbeddingModel OpenAI 嵌入模型
 type OpenAiEmbeddingModel struct {
-	Api *OpenAiApi
+	Api        *OpenAiApi
+	Model      string
+	BatchSize  int           // 单次请求最多文本条数
+	MaxRetries int           // 429 限流时的最大重试次数
+	Timeout    time.Duration // 单次请求超时，0 表示不限制
+
+	dimensions int
+	mu         sync.Mutex
 }
 
 // PgVectorStore PG向量存储
 type PgVectorStore struct {
-	DB              *sql.DB
-	EmbeddingModel  *OpenAiEmbeddingModel
-	VectorTableName string
+	DB               *sql.DB
+	EmbeddingModel   EmbeddingModel
+	VectorTableName  string
+	Dimensions       int    // 向量维度，0 表示从嵌入模型获取
+	DistanceType     string // cosine / euclidean / inner_product
+	IndexType        string // hnsw / ivfflat / none
+	InitializeSchema bool   // 是否自动创建扩展、表和索引
+
+	initMu      sync.Mutex
+	initialized bool
 }
 
-// TokenTextSplitter 文本分割器
-type TokenTextSplitter struct{}
+// InMemoryVectorStore 内存向量存储，适用于单元测试和开发环境的小规模数据
+// 快照为全量 JSON 文件，变更后延迟 SaveDelay 合并写入，进程退出前需调用 Close 写入未落盘的变更
+type InMemoryVectorStore struct {
+	EmbeddingModel EmbeddingModel
+	SnapshotPath   string        // 快照文件路径，为空时不落盘
+	SaveDelay      time.Duration // 变更后延迟写快照的时间，0 表示每次变更立即写入
+
+	docs      map[string]Document
+	mu        sync.RWMutex
+	dirty     bool        // 有未写入快照的变更
+	saveTimer *time.Timer // 等待中的延迟写入
+}
+
+// TokenTextSplitter 文本分割器，按 token 数切分文档
+type TokenTextSplitter struct {
+	ChunkSize      int    // 每块最多 token 数
+	ChunkOverlap   int    // 相邻块重叠 token 数
+	MinChunkLength int    // 去除首尾空白后短于该字符数的块丢弃
+	MaxNumChunks   int    // 单个文档最多分块数
+	Boundary       string // 分块边界偏好 paragraph / sentence / none
+	Encoding       string // token 编码 cl100k_base / o200k_base
+}
 
 // MainDataSource 创建主数据源（替代MyBatis数据源）
 func (config *AiAgentConfig) MainDataSource() (*DataSource, error) {
@@ -146,35 +227,90 @@ func (config *AiAgentConfig) PgVectorDataSource() (*DataSource, error) {
 	}, nil
 }
 
-// VectorStore 创建向量存储
-func (config *AiAgentConfig) VectorStore() (*PgVectorStore, error) {
+// CreateVectorStore 创建向量存储，按配置选择 pgvector 或内存实现
+func (config *AiAgentConfig) CreateVectorStore() (VectorStore, error) {
+	// 创建 OpenAI API 客户端
+	openAiApi := &OpenAiApi{
+		BaseUrl:        config.OpenAI.BaseUrl,
+		ApiKey:         config.OpenAI.ApiKey,
+		EmbeddingsPath: config.OpenAI.EmbeddingsPath,
+	}
+
+	// 创建嵌入模型
+	embeddingModel := NewOpenAiEmbeddingModel(openAiApi, config.OpenAI.EmbeddingModel)
+
+	switch config.VectorStore.Type {
+	case VectorStoreTypeMemory:
+		vectorStore := NewInMemoryVectorStore(embeddingModel, config.VectorStore.SnapshotPath)
+		if config.VectorStore.SnapshotDelay > 0 {
+			vectorStore.SaveDelay = time.Duration(config.VectorStore.SnapshotDelay) * time.Second
+		}
+		if err := vectorStore.Load(); err != nil {
+			return nil, err
+		}
+		return vectorStore, nil
+	case VectorStoreTypePgVector, "":
+	default:
+		return nil, fmt.Errorf("unsupported vector store type: %s", config.VectorStore.Type)
+	}
+
 	// 创建 PgVector 数据源
 	pgVectorDS, err := config.PgVectorDataSource()
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建 OpenAI API 客户端
-	openAiApi := &OpenAiApi{
-		BaseUrl: config.OpenAI.BaseUrl,
-		ApiKey:  config.OpenAI.ApiKey,
+	vectorStore := NewPgVectorStore(pgVectorDS.DB, embeddingModel, "vector_store_openai")
+	if config.VectorDB.DistanceType != "" {
+		vectorStore.DistanceType = config.VectorDB.DistanceType
 	}
+	if config.VectorDB.IndexType != "" {
+		vectorStore.IndexType = config.VectorDB.IndexType
+	}
+	return vectorStore, nil
+}
 
-	// 创建嵌入模型
-	embeddingModel := &OpenAiEmbeddingModel{
-		Api: openAiApi,
+// RedisClient 创建 Redis 客户端并测试连接
+func (config *AiAgentConfig) RedisClient() (*redis.Client, error) {
+	if config.Redis.Addr == "" {
+		return nil, errors.New("redis addr not configured")
 	}
 
-	return &PgVectorStore{
-		DB:              pgVectorDS.DB,
-		EmbeddingModel:  embeddingModel,
-		VectorTableName: "vector_store_openai",
-	}, nil
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Redis.Addr,
+		Password: config.Redis.Password,
+		DB:       config.Redis.DB,
+	})
+
+	// 测试连接
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return client, nil
 }
 
 // TokenTextSplitter 创建文本分割器
 func (config *AiAgentConfig) CreateTokenTextSplitter() *TokenTextSplitter {
-	return &TokenTextSplitter{}
+	splitter := NewTokenTextSplitter()
+	if config.TextSplitter.ChunkSize > 0 {
+		splitter.ChunkSize = config.TextSplitter.ChunkSize
+	}
+	if config.TextSplitter.ChunkOverlap > 0 {
+		splitter.ChunkOverlap = config.TextSplitter.ChunkOverlap
+	}
+	if config.TextSplitter.MinChunkLength > 0 {
+		splitter.MinChunkLength = config.TextSplitter.MinChunkLength
+	}
+	if config.TextSplitter.Boundary != "" {
+		splitter.Boundary = config.TextSplitter.Boundary
+	}
+	if config.TextSplitter.Encoding != "" {
+		splitter.Encoding = config.TextSplitter.Encoding
+	}
+	return splitter
 }
 
 // Close 关闭数据源
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -12,11 +13,14 @@ type Config struct {
 	Database   DatabaseConfig   `mapstructure:"spring"`
 	ThreadPool ThreadPoolConfig `mapstructure:"thread"`
 	Logging    LoggingConfig    `mapstructure:"logging"`
+	AiAgent    AiAgentConfig    `mapstructure:"-"` // ai 节点，沿用 yaml 标签单独解析
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
+	Port            string `mapstructure:"port"`
+	AdminToken      string `mapstructure:"admin-token"`      // 管理接口令牌，为空时管理接口不可用
+	ShutdownTimeout int    `mapstructure:"shutdown-timeout"` // 优雅关闭等待请求结束的秒数
 }
 
 // DatabaseConfig 数据库配置
@@ -70,6 +74,7 @@ func Load() *Config {
 
 	// 设置默认值
 	viper.SetDefault("server.port", "8091")
+	viper.SetDefault("server.shutdown-timeout", 30)
 
 	// 获取环境变量
 	profile := viper.GetString("spring.profiles.active")
@@ -91,6 +96,11 @@ func Load() *Config {
 	if err := viper.Unmarshal(&config); err != nil {
 		log.Fatalf("Error unmarshaling config: %v", err)
 	}
+	if err := viper.UnmarshalKey("ai", &config.AiAgent, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "yaml"
+	}); err != nil {
+		log.Fatalf("Error unmarshaling ai config: %v", err)
+	}
 
 	return &config
 }
@@ -39,7 +39,7 @@ func InitDatabase(cfg *Config) *gorm.DB {
 	}
 
 	// 自动迁移
-	if err := db.AutoMigrate(&po.AiClientModel{}); err != nil {
+	if err := db.AutoMigrate(&po.AiClient{}, &po.AiClientModel{}, &po.AiClientModelRel{}, &po.AiClientAdvisor{}, &po.AiClientSystemPrompt{}, &po.AiChatMemory{}, &po.AiModelPrice{}, &po.AiUsageLedger{}); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+)
+
+// Document 向量存储中的文档（模拟Java中的Document）
+type Document struct {
+	ID        string         `json:"id"`
+	Text      string         `json:"text"`
+	Metadata  map[string]any `json:"metadata"`
+	Embedding []float32      `json:"embedding,omitempty"` // 为空时由向量存储调用嵌入模型计算
+	Score     float64        `json:"score"`               // 相似度检索得分，越大越相似
+}
+
+// NewDocument 创建文档，自动生成随机ID
+func NewDocument(text string, metadata map[string]any) Document {
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	return Document{
+		ID:       NewDocumentID(),
+		Text:     text,
+		Metadata: metadata,
+	}
+}
+
+// NewDocumentID 生成随机 UUID（v4）
+func NewDocumentID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b)
+}
+
+// NewNameBasedDocumentID 基于名称生成确定性 UUID（v5 风格），相同名称得到相同ID
+func NewNameBasedDocumentID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	var b [16]byte
+	copy(b[:], sum[:16])
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b)
+}
+
+// formatUUID 格式化为标准 UUID 字符串
+func formatUUID(b [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultEmbeddingsPath 未配置时使用的嵌入接口路径
+	defaultEmbeddingsPath = "/v1/embeddings"
+	// defaultEmbeddingModelName 未配置时使用的嵌入模型
+	defaultEmbeddingModelName = "text-embedding-ada-002"
+	// defaultEmbeddingBatchSize 单次请求默认最多文本条数
+	defaultEmbeddingBatchSize = 100
+	// maxEmbeddingBatchChars 单次请求文本总字符数上限，避免超出接口 token 限制
+	maxEmbeddingBatchChars = 200000
+	// defaultEmbeddingMaxRetries 限流默认重试次数
+	defaultEmbeddingMaxRetries = 3
+	// embeddingRetryBaseDelay 限流重试的初始等待时间
+	embeddingRetryBaseDelay = time.Second
+)
+
+// knownEmbeddingDimensions 常见嵌入模型维度，未知模型通过一次试探调用获取
+var knownEmbeddingDimensions = map[string]int{
+	"text-embedding-ada-002": 1536,
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+}
+
+// EmbeddingModel 嵌入模型（模拟Java中的EmbeddingModel）
+type EmbeddingModel interface {
+	// Embed 批量计算文本向量，返回顺序与入参一致
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Dimensions 向量维度
+	Dimensions(ctx context.Context) (int, error)
+}
+
+// embeddingRequest /embeddings 请求体
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// embeddingResponse /embeddings 响应体
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// EmbeddingApiError 嵌入接口返回的错误
+type EmbeddingApiError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *EmbeddingApiError) Error() string {
+	return fmt.Sprintf("嵌入接口调用失败 status=%d message=%s", e.StatusCode, e.Message)
+}
+
+// NewOpenAiEmbeddingModel 创建 OpenAI 嵌入模型
+func NewOpenAiEmbeddingModel(api *OpenAiApi, model string) *OpenAiEmbeddingModel {
+	if model == "" {
+		model = defaultEmbeddingModelName
+	}
+	return &OpenAiEmbeddingModel{
+		Api:        api,
+		Model:      model,
+		BatchSize:  defaultEmbeddingBatchSize,
+		MaxRetries: defaultEmbeddingMaxRetries,
+	}
+}
+
+// Embed 批量计算文本向量，超出批量上限时自动拆分请求
+func (m *OpenAiEmbeddingModel) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	batchSize := m.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+
+	result := make([][]float32, 0, len(texts))
+	start := 0
+	for start < len(texts) {
+		end, chars := start, 0
+		for end < len(texts) && end-start < batchSize {
+			if end > start && chars+len(texts[end]) > maxEmbeddingBatchChars {
+				break
+			}
+			chars += len(texts[end])
+			end++
+		}
+
+		embeddings, err := m.embedWithRetry(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, embeddings...)
+		start = end
+	}
+	return result, nil
+}
+
+// Dimensions 向量维度，已知模型直接返回，否则试探调用一次并缓存
+func (m *OpenAiEmbeddingModel) Dimensions(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dimensions > 0 {
+		return m.dimensions, nil
+	}
+	if dimensions, ok := knownEmbeddingDimensions[m.Model]; ok {
+		m.dimensions = dimensions
+		return dimensions, nil
+	}
+
+	embeddings, err := m.embedWithRetry(ctx, []string{"Test String"})
+	if err != nil {
+		return 0, fmt.Errorf("获取嵌入模型 %s 维度失败: %w", m.Model, err)
+	}
+	m.dimensions = len(embeddings[0])
+	return m.dimensions, nil
+}
+
+// embedWithRetry 单批请求，429 限流时按 Retry-After 或指数退避重试
+func (m *OpenAiEmbeddingModel) embedWithRetry(ctx context.Context, texts []string) ([][]float32, error) {
+	maxRetries := m.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		embeddings, err := m.embedBatch(ctx, texts)
+		if err == nil {
+			return embeddings, nil
+		}
+
+		var apiErr *EmbeddingApiError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || attempt >= maxRetries {
+			return nil, err
+		}
+
+		delay := apiErr.RetryAfter
+		if delay <= 0 {
+			delay = embeddingRetryBaseDelay << attempt
+		}
+		log.Printf("嵌入接口限流，%s 后重试(%d/%d)", delay, attempt+1, maxRetries)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// embedBatch 单批请求
+func (m *OpenAiEmbeddingModel) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if m.Api == nil {
+		return nil, errors.New("OpenAiApi未配置")
+	}
+	if m.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(embeddingRequest{Model: m.Model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("序列化嵌入请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.Api.embeddingsURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.Api.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.Api.ApiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求嵌入接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取嵌入响应失败: %w", err)
+	}
+
+	var embeddingResp embeddingResponse
+	_ = json.Unmarshal(respBody, &embeddingResp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &EmbeddingApiError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(respBody)),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+		if embeddingResp.Error != nil {
+			apiErr.Message = embeddingResp.Error.Message
+		}
+		return nil, apiErr
+	}
+
+	if len(embeddingResp.Data) != len(texts) {
+		return nil, fmt.Errorf("嵌入结果数量不匹配: 期望 %d 实际 %d", len(texts), len(embeddingResp.Data))
+	}
+
+	sort.Slice(embeddingResp.Data, func(i, j int) bool {
+		return embeddingResp.Data[i].Index < embeddingResp.Data[j].Index
+	})
+	embeddings := make([][]float32, len(embeddingResp.Data))
+	for i, data := range embeddingResp.Data {
+		embeddings[i] = data.Embedding
+	}
+	return embeddings, nil
+}
+
+// embeddingsURL 拼接嵌入接口地址
+func (api *OpenAiApi) embeddingsURL() string {
+	path := api.EmbeddingsPath
+	if path == "" {
+		path = defaultEmbeddingsPath
+	}
+	return strings.TrimRight(api.BaseUrl, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// parseRetryAfter 解析 Retry-After 响应头（秒数或 HTTP 日期）
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
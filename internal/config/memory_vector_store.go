@@ -0,0 +1,368 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// defaultMemoryVectorSaveDelay 变更后延迟写快照的默认时间
+const defaultMemoryVectorSaveDelay = 5 * time.Second
+
+// memoryVectorSnapshot 快照文件结构
+type memoryVectorSnapshot struct {
+	Documents []Document `json:"documents"`
+}
+
+// NewInMemoryVectorStore 创建内存向量存储，snapshotPath 为空时不落盘
+func NewInMemoryVectorStore(embeddingModel EmbeddingModel, snapshotPath string) *InMemoryVectorStore {
+	return &InMemoryVectorStore{
+		EmbeddingModel: embeddingModel,
+		SnapshotPath:   snapshotPath,
+		SaveDelay:      defaultMemoryVectorSaveDelay,
+		docs:           make(map[string]Document),
+	}
+}
+
+// Load 从快照文件加载文档，文件不存在时忽略
+func (s *InMemoryVectorStore) Load() error {
+	if s.SnapshotPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取向量快照失败: %w", err)
+	}
+
+	var snapshot memoryVectorSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("解析向量快照 %s 失败: %w", s.SnapshotPath, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs = make(map[string]Document, len(snapshot.Documents))
+	for _, doc := range snapshot.Documents {
+		s.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+// Save 立即将全部文档写入快照文件，先写临时文件再替换，避免写一半的快照
+func (s *InMemoryVectorStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopSaveTimerLocked()
+	if err := s.saveLocked(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Close 写入尚未落盘的变更
+func (s *InMemoryVectorStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopSaveTimerLocked()
+	return s.flushLocked()
+}
+
+// Add 计算文档向量并写入，ID 已存在时覆盖
+func (s *InMemoryVectorStore) Add(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if s.EmbeddingModel == nil {
+		return errors.New("InMemoryVectorStore 嵌入模型为空")
+	}
+	if err := embedDocuments(ctx, s.EmbeddingModel, docs); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.docs == nil {
+		s.docs = make(map[string]Document, len(docs))
+	}
+	for i := range docs {
+		if docs[i].ID == "" {
+			docs[i].ID = NewDocumentID()
+		}
+		doc := docs[i]
+		doc.Metadata = normalizeMetadata(nonNilMetadata(doc.Metadata))
+		doc.Score = 0
+		s.docs[doc.ID] = doc
+	}
+	return s.markDirtyLocked()
+}
+
+// SimilaritySearch 暴力计算余弦相似度，threshold<=0 表示不过滤，filter 按元数据包含关系过滤
+func (s *InMemoryVectorStore) SimilaritySearch(ctx context.Context, query string, topK int, threshold float64, filter map[string]any) ([]Document, error) {
+	if s.EmbeddingModel == nil {
+		return nil, errors.New("InMemoryVectorStore 嵌入模型为空")
+	}
+
+	embeddings, err := s.EmbeddingModel.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	return s.SimilaritySearchByVector(ctx, embeddings[0], topK, threshold, filter)
+}
+
+// SimilaritySearchByVector 按查询向量暴力计算余弦相似度，维度与已存文档不一致时返回错误
+func (s *InMemoryVectorStore) SimilaritySearchByVector(ctx context.Context, embedding []float32, topK int, threshold float64, filter map[string]any) ([]Document, error) {
+	if topK <= 0 {
+		topK = defaultTopK
+	}
+	normalizedFilter := normalizeMetadata(filter)
+
+	s.mu.RLock()
+	var docs []Document
+	for _, doc := range s.docs {
+		if len(doc.Embedding) != len(embedding) {
+			s.mu.RUnlock()
+			return nil, fmt.Errorf("查询向量维度 %d 与文档 %s 的维度 %d 不一致", len(embedding), doc.ID, len(doc.Embedding))
+		}
+		if len(filter) > 0 && !jsonContains(doc.Metadata, normalizedFilter) {
+			continue
+		}
+		score := cosineSimilarity(embedding, doc.Embedding)
+		if threshold > 0 && score < threshold {
+			continue
+		}
+		doc.Score = score
+		doc.Embedding = nil
+		docs = append(docs, doc)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].Score != docs[j].Score {
+			return docs[i].Score > docs[j].Score
+		}
+		return docs[i].ID < docs[j].ID
+	})
+	if len(docs) > topK {
+		docs = docs[:topK]
+	}
+	return docs, nil
+}
+
+// ListIDs 按元数据包含关系查询文档ID，filter 为空时返回全部，结果按ID排序
+func (s *InMemoryVectorStore) ListIDs(ctx context.Context, filter map[string]any) ([]string, error) {
+	normalizedFilter := normalizeMetadata(filter)
+
+	s.mu.RLock()
+	var ids []string
+	for id, doc := range s.docs {
+		if len(filter) > 0 && !jsonContains(doc.Metadata, normalizedFilter) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete 按ID删除文档
+func (s *InMemoryVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.docs, id)
+	}
+	return s.markDirtyLocked()
+}
+
+// Size 文档数量
+func (s *InMemoryVectorStore) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.docs)
+}
+
+// markDirtyLocked 记录变更，SaveDelay 为 0 时立即写快照，否则合并到延迟写入，调用方需持有写锁
+func (s *InMemoryVectorStore) markDirtyLocked() error {
+	if s.SnapshotPath == "" {
+		return nil
+	}
+	s.dirty = true
+	if s.SaveDelay <= 0 {
+		return s.flushLocked()
+	}
+	if s.saveTimer == nil {
+		s.saveTimer = time.AfterFunc(s.SaveDelay, s.delayedSave)
+	}
+	return nil
+}
+
+// delayedSave 延迟写入触发，失败时保留变更等待下一次变更或 Close 重试
+func (s *InMemoryVectorStore) delayedSave() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveTimer = nil
+	if err := s.flushLocked(); err != nil {
+		log.Printf("写入向量快照 %s 失败: %v", s.SnapshotPath, err)
+	}
+}
+
+// flushLocked 有未落盘的变更时写快照，调用方需持有写锁
+func (s *InMemoryVectorStore) flushLocked() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := s.saveLocked(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// stopSaveTimerLocked 取消等待中的延迟写入，调用方需持有写锁
+func (s *InMemoryVectorStore) stopSaveTimerLocked() {
+	if s.saveTimer != nil {
+		s.saveTimer.Stop()
+		s.saveTimer = nil
+	}
+}
+
+// saveLocked 写快照，调用方需持有锁
+func (s *InMemoryVectorStore) saveLocked() error {
+	if s.SnapshotPath == "" {
+		return nil
+	}
+
+	snapshot := memoryVectorSnapshot{Documents: make([]Document, 0, len(s.docs))}
+	for _, doc := range s.docs {
+		snapshot.Documents = append(snapshot.Documents, doc)
+	}
+	sort.Slice(snapshot.Documents, func(i, j int) bool {
+		return snapshot.Documents[i].ID < snapshot.Documents[j].ID
+	})
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("序列化向量快照失败: %w", err)
+	}
+
+	dir := filepath.Dir(s.SnapshotPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建快照目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.SnapshotPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时快照文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入向量快照失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入向量快照失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.SnapshotPath); err != nil {
+		return fmt.Errorf("替换向量快照失败: %w", err)
+	}
+	return nil
+}
+
+// cosineSimilarity 余弦相似度，维度不一致或零向量时返回 0
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// normalizeMetadata 经 JSON 往返统一数值等类型，使比较语义与 jsonb 一致
+func normalizeMetadata(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		return nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return metadata
+	}
+	var normalized map[string]any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return metadata
+	}
+	return normalized
+}
+
+// jsonContains 与 PostgreSQL jsonb @> 语义一致的包含判断
+func jsonContains(container, contained any) bool {
+	switch want := contained.(type) {
+	case map[string]any:
+		have, ok := container.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range want {
+			haveValue, ok := have[key]
+			if !ok || !jsonContains(haveValue, value) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		have, ok := container.([]any)
+		if !ok {
+			return false
+		}
+		for _, value := range want {
+			found := false
+			for _, haveValue := range have {
+				if jsonContains(haveValue, value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	default:
+		// jsonb 中数组包含同值的标量元素
+		if have, ok := container.([]any); ok {
+			for _, haveValue := range have {
+				if reflect.DeepEqual(haveValue, contained) {
+					return true
+				}
+			}
+			return false
+		}
+		return reflect.DeepEqual(container, contained)
+	}
+}
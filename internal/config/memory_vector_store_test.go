@@ -0,0 +1,230 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeEmbeddingModel 按预设文本返回固定向量，未预设的文本返回错误
+type fakeEmbeddingModel map[string][]float32
+
+func (m fakeEmbeddingModel) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		embedding, ok := m[text]
+		if !ok {
+			return nil, errors.New("unexpected text: " + text)
+		}
+		embeddings = append(embeddings, embedding)
+	}
+	return embeddings, nil
+}
+
+func (m fakeEmbeddingModel) Dimensions(context.Context) (int, error) {
+	return 2, nil
+}
+
+var testEmbeddingModel = fakeEmbeddingModel{
+	"cat":    {1, 0},
+	"kitten": {0.9, 0.1},
+	"car":    {0, 1},
+	"animal": {1, 0.05},
+}
+
+// newTestMemoryVectorStore 创建写入 cat / kitten / car 三个文档的内存向量存储
+func newTestMemoryVectorStore(t *testing.T, snapshotPath string) *InMemoryVectorStore {
+	t.Helper()
+	store := NewInMemoryVectorStore(testEmbeddingModel, snapshotPath)
+	store.SaveDelay = 0
+	docs := []Document{
+		{ID: "cat", Text: "cat", Metadata: map[string]any{"source": "a.md", "tags": []string{"pet", "animal"}}},
+		{ID: "kitten", Text: "kitten", Metadata: map[string]any{"source": "b.md", "tags": []string{"pet"}}},
+		{ID: "car", Text: "car", Metadata: map[string]any{"source": "a.md", "wheels": 4}},
+	}
+	if err := store.Add(context.Background(), docs); err != nil {
+		t.Fatalf("Add 失败: %v", err)
+	}
+	return store
+}
+
+// documentIDs 检索结果的文档ID
+func documentIDs(docs []Document) []string {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	return ids
+}
+
+func TestInMemoryVectorStoreSimilaritySearch(t *testing.T) {
+	store := newTestMemoryVectorStore(t, "")
+
+	tests := []struct {
+		name      string
+		topK      int
+		threshold float64
+		filter    map[string]any
+		want      []string
+	}{
+		{name: "按相似度排序", topK: 3, want: []string{"cat", "kitten", "car"}},
+		{name: "topK 截断", topK: 1, want: []string{"cat"}},
+		{name: "相似度阈值过滤", topK: 3, threshold: 0.5, want: []string{"cat", "kitten"}},
+		{name: "元数据过滤", topK: 3, filter: map[string]any{"source": "a.md"}, want: []string{"cat", "car"}},
+		{name: "数组包含过滤", topK: 3, filter: map[string]any{"tags": []string{"animal"}}, want: []string{"cat"}},
+		{name: "数值类型统一后过滤", topK: 3, filter: map[string]any{"wheels": 4.0}, want: []string{"car"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := store.SimilaritySearch(context.Background(), "animal", tt.topK, tt.threshold, tt.filter)
+			if err != nil {
+				t.Fatalf("SimilaritySearch 失败: %v", err)
+			}
+			got := documentIDs(docs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("检索结果 = %v, 期望 %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("检索结果 = %v, 期望 %v", got, tt.want)
+				}
+			}
+			for _, doc := range docs {
+				if doc.Embedding != nil || doc.Score == 0 {
+					t.Errorf("文档 %s 检索结果应带得分且不带向量: %+v", doc.ID, doc)
+				}
+			}
+		})
+	}
+}
+
+func TestInMemoryVectorStoreDelete(t *testing.T) {
+	store := newTestMemoryVectorStore(t, "")
+
+	if err := store.Delete(context.Background(), []string{"cat", "missing"}); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if store.Size() != 2 {
+		t.Errorf("删除后文档数量 = %d, 期望 2", store.Size())
+	}
+	docs, err := store.SimilaritySearch(context.Background(), "animal", 1, 0, nil)
+	if err != nil {
+		t.Fatalf("SimilaritySearch 失败: %v", err)
+	}
+	if ids := documentIDs(docs); len(ids) != 1 || ids[0] != "kitten" {
+		t.Errorf("删除后检索结果 = %v", ids)
+	}
+}
+
+func TestInMemoryVectorStoreSimilaritySearchByVector(t *testing.T) {
+	store := newTestMemoryVectorStore(t, "")
+
+	// 查询向量由调用方计算，不经过存储的嵌入模型
+	docs, err := store.SimilaritySearchByVector(context.Background(), []float32{0, 1}, 1, 0, nil)
+	if err != nil {
+		t.Fatalf("SimilaritySearchByVector 失败: %v", err)
+	}
+	if ids := documentIDs(docs); len(ids) != 1 || ids[0] != "car" {
+		t.Errorf("检索结果 = %v, 期望 [car]", ids)
+	}
+
+	if _, err := store.SimilaritySearchByVector(context.Background(), []float32{1, 0, 0}, 1, 0, nil); err == nil {
+		t.Error("查询向量维度不一致时应返回错误")
+	}
+}
+
+func TestInMemoryVectorStoreSnapshot(t *testing.T) {
+	snapshotPath := filepath.Join(t.TempDir(), "vector", "snapshot.json")
+	store := newTestMemoryVectorStore(t, snapshotPath)
+	if err := store.Delete(context.Background(), []string{"car"}); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+
+	// 快照保存向量，重新加载后无需调用嵌入模型
+	loaded := NewInMemoryVectorStore(fakeEmbeddingModel{"animal": {1, 0.05}}, snapshotPath)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load 失败: %v", err)
+	}
+	if loaded.Size() != 2 {
+		t.Fatalf("加载后文档数量 = %d, 期望 2", loaded.Size())
+	}
+	docs, err := loaded.SimilaritySearch(context.Background(), "animal", 3, 0, map[string]any{"tags": []string{"pet"}})
+	if err != nil {
+		t.Fatalf("SimilaritySearch 失败: %v", err)
+	}
+	if ids := documentIDs(docs); len(ids) != 2 || ids[0] != "cat" || ids[1] != "kitten" {
+		t.Errorf("加载后检索结果 = %v", ids)
+	}
+
+	// 快照文件不存在时视为空库
+	empty := NewInMemoryVectorStore(testEmbeddingModel, filepath.Join(t.TempDir(), "missing.json"))
+	if err := empty.Load(); err != nil || empty.Size() != 0 {
+		t.Errorf("加载不存在的快照: size=%d err=%v", empty.Size(), err)
+	}
+}
+
+func TestInMemoryVectorStoreDelayedSnapshot(t *testing.T) {
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+	store := NewInMemoryVectorStore(testEmbeddingModel, snapshotPath)
+	store.SaveDelay = 50 * time.Millisecond
+
+	for _, text := range []string{"cat", "kitten", "car"} {
+		if err := store.Add(context.Background(), []Document{{ID: text, Text: text}}); err != nil {
+			t.Fatalf("Add 失败: %v", err)
+		}
+	}
+	if _, err := os.Stat(snapshotPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("延迟时间内不应写快照: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		loaded := NewInMemoryVectorStore(testEmbeddingModel, snapshotPath)
+		if err := loaded.Load(); err != nil {
+			t.Fatalf("Load 失败: %v", err)
+		}
+		if loaded.Size() == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("延迟写入后快照文档数量 = %d, 期望 3", loaded.Size())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Close 立即写入尚未落盘的变更
+	store.SaveDelay = time.Hour
+	if err := store.Delete(context.Background(), []string{"car"}); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+	loaded := NewInMemoryVectorStore(testEmbeddingModel, snapshotPath)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load 失败: %v", err)
+	}
+	if loaded.Size() != 2 {
+		t.Errorf("Close 后快照文档数量 = %d, 期望 2", loaded.Size())
+	}
+}
+
+func TestInMemoryVectorStoreListIDs(t *testing.T) {
+	store := newTestMemoryVectorStore(t, "")
+
+	ids, err := store.ListIDs(context.Background(), map[string]any{"source": "a.md"})
+	if err != nil {
+		t.Fatalf("ListIDs 失败: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "car" || ids[1] != "cat" {
+		t.Errorf("按来源查询结果 = %v", ids)
+	}
+
+	all, err := store.ListIDs(context.Background(), nil)
+	if err != nil || len(all) != 3 {
+		t.Errorf("查询全部结果 = %v, err = %v", all, err)
+	}
+}
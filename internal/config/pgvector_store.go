@@ -0,0 +1,385 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// PgVector 距离类型
+const (
+	PgDistanceCosine       = "cosine"
+	PgDistanceEuclidean    = "euclidean"
+	PgDistanceInnerProduct = "inner_product"
+)
+
+// PgVector 索引类型
+const (
+	PgIndexHnsw    = "hnsw"
+	PgIndexIvfFlat = "ivfflat"
+	PgIndexNone    = "none"
+)
+
+// defaultTopK 检索默认返回条数
+const defaultTopK = 4
+
+// pgVectorMaxIndexDimensions pgvector 索引支持的最大维度
+const pgVectorMaxIndexDimensions = 2000
+
+// pgIdentifierPattern 表名只允许字母、数字和下划线，防止 SQL 注入
+var pgIdentifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// pgDistance 距离类型对应的操作符、索引算子及得分换算
+type pgDistance struct {
+	operator string
+	opsClass string
+	// score 将数据库返回的距离换算为越大越相似的得分
+	score func(distance float64) float64
+	// maxDistance 将相似度阈值换算为距离上限
+	maxDistance func(threshold float64) float64
+}
+
+var pgDistances = map[string]pgDistance{
+	PgDistanceCosine: {
+		operator:    "<=>",
+		opsClass:    "vector_cosine_ops",
+		score:       func(d float64) float64 { return 1 - d },
+		maxDistance: func(t float64) float64 { return 1 - t },
+	},
+	PgDistanceEuclidean: {
+		operator:    "<->",
+		opsClass:    "vector_l2_ops",
+		score:       func(d float64) float64 { return 1 / (1 + d) },
+		maxDistance: func(t float64) float64 { return 1/t - 1 },
+	},
+	// <#> 返回负内积
+	PgDistanceInnerProduct: {
+		operator:    "<#>",
+		opsClass:    "vector_ip_ops",
+		score:       func(d float64) float64 { return -d },
+		maxDistance: func(t float64) float64 { return -t },
+	},
+}
+
+// NewPgVectorStore 创建 PG 向量存储，默认余弦距离 + HNSW 索引并自动建表
+func NewPgVectorStore(db *sql.DB, embeddingModel EmbeddingModel, tableName string) *PgVectorStore {
+	return &PgVectorStore{
+		DB:               db,
+		EmbeddingModel:   embeddingModel,
+		VectorTableName:  tableName,
+		DistanceType:     PgDistanceCosine,
+		IndexType:        PgIndexHnsw,
+		InitializeSchema: true,
+	}
+}
+
+// Initialize 初始化 pgvector 扩展、向量表及索引，可重复调用
+func (s *PgVectorStore) Initialize(ctx context.Context) error {
+	s.initMu.Lock()
+	defer s.initMu.Unlock()
+
+	if s.initialized {
+		return nil
+	}
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if !s.InitializeSchema {
+		s.initialized = true
+		return nil
+	}
+
+	dimensions := s.Dimensions
+	if dimensions <= 0 {
+		var err error
+		if dimensions, err = s.EmbeddingModel.Dimensions(ctx); err != nil {
+			return err
+		}
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id text PRIMARY KEY,
+	content text,
+	metadata jsonb,
+	embedding vector(%d)
+)`, s.VectorTableName, dimensions),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_metadata_idx ON %s USING gin (metadata jsonb_path_ops)`, s.VectorTableName, s.VectorTableName),
+	}
+
+	if indexSQL := s.indexSQL(dimensions); indexSQL != "" {
+		statements = append(statements, indexSQL)
+	}
+
+	for _, statement := range statements {
+		if _, err := s.DB.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("初始化向量表 %s 失败: %w", s.VectorTableName, err)
+		}
+	}
+
+	s.initialized = true
+	log.Printf("向量表 %s 初始化完成 dimensions=%d distance=%s index=%s", s.VectorTableName, dimensions, s.DistanceType, s.IndexType)
+	return nil
+}
+
+// Add 计算文档向量并写入，ID 已存在时覆盖
+func (s *PgVectorStore) Add(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := s.Initialize(ctx); err != nil {
+		return err
+	}
+	if err := embedDocuments(ctx, s.EmbeddingModel, docs); err != nil {
+		return err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s (id, content, metadata, embedding)
+VALUES ($1, $2, $3::jsonb, $4::vector)
+ON CONFLICT (id) DO UPDATE SET content = EXCLUDED.content, metadata = EXCLUDED.metadata, embedding = EXCLUDED.embedding`, s.VectorTableName))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := range docs {
+		if docs[i].ID == "" {
+			docs[i].ID = NewDocumentID()
+		}
+		metadata, err := json.Marshal(nonNilMetadata(docs[i].Metadata))
+		if err != nil {
+			return fmt.Errorf("序列化文档 %s 元数据失败: %w", docs[i].ID, err)
+		}
+		if _, err := stmt.ExecContext(ctx, docs[i].ID, docs[i].Text, string(metadata), formatPgVector(docs[i].Embedding)); err != nil {
+			return fmt.Errorf("写入文档 %s 失败: %w", docs[i].ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SimilaritySearch 相似度检索，threshold<=0 表示不过滤，filter 按元数据包含关系过滤
+func (s *PgVectorStore) SimilaritySearch(ctx context.Context, query string, topK int, threshold float64, filter map[string]any) ([]Document, error) {
+	if err := s.Initialize(ctx); err != nil {
+		return nil, err
+	}
+
+	embeddings, err := s.EmbeddingModel.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	return s.SimilaritySearchByVector(ctx, embeddings[0], topK, threshold, filter)
+}
+
+// SimilaritySearchByVector 按查询向量检索，维度与向量表不一致时由数据库报错
+func (s *PgVectorStore) SimilaritySearchByVector(ctx context.Context, embedding []float32, topK int, threshold float64, filter map[string]any) ([]Document, error) {
+	if err := s.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	if topK <= 0 {
+		topK = defaultTopK
+	}
+
+	distance := pgDistances[s.DistanceType]
+	args := []any{formatPgVector(embedding)}
+	var conditions []string
+	if len(filter) > 0 {
+		filterJSON, err := json.Marshal(filter)
+		if err != nil {
+			return nil, fmt.Errorf("序列化过滤条件失败: %w", err)
+		}
+		args = append(args, string(filterJSON))
+		conditions = append(conditions, fmt.Sprintf("metadata @> $%d::jsonb", len(args)))
+	}
+	if threshold > 0 {
+		args = append(args, distance.maxDistance(threshold))
+		conditions = append(conditions, fmt.Sprintf("embedding %s $1::vector <= $%d", distance.operator, len(args)))
+	}
+	args = append(args, topK)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	querySQL := fmt.Sprintf(`SELECT id, content, metadata, embedding %s $1::vector AS distance
+FROM %s %s
+ORDER BY distance
+LIMIT $%d`, distance.operator, s.VectorTableName, where, len(args))
+
+	rows, err := s.DB.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("向量检索失败: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []Document
+	for rows.Next() {
+		var (
+			doc      Document
+			metadata []byte
+			dist     float64
+		)
+		if err := rows.Scan(&doc.ID, &doc.Text, &metadata, &dist); err != nil {
+			return nil, err
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &doc.Metadata); err != nil {
+				return nil, fmt.Errorf("解析文档 %s 元数据失败: %w", doc.ID, err)
+			}
+		}
+		doc.Score = distance.score(dist)
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// ListIDs 按元数据包含关系查询文档ID，filter 为空时返回全部
+func (s *PgVectorStore) ListIDs(ctx context.Context, filter map[string]any) ([]string, error) {
+	if err := s.Initialize(ctx); err != nil {
+		return nil, err
+	}
+
+	querySQL := fmt.Sprintf(`SELECT id FROM %s`, s.VectorTableName)
+	var args []any
+	if len(filter) > 0 {
+		filterJSON, err := json.Marshal(filter)
+		if err != nil {
+			return nil, fmt.Errorf("序列化过滤条件失败: %w", err)
+		}
+		querySQL += ` WHERE metadata @> $1::jsonb`
+		args = append(args, string(filterJSON))
+	}
+
+	rows, err := s.DB.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询文档ID失败: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Delete 按ID删除文档
+func (s *PgVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.Initialize(ctx); err != nil {
+		return err
+	}
+	_, err := s.DB.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1)`, s.VectorTableName), pq.Array(ids))
+	return err
+}
+
+// validate 校验配置
+func (s *PgVectorStore) validate() error {
+	if s.DB == nil {
+		return errors.New("PgVectorStore 数据源为空")
+	}
+	if s.EmbeddingModel == nil {
+		return errors.New("PgVectorStore 嵌入模型为空")
+	}
+	if !pgIdentifierPattern.MatchString(s.VectorTableName) {
+		return fmt.Errorf("向量表名不合法: %s", s.VectorTableName)
+	}
+	if s.DistanceType == "" {
+		s.DistanceType = PgDistanceCosine
+	}
+	if _, ok := pgDistances[s.DistanceType]; !ok {
+		return fmt.Errorf("不支持的距离类型: %s", s.DistanceType)
+	}
+	return nil
+}
+
+// indexSQL 生成向量索引语句，维度超出 pgvector 索引上限时不建索引
+func (s *PgVectorStore) indexSQL(dimensions int) string {
+	opsClass := pgDistances[s.DistanceType].opsClass
+	indexName := s.VectorTableName + "_embedding_idx"
+
+	switch s.IndexType {
+	case PgIndexNone:
+		return ""
+	case PgIndexIvfFlat, PgIndexHnsw, "":
+	default:
+		log.Printf("未知索引类型 %s，使用 %s", s.IndexType, PgIndexHnsw)
+	}
+
+	if dimensions > pgVectorMaxIndexDimensions {
+		log.Printf("向量维度 %d 超过 pgvector 索引上限 %d，跳过建立向量索引", dimensions, pgVectorMaxIndexDimensions)
+		return ""
+	}
+	if s.IndexType == PgIndexIvfFlat {
+		return fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING ivfflat (embedding %s) WITH (lists = 100)`, indexName, s.VectorTableName, opsClass)
+	}
+	return fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING hnsw (embedding %s)`, indexName, s.VectorTableName, opsClass)
+}
+
+// embedDocuments 为缺少向量的文档批量计算向量
+func embedDocuments(ctx context.Context, embeddingModel EmbeddingModel, docs []Document) error {
+	var (
+		texts   []string
+		indexes []int
+	)
+	for i := range docs {
+		if len(docs[i].Embedding) == 0 {
+			texts = append(texts, docs[i].Text)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	embeddings, err := embeddingModel.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("计算文档向量失败: %w", err)
+	}
+	for i, index := range indexes {
+		docs[index].Embedding = embeddings[i]
+	}
+	return nil
+}
+
+// formatPgVector 转为 pgvector 文本格式 [1,2,3]
+func formatPgVector(embedding []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, v := range embedding {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// nonNilMetadata 保证元数据序列化为 JSON 对象
+func nonNilMetadata(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		return map[string]any{}
+	}
+	return metadata
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// 分块边界偏好
+const (
+	SplitBoundaryParagraph = "paragraph" // 优先段落，其次句子
+	SplitBoundarySentence  = "sentence"  // 仅句子
+	SplitBoundaryNone      = "none"      // 严格按 token 数切分
+)
+
+const (
+	defaultChunkSize      = 800
+	defaultMinChunkLength = 5
+	defaultMaxNumChunks   = 10000
+	defaultTokenEncoding  = string(tokenizer.Cl100kBase)
+)
+
+// 分块元数据键
+const (
+	ChunkMetadataSourceID    = "source_id"
+	ChunkMetadataChunkIndex  = "chunk_index"
+	ChunkMetadataStartOffset = "start_offset"
+	ChunkMetadataEndOffset   = "end_offset"
+	ChunkMetadataTokenCount  = "token_count"
+)
+
+// sentenceTerminators 句末标点
+const sentenceTerminators = ".!?。！？；;…"
+
+var (
+	codecCache   = make(map[string]tokenizer.Codec)
+	codecCacheMu sync.Mutex
+)
+
+// getCodec 获取分词器，词表加载较重，按编码缓存复用
+func getCodec(encoding string) (tokenizer.Codec, error) {
+	if encoding == "" {
+		encoding = defaultTokenEncoding
+	}
+
+	codecCacheMu.Lock()
+	defer codecCacheMu.Unlock()
+
+	if codec, ok := codecCache[encoding]; ok {
+		return codec, nil
+	}
+	codec, err := tokenizer.Get(tokenizer.Encoding(encoding))
+	if err != nil {
+		return nil, fmt.Errorf("不支持的 token 编码 %s: %w", encoding, err)
+	}
+	codecCache[encoding] = codec
+	return codec, nil
+}
+
+// NewTokenTextSplitter 创建文本分割器，默认 cl100k_base 编码、800 token 一块、优先按段落切分
+func NewTokenTextSplitter() *TokenTextSplitter {
+	return &TokenTextSplitter{
+		ChunkSize:      defaultChunkSize,
+		MinChunkLength: defaultMinChunkLength,
+		MaxNumChunks:   defaultMaxNumChunks,
+		Boundary:       SplitBoundaryParagraph,
+		Encoding:       defaultTokenEncoding,
+	}
+}
+
+// CountTokens 计算文本 token 数
+func (s *TokenTextSplitter) CountTokens(text string) (int, error) {
+	return CountTokens(s.Encoding, text)
+}
+
+// CountTokens 按指定编码计算文本 token 数，encoding 为空时使用 cl100k_base
+func CountTokens(encoding, text string) (int, error) {
+	codec, err := getCodec(encoding)
+	if err != nil {
+		return 0, err
+	}
+	return codec.Count(text)
+}
+
+// Apply 批量切分文档（模拟Java中的TextSplitter.apply）
+func (s *TokenTextSplitter) Apply(docs []Document) ([]Document, error) {
+	var chunks []Document
+	for _, doc := range docs {
+		docChunks, err := s.Split(doc)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, docChunks...)
+	}
+	return chunks, nil
+}
+
+// Split 按 token 数切分单个文档，分块继承源文档元数据并记录源文档ID、序号、字节偏移及 token 数
+// 分块ID由源文档ID和序号生成，同一文档重复切分得到相同ID
+func (s *TokenTextSplitter) Split(doc Document) ([]Document, error) {
+	if strings.TrimSpace(doc.Text) == "" {
+		return nil, nil
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	codec, err := getCodec(s.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	_, tokens, err := codec.Encode(doc.Text)
+	if err != nil {
+		return nil, fmt.Errorf("文档 %s 分词失败: %w", doc.ID, err)
+	}
+
+	// offsets[i] 为第 i 个 token 的起始字节，offsets[len(tokens)] 为文本长度
+	offsets := make([]int, len(tokens)+1)
+	for i, token := range tokens {
+		offsets[i+1] = offsets[i] + len(token)
+	}
+
+	text := doc.Text
+	sourceID := doc.ID
+	if sourceID == "" {
+		sourceID = NewDocumentID()
+	}
+
+	var chunks []Document
+	for start := 0; start < len(tokens) && len(chunks) < s.maxNumChunks(); {
+		end := start + s.ChunkSize
+		if end >= len(tokens) {
+			end = len(tokens)
+		} else {
+			end = s.preferredEnd(text, offsets, start, end)
+		}
+		end = alignRuneEnd(text, offsets, end)
+
+		if chunk, ok := s.newChunk(doc, sourceID, len(chunks), text, offsets[start], offsets[end], end-start); ok {
+			chunks = append(chunks, chunk)
+		}
+		if end >= len(tokens) {
+			break
+		}
+
+		next := end - s.ChunkOverlap
+		if next <= start {
+			next = end
+		}
+		start = alignRuneEnd(text, offsets, next)
+	}
+	return chunks, nil
+}
+
+// validate 校验配置
+func (s *TokenTextSplitter) validate() error {
+	if s.ChunkSize <= 0 {
+		return fmt.Errorf("chunk size 必须大于 0: %d", s.ChunkSize)
+	}
+	if s.ChunkOverlap < 0 || s.ChunkOverlap >= s.ChunkSize {
+		return fmt.Errorf("chunk overlap 必须在 [0, %d) 之间: %d", s.ChunkSize, s.ChunkOverlap)
+	}
+	switch s.Boundary {
+	case SplitBoundaryParagraph, SplitBoundarySentence, SplitBoundaryNone, "":
+	default:
+		return fmt.Errorf("不支持的分块边界: %s", s.Boundary)
+	}
+	return nil
+}
+
+// maxNumChunks 单个文档最多分块数
+func (s *TokenTextSplitter) maxNumChunks() int {
+	if s.MaxNumChunks <= 0 {
+		return defaultMaxNumChunks
+	}
+	return s.MaxNumChunks
+}
+
+// preferredEnd 在 [start+ChunkSize/2, end] 范围内寻找最靠后的段落或句子边界，找不到时返回 end
+func (s *TokenTextSplitter) preferredEnd(text string, offsets []int, start, end int) int {
+	if s.Boundary == SplitBoundaryNone {
+		return end
+	}
+
+	minEnd := start + s.ChunkSize/2
+	if minEnd <= start {
+		minEnd = start + 1
+	}
+
+	window := text[offsets[minEnd]:offsets[end]]
+	cut := -1
+	if s.Boundary != SplitBoundarySentence {
+		if i := strings.LastIndex(window, "\n\n"); i >= 0 {
+			cut = i + 2
+		}
+	}
+	if cut < 0 {
+		cut = lastSentenceEnd(window)
+	}
+	if cut <= 0 {
+		return end
+	}
+
+	// 边界可能落在 token 内部，取包含该边界的 token 的结尾
+	pos := offsets[minEnd] + cut
+	for i := minEnd; i <= end; i++ {
+		if offsets[i] >= pos {
+			return i
+		}
+	}
+	return end
+}
+
+// lastSentenceEnd 返回最后一个句末标点或换行之后的字节位置，没有时返回 -1
+func lastSentenceEnd(text string) int {
+	for i := len(text); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(text[:i])
+		if r == '\n' {
+			return i
+		}
+		if strings.ContainsRune(sentenceTerminators, r) {
+			// 英文句号需后跟空白，避免切断小数和缩写
+			if r < utf8.RuneSelf && i < len(text) {
+				next, _ := utf8.DecodeRuneInString(text[i:])
+				if !unicode.IsSpace(next) {
+					i -= size
+					continue
+				}
+			}
+			return i
+		}
+		i -= size
+	}
+	return -1
+}
+
+// alignRuneEnd 字节级 BPE 可能把多字节字符拆到多个 token，向后移动到完整字符边界
+func alignRuneEnd(text string, offsets []int, index int) int {
+	for index < len(offsets)-1 && !utf8.RuneStart(text[offsets[index]]) {
+		index++
+	}
+	return index
+}
+
+// newChunk 构造分块文档，去除首尾空白后过短的分块丢弃
+func (s *TokenTextSplitter) newChunk(doc Document, sourceID string, index int, text string, startOffset, endOffset, tokenCount int) (Document, bool) {
+	content := text[startOffset:endOffset]
+	trimmedLeft := strings.TrimLeftFunc(content, unicode.IsSpace)
+	startOffset += len(content) - len(trimmedLeft)
+	content = strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+	endOffset = startOffset + len(content)
+
+	if utf8.RuneCountInString(content) < s.MinChunkLength || content == "" {
+		return Document{}, false
+	}
+
+	metadata := make(map[string]any, len(doc.Metadata)+5)
+	for key, value := range doc.Metadata {
+		metadata[key] = value
+	}
+	metadata[ChunkMetadataSourceID] = sourceID
+	metadata[ChunkMetadataChunkIndex] = index
+	metadata[ChunkMetadataStartOffset] = startOffset
+	metadata[ChunkMetadataEndOffset] = endOffset
+	metadata[ChunkMetadataTokenCount] = tokenCount
+
+	return Document{
+		ID:       NewNameBasedDocumentID(fmt.Sprintf("%s#%d", sourceID, index)),
+		Text:     content,
+		Metadata: metadata,
+	}, true
+}
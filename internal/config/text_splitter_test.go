@@ -0,0 +1,278 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// newTestTextSplitter 不丢弃短分块的文本分割器
+func newTestTextSplitter(chunkSize, chunkOverlap int, boundary string) *TokenTextSplitter {
+	splitter := NewTokenTextSplitter()
+	splitter.ChunkSize = chunkSize
+	splitter.ChunkOverlap = chunkOverlap
+	splitter.Boundary = boundary
+	splitter.MinChunkLength = 1
+	return splitter
+}
+
+// splitTexts 切分文本并校验分块元数据，返回分块内容和 token 数
+func splitTexts(t *testing.T, splitter *TokenTextSplitter, text string) ([]string, []int) {
+	t.Helper()
+	doc := Document{ID: "doc-1", Text: text, Metadata: map[string]any{"source": "test.md"}}
+	chunks, err := splitter.Split(doc)
+	if err != nil {
+		t.Fatalf("切分失败: %v", err)
+	}
+
+	var (
+		texts  []string
+		tokens []int
+	)
+	for i, chunk := range chunks {
+		if !utf8.ValidString(chunk.Text) {
+			t.Errorf("第 %d 块不是合法 UTF-8: %q", i, chunk.Text)
+		}
+		start, end := chunk.Metadata[ChunkMetadataStartOffset].(int), chunk.Metadata[ChunkMetadataEndOffset].(int)
+		if text[start:end] != chunk.Text {
+			t.Errorf("第 %d 块偏移 [%d,%d) = %q, 内容 %q", i, start, end, text[start:end], chunk.Text)
+		}
+		if chunk.Metadata[ChunkMetadataSourceID] != "doc-1" || chunk.Metadata[ChunkMetadataChunkIndex] != i || chunk.Metadata["source"] != "test.md" {
+			t.Errorf("第 %d 块元数据 = %v", i, chunk.Metadata)
+		}
+		texts = append(texts, chunk.Text)
+		tokens = append(tokens, chunk.Metadata[ChunkMetadataTokenCount].(int))
+	}
+	return texts, tokens
+}
+
+func TestTokenTextSplitterSplit(t *testing.T) {
+	const words = "one two three four five six seven eight nine ten"
+	tests := []struct {
+		name       string
+		chunkSize  int
+		overlap    int
+		boundary   string
+		text       string
+		wantTexts  []string
+		wantTokens []int
+	}{
+		{
+			name:       "按 chunk size 切分",
+			chunkSize:  4,
+			boundary:   SplitBoundaryNone,
+			text:       words,
+			wantTexts:  []string{"one two three four", "five six seven eight", "nine ten"},
+			wantTokens: []int{4, 4, 2},
+		},
+		{
+			name:       "文本不足一块",
+			chunkSize:  20,
+			boundary:   SplitBoundaryParagraph,
+			text:       "  " + words + "\n",
+			wantTexts:  []string{words},
+			wantTokens: []int{12},
+		},
+		{
+			name:       "相邻分块重叠",
+			chunkSize:  4,
+			overlap:    1,
+			boundary:   SplitBoundaryNone,
+			text:       words,
+			wantTexts:  []string{"one two three four", "four five six seven", "seven eight nine ten"},
+			wantTokens: []int{4, 4, 4},
+		},
+		{
+			name:       "优先在段落处切分",
+			chunkSize:  10,
+			boundary:   SplitBoundaryParagraph,
+			text:       "one two three four five six.\n\nseven eight. nine ten eleven twelve",
+			wantTexts:  []string{"one two three four five six.", "seven eight. nine ten eleven twelve"},
+			wantTokens: []int{7, 7},
+		},
+		{
+			name:       "仅句子边界时忽略段落",
+			chunkSize:  10,
+			boundary:   SplitBoundarySentence,
+			text:       "one two three four five six.\n\nseven eight. nine ten eleven twelve",
+			wantTexts:  []string{"one two three four five six.\n\nseven eight.", "nine ten eleven twelve"},
+			wantTokens: []int{10, 4},
+		},
+		{
+			name:       "中文句末标点",
+			chunkSize:  10,
+			boundary:   SplitBoundarySentence,
+			text:       "今天天气很好。我们去西湖散步",
+			wantTexts:  []string{"今天天气很好。", "我们去西湖散步"},
+			wantTokens: []int{9, 8},
+		},
+		{
+			name:       "边界早于半块时按 token 数切分",
+			chunkSize:  6,
+			boundary:   SplitBoundaryParagraph,
+			text:       "one.\n\ntwo three four five six seven eight",
+			wantTexts:  []string{"one.\n\ntwo three four five", "six seven eight"},
+			wantTokens: []int{6, 3},
+		},
+		{
+			name:       "严格按 token 数时忽略标点",
+			chunkSize:  4,
+			boundary:   SplitBoundaryNone,
+			text:       "one two. three four five",
+			wantTexts:  []string{"one two. three", "four five"},
+			wantTokens: []int{4, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			texts, tokens := splitTexts(t, newTestTextSplitter(tt.chunkSize, tt.overlap, tt.boundary), tt.text)
+			if !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("分块 = %q, 期望 %q", texts, tt.wantTexts)
+			}
+			if !reflect.DeepEqual(tokens, tt.wantTokens) {
+				t.Errorf("token 数 = %v, 期望 %v", tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestTokenTextSplitterAlignsMultibyteRunes(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunkSize  int
+		overlap    int
+		text       string
+		wantTexts  []string
+		wantTokens []int
+	}{
+		{
+			// "杭"、"湖" 各被拆成 2 个 token，emoji 被拆成 2 到 3 个 token
+			name:       "每块一个 token",
+			chunkSize:  1,
+			text:       "杭州西湖😀🎉",
+			wantTexts:  []string{"杭", "州", "西", "湖", "😀", "🎉"},
+			wantTokens: []int{2, 1, 1, 2, 2, 3},
+		},
+		{
+			name:       "重叠起点对齐到字符边界",
+			chunkSize:  3,
+			overlap:    1,
+			text:       "杭州西湖",
+			wantTexts:  []string{"杭州", "州西湖"},
+			wantTokens: []int{3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			texts, tokens := splitTexts(t, newTestTextSplitter(tt.chunkSize, tt.overlap, SplitBoundaryNone), tt.text)
+			if !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("分块 = %q, 期望 %q", texts, tt.wantTexts)
+			}
+			if !reflect.DeepEqual(tokens, tt.wantTokens) {
+				t.Errorf("token 数 = %v, 期望 %v", tokens, tt.wantTokens)
+			}
+		})
+	}
+
+	// 不同分块大小下分块都是完整字符，拼接后还原原文
+	text := "西湖边的天气😀晴朗，👨‍👩‍👧一家人去散步🎉。"
+	for chunkSize := 1; chunkSize <= 8; chunkSize++ {
+		texts, _ := splitTexts(t, newTestTextSplitter(chunkSize, 0, SplitBoundaryNone), text)
+		if joined := strings.Join(texts, ""); joined != text {
+			t.Errorf("chunkSize=%d 拼接结果 = %q", chunkSize, joined)
+		}
+	}
+}
+
+func TestAlignRuneEnd(t *testing.T) {
+	// "杭" 拆成 2 个 token，"州" 为 1 个 token
+	text := "杭州"
+	offsets := []int{0, 2, 3, 6}
+	tests := []struct {
+		name  string
+		index int
+		want  int
+	}{
+		{name: "字符起点", index: 0, want: 0},
+		{name: "字符中间向后对齐", index: 1, want: 2},
+		{name: "下一个字符起点", index: 2, want: 2},
+		{name: "文本末尾", index: 3, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alignRuneEnd(text, offsets, tt.index); got != tt.want {
+				t.Errorf("alignRuneEnd(%d) = %d, 期望 %d", tt.index, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastSentenceEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "英文句号后跟空白", text: "one. two", want: 4},
+		{name: "小数点不是句末", text: "pi is 3.14 ok", want: -1},
+		{name: "文本末尾的句号", text: "one two.", want: 8},
+		{name: "中文句号", text: "你好。世界", want: len("你好。")},
+		{name: "换行", text: "one\ntwo", want: 4},
+		{name: "取最后一个", text: "一！二？三", want: len("一！二？")},
+		{name: "没有边界", text: "one two", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lastSentenceEnd(tt.text); got != tt.want {
+				t.Errorf("lastSentenceEnd(%q) = %d, 期望 %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenTextSplitterLimitsAndValidation(t *testing.T) {
+	splitter := newTestTextSplitter(2, 0, SplitBoundaryNone)
+	splitter.MaxNumChunks = 2
+	texts, _ := splitTexts(t, splitter, "one two three four five six")
+	if !reflect.DeepEqual(texts, []string{"one two", "three four"}) {
+		t.Errorf("最多分块数限制后 = %q", texts)
+	}
+
+	// 过短的分块丢弃，序号连续
+	splitter = newTestTextSplitter(2, 0, SplitBoundaryNone)
+	splitter.MinChunkLength = 9
+	if texts, _ := splitTexts(t, splitter, "one two three four five six"); !reflect.DeepEqual(texts, []string{"three four"}) {
+		t.Errorf("丢弃短分块后 = %q", texts)
+	}
+
+	// 同一文档重复切分得到相同ID
+	first, _ := newTestTextSplitter(2, 0, SplitBoundaryNone).Split(Document{ID: "doc-1", Text: "one two three"})
+	second, _ := newTestTextSplitter(2, 0, SplitBoundaryNone).Split(Document{ID: "doc-1", Text: "one two three"})
+	if len(first) != 2 || first[0].ID != second[0].ID || first[0].ID == first[1].ID {
+		t.Errorf("分块ID = %v / %v", first, second)
+	}
+
+	tests := []struct {
+		name     string
+		splitter *TokenTextSplitter
+		wantErr  string
+	}{
+		{name: "chunk size 为 0", splitter: newTestTextSplitter(0, 0, SplitBoundaryNone), wantErr: "chunk size 必须大于 0"},
+		{name: "重叠为负数", splitter: newTestTextSplitter(4, -1, SplitBoundaryNone), wantErr: "chunk overlap 必须在 [0, 4) 之间"},
+		{name: "重叠不小于 chunk size", splitter: newTestTextSplitter(4, 4, SplitBoundaryNone), wantErr: "chunk overlap 必须在 [0, 4) 之间"},
+		{name: "未知边界", splitter: newTestTextSplitter(4, 0, "word"), wantErr: "不支持的分块边界"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.splitter.Split(Document{ID: "doc-1", Text: "one two"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("错误 = %v, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if chunks, err := newTestTextSplitter(0, 0, "word").Split(Document{Text: " \n "}); err != nil || chunks != nil {
+		t.Errorf("空白文档 = %v, %v, 期望直接返回空", chunks, err)
+	}
+}
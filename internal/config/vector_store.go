@@ -0,0 +1,28 @@
+package config
+
+import "context"
+
+// 向量存储类型
+const (
+	VectorStoreTypePgVector = "pgvector"
+	VectorStoreTypeMemory   = "memory"
+)
+
+// VectorStore 向量存储（模拟Java中的VectorStore）
+type VectorStore interface {
+	// Add 计算文档向量并写入，ID 已存在时覆盖
+	Add(ctx context.Context, docs []Document) error
+	// SimilaritySearch 相似度检索，threshold<=0 表示不过滤，filter 按元数据包含关系过滤
+	SimilaritySearch(ctx context.Context, query string, topK int, threshold float64, filter map[string]any) ([]Document, error)
+	// SimilaritySearchByVector 按已计算的查询向量检索，向量须与存储使用同一嵌入模型生成
+	SimilaritySearchByVector(ctx context.Context, embedding []float32, topK int, threshold float64, filter map[string]any) ([]Document, error)
+	// ListIDs 按元数据包含关系查询文档ID，filter 为空时返回全部
+	ListIDs(ctx context.Context, filter map[string]any) ([]string, error)
+	// Delete 按ID删除文档
+	Delete(ctx context.Context, ids []string) error
+}
+
+var (
+	_ VectorStore = (*PgVectorStore)(nil)
+	_ VectorStore = (*InMemoryVectorStore)(nil)
+)
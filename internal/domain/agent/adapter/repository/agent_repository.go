@@ -3,9 +3,33 @@ package repository
 import "smart-weaver/internal/domain/agent/model/valobj"
 
 type IAgentRepository interface {
-	// QueryAiClientModelVOListByClientIDs 根据 clientId 列表查询 AiClientModelVO
-	QueryAiClientModelVOListByClientIDs(clientIDList []int64) ([]valobj.AiClientModelVO, error)
+	// QueryEnabledClientIds 查询所有启用的 clientId
+	QueryEnabledClientIds() ([]int64, error)
 
-	// QueryAiClientToolMcpVOListByClientIDs 根据 clientId 列表查询 AiClientToolMcpVO
-	QueryAiClientToolMcpVOListByClientIDs(clientIDList []int64) ([]valobj.AiClientToolMcpVO, error)
+	// QueryClientConfigVersions 查询启用客户端的配置版本，客户端、模型组、模型、MCP、顾问或系统提示词配置变化时版本变化
+	QueryClientConfigVersions() (map[int64]string, error)
+
+	// QueryAiClientVOListByClientIds 根据 clientId 列表查询 AiClientVO
+	QueryAiClientVOListByClientIds(clientIdList []int64) ([]valobj.AiClientVO, error)
+
+	// QueryAiClientModelVOListByClientIds 根据 clientId 列表查询 AiClientModelVO
+	QueryAiClientModelVOListByClientIds(clientIdList []int64) ([]valobj.AiClientModelVO, error)
+
+	// QueryAiClientToolMcpVOListByClientIds 根据 clientId 列表查询 AiClientToolMcpVO
+	QueryAiClientToolMcpVOListByClientIds(clientIdList []int64) ([]valobj.AiClientToolMcpVO, error)
+
+	// QueryAiClientAdvisorVOListByClientIds 根据 clientId 列表查询 AiClientAdvisorVO
+	QueryAiClientAdvisorVOListByClientIds(clientIdList []int64) ([]valobj.AiClientAdvisorVO, error)
+
+	// QueryAiClientSystemPromptVOListByClientIds 根据 clientId 列表查询生效的 AiClientSystemPromptVO
+	QueryAiClientSystemPromptVOListByClientIds(clientIdList []int64) ([]valobj.AiClientSystemPromptVO, error)
+
+	// QueryAiClientSystemPromptVersions 查询客户端同名提示词的全部版本，新版本在前
+	QueryAiClientSystemPromptVersions(clientId int64, promptName string) ([]valobj.AiClientSystemPromptVersionVO, error)
+
+	// SaveAiClientSystemPromptVersion 新增提示词版本并设为生效，回填 ID、版本号和创建时间
+	SaveAiClientSystemPromptVersion(prompt *valobj.AiClientSystemPromptVersionVO) error
+
+	// ActivateAiClientSystemPromptVersion 将指定版本设为生效，用于回滚
+	ActivateAiClientSystemPromptVersion(clientId int64, promptName string, version int) error
 }
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+)
+
+type IUsageRepository interface {
+	// QueryModelPrice 查询模型价格，未配置时返回 nil
+	QueryModelPrice(modelId int64) (*valobj.AiModelPriceVO, error)
+
+	// SaveUsageLedger 保存一条用量流水
+	SaveUsageLedger(ledger *entity.AiUsageLedgerEntity) error
+
+	// QueryUsageSummary 按维度聚合用量
+	QueryUsageSummary(query *entity.UsageQueryEntity) ([]valobj.UsageSummaryVO, error)
+}
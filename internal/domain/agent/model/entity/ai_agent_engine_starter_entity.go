@@ -2,5 +2,6 @@ package entity
 
 // AiAgentEngineStarterEntity 引擎启动器实体对象
 type AiAgentEngineStarterEntity struct {
-	ClientIDList []int64 `json:"client_id_list"`
+	ClientIDList    []int64           `json:"client_id_list"`
+	PromptVariables map[string]string `json:"prompt_variables"` // 系统提示词 {{变量}} 取值，优先于动态上下文，可为空
 }
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// AiUsageLedgerEntity 模型用量流水实体对象
+type AiUsageLedgerEntity struct {
+	ModelID          int64     `json:"model_id"`
+	ModelName        string    `json:"model_name"`
+	ClientID         int64     `json:"client_id"`
+	ConversationID   string    `json:"conversation_id"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CachedTokens     int       `json:"cached_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+	Currency         string    `json:"currency"`
+	CreateTime       time.Time `json:"create_time"`
+}
+
+// UsageQueryEntity 用量聚合查询条件，时间范围为 [StartTime, EndTime)
+type UsageQueryEntity struct {
+	GroupBy   []string  `json:"group_by"` // day / client / model / conversation
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	ClientID  int64     `json:"client_id"` // 为0时不过滤
+	ModelID   int64     `json:"model_id"`  // 为0时不过滤
+}
@@ -0,0 +1,35 @@
+package valobj
+
+// 顾问类型
+const (
+	AdvisorTypeRagAnswer  = "RagAnswer"  // 知识库问答
+	AdvisorTypeChatMemory = "ChatMemory" // 对话记忆
+)
+
+// AiClientAdvisorVO 顾问 VO 对象
+type AiClientAdvisorVO struct {
+	ID          int64         `json:"id"`
+	ClientID    int64         `json:"client_id"`
+	AdvisorName string        `json:"advisor_name"`
+	AdvisorType string        `json:"advisor_type"` // RagAnswer / ChatMemory
+	OrderNum    int           `json:"order_num"`    // 越小越先执行
+	RagAnswer   *RagAnswerVO  `json:"rag_answer"`   // 知识库问答配置，可为空
+	ChatMemory  *ChatMemoryVO `json:"chat_memory"`  // 对话记忆配置，可为空
+}
+
+// RagAnswerVO 知识库问答配置
+type RagAnswerVO struct {
+	TopK                int            `json:"topK"`
+	SimilarityThreshold float64        `json:"similarityThreshold"`
+	FilterExpression    map[string]any `json:"filterExpression"` // 元数据过滤，如 {"knowledge": "xxx"}
+	UserTextAdvise      string         `json:"userTextAdvise"`   // 注入模板，为空时使用默认模板
+	EmbeddingModelID    int64          `json:"embeddingModelId"` // 计算查询向量的嵌入模型，为 0 时使用向量存储的嵌入模型
+}
+
+// ChatMemoryVO 对话记忆配置
+type ChatMemoryVO struct {
+	MaxMessages int    `json:"maxMessages"` // 保留的最大消息条数，为 0 时使用默认值
+	MaxTokens   int    `json:"maxTokens"`   // 历史消息 token 预算，为 0 时不限制
+	Storage     string `json:"storage"`     // in_memory / mysql / redis，为空时使用 in_memory
+	Summarize   bool   `json:"summarize"`   // 是否将淘汰的消息压缩为摘要
+}
@@ -2,6 +2,14 @@ package valobj
 
 import "time"
 
+// 模型类型，不区分大小写，为空时按 openai 处理
+const (
+	ModelTypeOpenAI    = "openai"    // OpenAI 及兼容接口
+	ModelTypeAzure     = "azure"     // Azure OpenAI，ModelVersion 为部署名称
+	ModelTypeAnthropic = "anthropic" // Anthropic Messages API
+	ModelTypeOllama    = "ollama"    // Ollama 原生接口
+)
+
 type AiClientModelVO struct {
 	ID                       int64                       `json:"id"`
 	ModelName                string                      `json:"model_name"`
@@ -9,9 +17,14 @@ type AiClientModelVO struct {
 	APIKey                   string                      `json:"api_key"`
 	CompletionsPath          string                      `json:"completions_path"`
 	EmbeddingsPath           string                      `json:"embeddings_path"`
-	ModelType                string                      `json:"model_type"` // openai/azure 等
+	ModelType                string                      `json:"model_type"` // openai / azure / anthropic / ollama，包含 embedding 时为嵌入模型
 	ModelVersion             string                      `json:"model_version"`
-	Timeout                  int                         `json:"timeout"` // 秒
+	Timeout                  int                         `json:"timeout"`          // 秒
+	RpmLimit                 int                         `json:"rpm_limit"`        // 每分钟请求数上限，0 表示不限制
+	TpmLimit                 int                         `json:"tpm_limit"`        // 每分钟 token 数上限，0 表示不限制
+	MaxConcurrency           int                         `json:"max_concurrency"`  // 最大并发请求数，0 表示不限制
+	QueueTimeout             int                         `json:"queue_timeout"`    // 超过限制时排队等待的秒数，0 表示使用默认值
+	ToolNamePrefix           string                      `json:"tool_name_prefix"` // 模型默认 MCP 工具命名策略 on_conflict / always / never，为空时为 on_conflict
 	AIClientModelToolConfigs []AIClientModelToolConfigVO `json:"ai_client_model_tool_configs"`
 }
 
@@ -0,0 +1,24 @@
+package valobj
+
+import "time"
+
+// AiClientSystemPromptVO 系统提示词 VO 对象，只包含生效版本
+type AiClientSystemPromptVO struct {
+	ID            int64  `json:"id"`
+	ClientID      int64  `json:"client_id"`
+	PromptName    string `json:"prompt_name"`
+	PromptContent string `json:"prompt_content"` // 支持 {{变量}} 占位符
+	Version       int    `json:"version"`
+}
+
+// AiClientSystemPromptVersionVO 系统提示词版本 VO 对象，用于查看历史版本和回滚
+type AiClientSystemPromptVersionVO struct {
+	ID            int64     `json:"id"`
+	ClientID      int64     `json:"client_id"`
+	PromptName    string    `json:"prompt_name"`
+	PromptContent string    `json:"prompt_content"`
+	Version       int       `json:"version"`
+	Description   string    `json:"description"`
+	Active        bool      `json:"active"` // 是否为生效版本
+	CreateTime    time.Time `json:"create_time"`
+}
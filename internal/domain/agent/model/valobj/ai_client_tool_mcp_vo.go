@@ -2,12 +2,13 @@ package valobj
 
 // AiClientToolMcpVO MCP VO 对象
 type AiClientToolMcpVO struct {
-	ID                   int64                 `json:"id"`
-	McpName              string                `json:"mcp_name"`
-	TransportType        string                `json:"transport_type"`         // sse / stdio
-	TransportConfigSse   *TransportConfigSse   `json:"transport_config_sse"`   // SSE 配置，可为空
-	TransportConfigStdio *TransportConfigStdio `json:"transport_config_stdio"` // STDIO 配置，可为空
-	RequestTimeout       int                   `json:"request_timeout"`        // 分钟
+	ID                            int64                          `json:"id"`
+	McpName                       string                         `json:"mcp_name"`
+	TransportType                 string                         `json:"transport_type"`                   // sse / stdio / streamable_http
+	TransportConfigSse            *TransportConfigSse            `json:"transport_config_sse"`             // SSE 配置，可为空
+	TransportConfigStdio          *TransportConfigStdio          `json:"transport_config_stdio"`           // STDIO 配置，可为空
+	TransportConfigStreamableHttp *TransportConfigStreamableHttp `json:"transport_config_streamable_http"` // Streamable HTTP 配置，可为空
+	RequestTimeout                int                            `json:"request_timeout"`                  // 分钟
 }
 
 // TransportConfigSse SSE 配置
@@ -16,6 +17,13 @@ type TransportConfigSse struct {
 	SseEndpoint string `json:"sse_endpoint"`
 }
 
+// TransportConfigStreamableHttp Streamable HTTP 配置
+type TransportConfigStreamableHttp struct {
+	BaseURI  string            `json:"base_uri"`
+	Endpoint string            `json:"endpoint"` // 默认 /mcp
+	Headers  map[string]string `json:"headers"`  // 附加请求头，如 Authorization，可为空
+}
+
 // TransportConfigStdio STDIO 配置
 type TransportConfigStdio struct {
 	Stdio map[string]Stdio `json:"stdio"` // key 对应 mcp-server 名称
@@ -23,6 +31,7 @@ type TransportConfigStdio struct {
 
 // Stdio STDIO 命令配置
 type Stdio struct {
-	Command string   `json:"command"`
-	Args    []string `json:"args"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"` // 附加环境变量，可为空
 }
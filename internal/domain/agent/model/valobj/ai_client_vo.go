@@ -0,0 +1,29 @@
+package valobj
+
+// AiClientVO 客户端 VO 对象
+type AiClientVO struct {
+	ClientID    int64                `json:"client_id"`
+	ClientName  string               `json:"client_name"`
+	ModelID     int64                `json:"model_id"`    // 对应 AiClientModel_<modelId>
+	ModelRels   []AiClientModelRelVO `json:"model_rels"`  // 模型组，为空时只使用 ModelID
+	McpIDList   []int64              `json:"mcp_id_list"` // 客户端级 MCP 工具，与模型默认工具合并，可为空
+	Description string               `json:"description"`
+
+	ToolNamePrefix string `json:"tool_name_prefix"` // 客户端级 MCP 工具命名策略 on_conflict / always / never，为空时为 on_conflict
+}
+
+// AiClientModelRelVO 模型组成员
+type AiClientModelRelVO struct {
+	ID       int64 `json:"id"`
+	ModelID  int64 `json:"model_id"`
+	Priority int   `json:"priority"` // 越小越优先
+	Weight   int   `json:"weight"`   // 同优先级按权重轮询
+}
+
+// GetModelRels 获取客户端使用的模型，未配置模型组时为 ModelID 对应的单个模型
+func (vo AiClientVO) GetModelRels() []AiClientModelRelVO {
+	if len(vo.ModelRels) > 0 {
+		return vo.ModelRels
+	}
+	return []AiClientModelRelVO{{ModelID: vo.ModelID, Weight: 1}}
+}
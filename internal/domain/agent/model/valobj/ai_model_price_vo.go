@@ -0,0 +1,22 @@
+package valobj
+
+// AiModelPriceVO 模型价格，单价按每百万 token 计
+type AiModelPriceVO struct {
+	ModelID          int64   `json:"model_id"`
+	InputPrice       float64 `json:"input_price"`
+	OutputPrice      float64 `json:"output_price"`
+	CachedInputPrice float64 `json:"cached_input_price"` // 为0时按输入单价计
+	Currency         string  `json:"currency"`
+}
+
+// Cost 计算费用，cachedTokens 包含在 promptTokens 中
+func (p *AiModelPriceVO) Cost(promptTokens, completionTokens, cachedTokens int) float64 {
+	cachedPrice := p.CachedInputPrice
+	if cachedPrice <= 0 {
+		cachedPrice = p.InputPrice
+	}
+	cachedTokens = min(cachedTokens, promptTokens)
+	return (float64(promptTokens-cachedTokens)*p.InputPrice +
+		float64(cachedTokens)*cachedPrice +
+		float64(completionTokens)*p.OutputPrice) / 1_000_000
+}
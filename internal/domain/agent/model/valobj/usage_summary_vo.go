@@ -0,0 +1,24 @@
+package valobj
+
+// 用量聚合维度
+const (
+	UsageGroupByDay          = "day"
+	UsageGroupByClient       = "client"
+	UsageGroupByModel        = "model"
+	UsageGroupByConversation = "conversation"
+)
+
+// UsageSummaryVO 用量聚合结果，未参与分组的维度为零值
+type UsageSummaryVO struct {
+	Day              string  `json:"day,omitempty"`
+	ClientID         int64   `json:"client_id,omitempty"`
+	ModelID          int64   `json:"model_id,omitempty"`
+	ModelName        string  `json:"model_name,omitempty"`
+	ConversationID   string  `json:"conversation_id,omitempty"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
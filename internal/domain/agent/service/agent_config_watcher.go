@@ -0,0 +1,111 @@
+package service
+
+import (
+	stdcontext "context"
+	"log"
+	"sort"
+	"time"
+
+	"smart-weaver/internal/domain/agent/adapter/repository"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+)
+
+// clientRebuilder 重新构建和卸载客户端，由 AgentService 实现
+type clientRebuilder interface {
+	RebuildClients(clientIdList []int64) (*node.ArmoryReport, error)
+	UnloadClients(clientIdList []int64)
+}
+
+// AgentConfigWatcher 客户端配置变更监听，定时比较客户端配置版本
+// 版本变化或新启用的客户端重新构建并原子替换，停用或删除的客户端卸载
+type AgentConfigWatcher struct {
+	repository   repository.IAgentRepository
+	agentService clientRebuilder
+	interval     time.Duration
+	versions     map[int64]string // clientId -> 已构建的配置版本
+}
+
+// NewAgentConfigWatcher 创建配置变更监听
+func NewAgentConfigWatcher(repository repository.IAgentRepository, agentService *AgentService, interval time.Duration) *AgentConfigWatcher {
+	return &AgentConfigWatcher{
+		repository:   repository,
+		agentService: agentService,
+		interval:     interval,
+	}
+}
+
+// Start 同步检查一次配置，构建全部启用的客户端，之后在后台定时检查，ctx 取消时停止
+// 只记录构建成功的客户端版本，首次构建失败的客户端在后续检查中重试；首次检查失败时仍启动定时检查并返回错误
+func (w *AgentConfigWatcher) Start(ctx stdcontext.Context) error {
+	w.versions = make(map[int64]string)
+	err := w.Poll()
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.Poll(); err != nil {
+					log.Printf("检查客户端配置变更失败: %v", err)
+				}
+			}
+		}
+	}()
+	return err
+}
+
+// Poll 检查一次配置变更，沿用客户端上次构建的提示词变量重新构建，只记录构建成功的客户端版本，失败的客户端下次检查时重试
+func (w *AgentConfigWatcher) Poll() error {
+	versions, err := w.repository.QueryClientConfigVersions()
+	if err != nil {
+		return err
+	}
+
+	var changed, removed []int64
+	for clientID, version := range versions {
+		if w.versions[clientID] != version {
+			changed = append(changed, clientID)
+		}
+	}
+	for clientID := range w.versions {
+		if _, ok := versions[clientID]; !ok {
+			removed = append(removed, clientID)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i] < changed[j] })
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+
+	if len(removed) > 0 {
+		log.Printf("客户端已停用或删除，卸载 clientIdList=%v", removed)
+		w.agentService.UnloadClients(removed)
+		for _, clientID := range removed {
+			delete(w.versions, clientID)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	log.Printf("客户端配置已变更，重新构建 clientIdList=%v", changed)
+	report, err := w.agentService.RebuildClients(changed)
+	if err != nil {
+		return err
+	}
+
+	for _, failure := range report.Failed {
+		log.Printf("Bean %s 重新构建失败: %s", failure.Bean, failure.Reason)
+	}
+	built := make(map[string]bool, len(report.Built))
+	for _, bean := range report.Built {
+		built[bean] = true
+	}
+	for _, clientID := range changed {
+		if built[clientBeanName(clientID)] {
+			w.versions[clientID] = versions[clientID]
+		}
+	}
+	return nil
+}
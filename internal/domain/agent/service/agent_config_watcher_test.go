@@ -0,0 +1,184 @@
+package service
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"smart-weaver/internal/domain/agent/adapter/repository"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+)
+
+// fakeVersionRepository 只实现配置版本查询的仓储
+type fakeVersionRepository struct {
+	repository.IAgentRepository
+	versions map[int64]string
+	err      error
+}
+
+func (r *fakeVersionRepository) QueryClientConfigVersions() (map[int64]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	versions := make(map[int64]string, len(r.versions))
+	for clientID, version := range r.versions {
+		versions[clientID] = version
+	}
+	return versions, nil
+}
+
+// fakeClientRebuilder 记录重新构建和卸载的客户端，failing 中的客户端构建失败，err 不为空时整体失败
+type fakeClientRebuilder struct {
+	rebuilt  [][]int64
+	unloaded [][]int64
+	failing  map[int64]bool
+	err      error
+}
+
+func (b *fakeClientRebuilder) RebuildClients(clientIdList []int64) (*node.ArmoryReport, error) {
+	b.rebuilt = append(b.rebuilt, append([]int64(nil), clientIdList...))
+	if b.err != nil {
+		return nil, b.err
+	}
+	report := &node.ArmoryReport{Built: []string{}, Failed: []node.ArmoryFailure{}}
+	for _, clientID := range clientIdList {
+		if b.failing[clientID] {
+			report.Failed = append(report.Failed, node.ArmoryFailure{Bean: clientBeanName(clientID), Reason: "模型不可用"})
+			continue
+		}
+		report.Built = append(report.Built, clientBeanName(clientID))
+	}
+	return report, nil
+}
+
+func (b *fakeClientRebuilder) UnloadClients(clientIdList []int64) {
+	b.unloaded = append(b.unloaded, append([]int64(nil), clientIdList...))
+}
+
+// takeRebuilt 取出并清空已记录的重新构建批次
+func (b *fakeClientRebuilder) takeRebuilt() [][]int64 {
+	rebuilt := b.rebuilt
+	b.rebuilt = nil
+	return rebuilt
+}
+
+// clientVersion 按客户端和模型的 update_time 生成与仓储一致格式的配置版本
+func clientVersion(clientID int64, clientUpdate, modelUpdate time.Time) string {
+	return fmt.Sprintf("client:%d@%d;model:%d@%d", clientID, clientUpdate.UnixNano(), clientID+1000, modelUpdate.UnixNano())
+}
+
+func TestAgentConfigWatcherDetectsUpdateTimeChanges(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	repo := &fakeVersionRepository{versions: map[int64]string{
+		1: clientVersion(1, t0, t0),
+		2: clientVersion(2, t0, t0),
+	}}
+	rebuilder := &fakeClientRebuilder{failing: map[int64]bool{2: true}}
+	watcher := &AgentConfigWatcher{repository: repo, agentService: rebuilder, interval: time.Hour}
+
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	defer cancel()
+	if err := watcher.Start(ctx); err != nil {
+		t.Fatalf("Start 失败: %v", err)
+	}
+	if rebuilt := rebuilder.takeRebuilt(); !reflect.DeepEqual(rebuilt, [][]int64{{1, 2}}) {
+		t.Fatalf("启动时构建 = %v, 期望 [[1 2]]", rebuilt)
+	}
+
+	steps := []struct {
+		name     string
+		change   func()
+		rebuilt  [][]int64
+		unloaded [][]int64
+	}{
+		{
+			name:    "配置未变化，只重试构建失败的客户端",
+			rebuilt: [][]int64{{2}},
+		},
+		{
+			name:   "客户端 update_time 变化",
+			change: func() { delete(rebuilder.failing, 2); repo.versions[1] = clientVersion(1, t0.Add(time.Minute), t0) },
+			// 客户端 2 上次构建失败，同样重新构建
+			rebuilt: [][]int64{{1, 2}},
+		},
+		{name: "全部构建成功后不再构建"},
+		{
+			name:    "关联模型 update_time 变化",
+			change:  func() { repo.versions[2] = clientVersion(2, t0, t0.Add(time.Second)) },
+			rebuilt: [][]int64{{2}},
+		},
+		{
+			name:    "update_time 只相差 1 纳秒",
+			change:  func() { repo.versions[1] = clientVersion(1, t0.Add(time.Minute+time.Nanosecond), t0) },
+			rebuilt: [][]int64{{1}},
+		},
+		{
+			name: "新启用和停用客户端",
+			change: func() {
+				delete(repo.versions, 1)
+				repo.versions[3] = clientVersion(3, t0, t0)
+			},
+			rebuilt:  [][]int64{{3}},
+			unloaded: [][]int64{{1}},
+		},
+		{
+			name:    "停用后重新启用的客户端重新构建",
+			change:  func() { repo.versions[1] = clientVersion(1, t0.Add(time.Minute), t0) },
+			rebuilt: [][]int64{{1}},
+		},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+		rebuilder.unloaded = nil
+		if err := watcher.Poll(); err != nil {
+			t.Fatalf("%s: Poll 失败: %v", step.name, err)
+		}
+		if rebuilt := rebuilder.takeRebuilt(); !reflect.DeepEqual(rebuilt, step.rebuilt) {
+			t.Errorf("%s: 重新构建 = %v, 期望 %v", step.name, rebuilt, step.rebuilt)
+		}
+		if !reflect.DeepEqual(rebuilder.unloaded, step.unloaded) {
+			t.Errorf("%s: 卸载 = %v, 期望 %v", step.name, rebuilder.unloaded, step.unloaded)
+		}
+	}
+}
+
+func TestAgentConfigWatcherRetriesAfterErrors(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	queryErr := errors.New("db down")
+	repo := &fakeVersionRepository{versions: map[int64]string{1: clientVersion(1, t0, t0)}, err: queryErr}
+	rebuilder := &fakeClientRebuilder{}
+	watcher := &AgentConfigWatcher{repository: repo, agentService: rebuilder, interval: time.Hour}
+
+	// 首次检查失败时返回错误，不构建
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	defer cancel()
+	if err := watcher.Start(ctx); !errors.Is(err, queryErr) {
+		t.Fatalf("Start 错误 = %v, 期望查询错误", err)
+	}
+	if len(rebuilder.rebuilt) != 0 {
+		t.Errorf("查询失败时不应构建: %v", rebuilder.rebuilt)
+	}
+
+	// 构建整体失败时不记录版本
+	repo.err = nil
+	rebuilder.err = errors.New("查询配置失败")
+	if err := watcher.Poll(); !errors.Is(err, rebuilder.err) {
+		t.Fatalf("Poll 错误 = %v, 期望构建错误", err)
+	}
+
+	rebuilder.err = nil
+	if err := watcher.Poll(); err != nil {
+		t.Fatalf("Poll 失败: %v", err)
+	}
+	if rebuilt := rebuilder.takeRebuilt(); !reflect.DeepEqual(rebuilt, [][]int64{{1}, {1}}) {
+		t.Errorf("重新构建 = %v, 期望失败后重试", rebuilt)
+	}
+	if err := watcher.Poll(); err != nil || len(rebuilder.rebuilt) != 0 {
+		t.Errorf("构建成功后不应再构建: %v, %v", err, rebuilder.rebuilt)
+	}
+}
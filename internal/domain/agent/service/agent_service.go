@@ -1,6 +1,148 @@
 package service
 
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/service/armory"
+	"smart-weaver/internal/domain/agent/service/armory/factory"
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+)
+
 type IAgentService interface {
-	// DoArmory 执行“生成器”相关操作
-	DoArmory()
+	// DoArmory 执行“生成器”相关操作，按客户端ID列表构建并注册 AiClient_<clientId>
+	DoArmory(requestParameter *entity.AiAgentEngineStarterEntity) (*node.ArmoryReport, error)
+	// ListBeans 列出已注册的Bean
+	ListBeans() []armory.BeanInfo
+}
+
+// AgentService 智能体服务实现
+type AgentService struct {
+	armoryFactory   *factory.DefaultArmoryStrategyFactory
+	mu              sync.Mutex                  // 串行执行构建，避免并发构建互相覆盖Bean
+	promptVariables map[int64]map[string]string // clientId -> 最近一次构建成功使用的提示词变量，重新构建时沿用
+}
+
+// NewAgentService 创建智能体服务
+func NewAgentService(armoryFactory *factory.DefaultArmoryStrategyFactory) *AgentService {
+	return &AgentService{
+		armoryFactory:   armoryFactory,
+		promptVariables: make(map[int64]map[string]string),
+	}
+}
+
+// DoArmory 执行构建链，配置查询失败时返回错误，单个Bean失败记录在构建结果中
+// 本次构建的Bean先注册在作用域内，构建完成后一次性替换容器中的旧Bean，失败时丢弃
+func (s *AgentService) DoArmory(requestParameter *entity.AiAgentEngineStarterEntity) (*node.ArmoryReport, error) {
+	if requestParameter == nil || len(requestParameter.ClientIDList) == 0 {
+		return nil, errors.New("clientIdList不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.doArmoryLocked(requestParameter)
+}
+
+// RebuildClients 按各客户端最近一次构建成功使用的提示词变量重新构建，变量相同的客户端合并为一次构建
+// 用于配置变更后的重新构建，从未构建成功的客户端不带提示词变量
+func (s *AgentService) RebuildClients(clientIdList []int64) (*node.ArmoryReport, error) {
+	if len(clientIdList) == 0 {
+		return nil, errors.New("clientIdList不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []*entity.AiAgentEngineStarterEntity
+	groups := make(map[string]*entity.AiAgentEngineStarterEntity)
+	for _, clientID := range clientIdList {
+		variables := s.promptVariables[clientID]
+		key, err := json.Marshal(variables)
+		if err != nil {
+			return nil, err
+		}
+		request, ok := groups[string(key)]
+		if !ok {
+			request = &entity.AiAgentEngineStarterEntity{PromptVariables: variables}
+			groups[string(key)] = request
+			requests = append(requests, request)
+		}
+		request.ClientIDList = append(request.ClientIDList, clientID)
+	}
+
+	report := &node.ArmoryReport{Built: []string{}, Failed: []node.ArmoryFailure{}}
+	for _, request := range requests {
+		groupReport, err := s.doArmoryLocked(request)
+		if err != nil {
+			return nil, err
+		}
+		report.Built = append(report.Built, groupReport.Built...)
+		report.Failed = append(report.Failed, groupReport.Failed...)
+	}
+	return report, nil
+}
+
+// doArmoryLocked 执行构建链并记录构建成功的客户端使用的提示词变量，调用方需持有锁
+func (s *AgentService) doArmoryLocked(requestParameter *entity.AiAgentEngineStarterEntity) (*node.ArmoryReport, error) {
+	scope := s.armoryFactory.Registry().NewScope()
+	dynamicContext := context.NewDynamicContext()
+	armory.WithScope(dynamicContext, scope)
+	report := node.GetArmoryReport(dynamicContext)
+	if _, err := s.armoryFactory.StrategyHandler().DoApply(requestParameter, dynamicContext); err != nil {
+		scope.Discard()
+		return nil, err
+	}
+	scope.Commit()
+	s.rememberPromptVariables(requestParameter, report)
+
+	log.Printf("Ai Agent 构建完成 clientIdList=%v 成功 %d 个，失败 %d 个", requestParameter.ClientIDList, len(report.Built), len(report.Failed))
+	return report, nil
+}
+
+// rememberPromptVariables 记录构建成功的客户端使用的提示词变量
+func (s *AgentService) rememberPromptVariables(requestParameter *entity.AiAgentEngineStarterEntity, report *node.ArmoryReport) {
+	built := make(map[string]bool, len(report.Built))
+	for _, bean := range report.Built {
+		built[bean] = true
+	}
+	for _, clientID := range requestParameter.ClientIDList {
+		if !built[clientBeanName(clientID)] {
+			continue
+		}
+		if len(requestParameter.PromptVariables) == 0 {
+			delete(s.promptVariables, clientID)
+			continue
+		}
+		variables := make(map[string]string, len(requestParameter.PromptVariables))
+		for name, value := range requestParameter.PromptVariables {
+			variables[name] = value
+		}
+		s.promptVariables[clientID] = variables
+	}
+}
+
+// ListBeans 列出已注册的Bean
+func (s *AgentService) ListBeans() []armory.BeanInfo {
+	return s.armoryFactory.Registry().List()
+}
+
+// UnloadClients 移除客户端 AiClient_<clientId>，进行中的请求结束后释放其依赖
+func (s *AgentService) UnloadClients(clientIdList []int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, clientID := range clientIdList {
+		s.armoryFactory.Registry().Remove(clientBeanName(clientID))
+		delete(s.promptVariables, clientID)
+	}
+}
+
+// clientBeanName 客户端Bean名称
+func clientBeanName(clientID int64) string {
+	return "AiClient_" + strconv.FormatInt(clientID, 10)
 }
@@ -1,30 +1,46 @@
 package armory
 
 import (
-	"log"
-	"sync"
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
 )
 
-// AbstractArmorySupport 抽象生成器类
+// AbstractArmorySupport 抽象生成器类，所有节点共享同一个实例
 type AbstractArmorySupport struct {
 	ThreadPool chan func()
-	Deps       map[string]any
-	Mu         sync.Mutex
+	Registry   *BeanRegistry
 }
 
-// RegisterDependency 注册依赖对象（线程安全），已存在则覆盖
-func (a *AbstractArmorySupport) RegisterDependency(name string, instance any) {
-	a.Mu.Lock()
-	defer a.Mu.Unlock()
-	a.Deps[name] = instance
-	log.Printf("成功注册依赖: %s", name)
+// NewAbstractArmorySupport 创建共享的生成器，启动 workers 个协程执行线程池任务
+func NewAbstractArmorySupport(registry *BeanRegistry, workers int) *AbstractArmorySupport {
+	support := &AbstractArmorySupport{
+		ThreadPool: make(chan func(), 100),
+		Registry:   registry,
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for task := range support.ThreadPool {
+				task()
+			}
+		}()
+	}
+	return support
 }
 
-// GetDependency 获取依赖对象（线程安全），不存在返回 nil
-func (a *AbstractArmorySupport) GetDependency(name string) any {
-	a.Mu.Lock()
-	defer a.Mu.Unlock()
-	return a.Deps[name]
+// RegisterBean 注册Bean，动态上下文中有构建作用域时写入作用域，提交后才对外可见
+// dependsOn 为依赖的Bean名称，依赖在该Bean释放前不会释放
+func (a *AbstractArmorySupport) RegisterBean(dynamicContext *context.DynamicContext, name string, instance any, dependsOn ...string) error {
+	if scope := ScopeFrom(dynamicContext); scope != nil {
+		return scope.Register(name, instance, dependsOn...)
+	}
+	return a.Registry.Register(name, instance, dependsOn...)
+}
+
+// Beans 本次构建可见的Bean，优先取构建作用域
+func (a *AbstractArmorySupport) Beans(dynamicContext *context.DynamicContext) BeanGetter {
+	if scope := ScopeFrom(dynamicContext); scope != nil {
+		return scope
+	}
+	return a.Registry
 }
 
 // SubmitTask 提交任务到线程池
@@ -0,0 +1,420 @@
+package armory
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+)
+
+// scopeKey 本次构建作用域在动态上下文中的键
+const scopeKey = "armoryBeanScope"
+
+// InitializingBean 注册时执行初始化的Bean（模拟Java中的InitializingBean），初始化失败时不注册
+type InitializingBean interface {
+	AfterPropertiesSet() error
+}
+
+// DisposableBean 不再被引用时释放资源的Bean，与 io.Closer 一致，如 MCP 客户端
+type DisposableBean interface {
+	Close() error
+}
+
+// BeanGetter 按名称获取Bean
+type BeanGetter interface {
+	Get(name string) (any, bool)
+}
+
+// BeanInfo Bean 描述信息
+type BeanInfo struct {
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	DependsOn    []string  `json:"dependsOn,omitempty"`
+	Refs         int32     `json:"refs"` // 容器、依赖方和进行中的请求持有的引用数
+	RegisterTime time.Time `json:"registerTime"`
+}
+
+// beanEntry 已注册的Bean，引用数归零时执行释放钩子并释放依赖
+// 容器持有一个引用，被替换或移除时释放；依赖方和租约各持有一个引用
+type beanEntry struct {
+	name         string
+	instance     any
+	dependsOn    []*beanEntry
+	registerTime time.Time
+	refs         atomic.Int32
+	keepInstance bool // 同一实例被重新注册时，旧条目释放不执行释放钩子
+}
+
+// newBeanEntry 创建Bean，持有依赖的引用
+func newBeanEntry(name string, instance any, dependsOn []*beanEntry) *beanEntry {
+	entry := &beanEntry{
+		name:         name,
+		instance:     instance,
+		dependsOn:    dependsOn,
+		registerTime: time.Now(),
+	}
+	entry.refs.Store(1)
+	for _, dep := range dependsOn {
+		dep.refs.Add(1)
+	}
+	return entry
+}
+
+// release 释放一个引用，引用归零时销毁
+func (e *beanEntry) release() {
+	if e.refs.Add(-1) == 0 {
+		e.destroy()
+	}
+}
+
+// destroy 执行释放钩子并释放依赖
+func (e *beanEntry) destroy() {
+	if !e.keepInstance {
+		destroyBean(e.name, e.instance)
+	}
+	for _, dep := range e.dependsOn {
+		dep.release()
+	}
+}
+
+// info Bean 描述信息
+func (e *beanEntry) info() BeanInfo {
+	info := BeanInfo{
+		Name:         e.name,
+		Type:         fmt.Sprintf("%T", e.instance),
+		Refs:         e.refs.Load(),
+		RegisterTime: e.registerTime,
+	}
+	for _, dep := range e.dependsOn {
+		info.DependsOn = append(info.DependsOn, dep.name)
+	}
+	return info
+}
+
+// Get 按名称获取指定类型的Bean，不存在或类型不匹配时返回 false
+func Get[T any](getter BeanGetter, name string) (T, bool) {
+	var zero T
+	instance, ok := getter.Get(name)
+	if !ok {
+		return zero, false
+	}
+	bean, ok := instance.(T)
+	if !ok {
+		return zero, false
+	}
+	return bean, true
+}
+
+// Acquire 按名称租用指定类型的Bean，使用完后必须调用返回的 release
+// 租用期间Bean即使被替换也不会释放，用于等待进行中的请求结束
+func Acquire[T any](registry *BeanRegistry, name string) (T, func(), bool) {
+	var zero T
+	instance, release, ok := registry.Acquire(name)
+	if !ok {
+		return zero, nil, false
+	}
+	bean, ok := instance.(T)
+	if !ok {
+		release()
+		return zero, nil, false
+	}
+	return bean, release, true
+}
+
+// BeanRegistry 各节点共享的Bean容器
+type BeanRegistry struct {
+	beans map[string]*beanEntry
+	mu    sync.RWMutex
+}
+
+// NewBeanRegistry 创建Bean容器
+func NewBeanRegistry() *BeanRegistry {
+	return &BeanRegistry{beans: make(map[string]*beanEntry)}
+}
+
+// Register 注册Bean，dependsOn 为依赖的Bean名称，依赖在该Bean释放前不会释放
+// 已存在同名Bean时替换，旧Bean在不再被引用后释放
+func (r *BeanRegistry) Register(name string, instance any, dependsOn ...string) error {
+	if err := initializeBean(name, instance); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	deps, err := resolveDependsOn(name, dependsOn, r.lookup)
+	if err != nil {
+		r.mu.Unlock()
+		destroyBean(name, instance)
+		return err
+	}
+	old := r.beans[name]
+	if old != nil {
+		old.keepInstance = sameBean(old.instance, instance)
+	}
+	r.beans[name] = newBeanEntry(name, instance, deps)
+	r.mu.Unlock()
+
+	log.Printf("成功注册依赖: %s", name)
+	if old != nil {
+		old.release()
+	}
+	return nil
+}
+
+// Get 获取Bean
+func (r *BeanRegistry) Get(name string) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.beans[name]
+	if !ok {
+		return nil, false
+	}
+	return entry.instance, true
+}
+
+// Acquire 租用Bean，返回的 release 可重复调用，最后一个租约释放时在后台销毁已被替换的Bean，避免阻塞请求
+func (r *BeanRegistry) Acquire(name string) (any, func(), bool) {
+	r.mu.RLock()
+	entry, ok := r.beans[name]
+	if ok {
+		entry.refs.Add(1)
+	}
+	r.mu.RUnlock()
+	if !ok {
+		return nil, nil, false
+	}
+
+	var once sync.Once
+	return entry.instance, func() {
+		once.Do(func() {
+			if entry.refs.Add(-1) == 0 {
+				go entry.destroy()
+			}
+		})
+	}, true
+}
+
+// Remove 移除Bean，不再被引用后释放
+func (r *BeanRegistry) Remove(name string) bool {
+	r.mu.Lock()
+	entry, ok := r.beans[name]
+	delete(r.beans, name)
+	r.mu.Unlock()
+
+	if ok {
+		log.Printf("已移除依赖: %s", name)
+		entry.release()
+	}
+	return ok
+}
+
+// List 按名称排序列出所有Bean
+func (r *BeanRegistry) List() []BeanInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]BeanInfo, 0, len(r.beans))
+	for _, entry := range r.beans {
+		infos = append(infos, entry.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Close 移除所有Bean，不再被引用的立即释放
+func (r *BeanRegistry) Close() {
+	r.mu.Lock()
+	beans := r.beans
+	r.beans = make(map[string]*beanEntry)
+	r.mu.Unlock()
+
+	for _, entry := range beans {
+		entry.release()
+	}
+}
+
+// NewScope 创建构建作用域，作用域内注册的Bean在提交前对容器外不可见
+func (r *BeanRegistry) NewScope() *BeanScope {
+	return &BeanScope{parent: r, beans: make(map[string]*beanEntry)}
+}
+
+// lookup 查找Bean，调用方需持有锁
+func (r *BeanRegistry) lookup(name string) (*beanEntry, bool) {
+	entry, ok := r.beans[name]
+	return entry, ok
+}
+
+// BeanScope 一次构建的作用域，提交时整体替换容器中的同名Bean，丢弃时释放本次创建的Bean
+type BeanScope struct {
+	parent *BeanRegistry
+	beans  map[string]*beanEntry
+	names  []string // 注册顺序
+	done   bool
+	mu     sync.RWMutex
+}
+
+// Register 在作用域内注册Bean，依赖优先取作用域内的Bean
+func (s *BeanScope) Register(name string, instance any, dependsOn ...string) error {
+	if err := initializeBean(name, instance); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		destroyBean(name, instance)
+		return fmt.Errorf("构建作用域已结束，无法注册 %s", name)
+	}
+	s.parent.mu.RLock()
+	deps, err := resolveDependsOn(name, dependsOn, s.lookup)
+	s.parent.mu.RUnlock()
+	if err != nil {
+		s.mu.Unlock()
+		destroyBean(name, instance)
+		return err
+	}
+	old, exists := s.beans[name]
+	if exists {
+		old.keepInstance = sameBean(old.instance, instance)
+	}
+	s.beans[name] = newBeanEntry(name, instance, deps)
+	if !exists {
+		s.names = append(s.names, name)
+	}
+	s.mu.Unlock()
+
+	log.Printf("成功注册依赖: %s", name)
+	if exists {
+		old.release()
+	}
+	return nil
+}
+
+// Get 优先获取作用域内的Bean，其次为容器中的Bean
+func (s *BeanScope) Get(name string) (any, bool) {
+	s.mu.RLock()
+	entry, ok := s.beans[name]
+	s.mu.RUnlock()
+	if ok {
+		return entry.instance, true
+	}
+	return s.parent.Get(name)
+}
+
+// Names 作用域内注册的Bean名称
+func (s *BeanScope) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.names...)
+}
+
+// Commit 将作用域内的Bean一次性写入容器，被替换的旧Bean在不再被引用后释放
+func (s *BeanScope) Commit() {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	s.done = true
+	beans, names := s.beans, s.names
+	s.mu.Unlock()
+
+	var replaced []*beanEntry
+	s.parent.mu.Lock()
+	for _, name := range names {
+		if old, ok := s.parent.beans[name]; ok {
+			old.keepInstance = sameBean(old.instance, beans[name].instance)
+			replaced = append(replaced, old)
+		}
+		s.parent.beans[name] = beans[name]
+	}
+	s.parent.mu.Unlock()
+
+	for _, old := range replaced {
+		old.release()
+	}
+}
+
+// Discard 丢弃作用域，释放本次创建的Bean
+func (s *BeanScope) Discard() {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	s.done = true
+	beans := s.beans
+	s.mu.Unlock()
+
+	for _, entry := range beans {
+		entry.release()
+	}
+}
+
+// lookup 优先查找作用域内的Bean，调用方需持有作用域和容器的锁
+func (s *BeanScope) lookup(name string) (*beanEntry, bool) {
+	if entry, ok := s.beans[name]; ok {
+		return entry, true
+	}
+	return s.parent.lookup(name)
+}
+
+// WithScope 将构建作用域放入动态上下文
+func WithScope(dynamicContext *context.DynamicContext, scope *BeanScope) {
+	dynamicContext.SetValue(scopeKey, scope)
+}
+
+// ScopeFrom 获取动态上下文中的构建作用域，没有时返回 nil
+func ScopeFrom(dynamicContext *context.DynamicContext) *BeanScope {
+	scope, _ := dynamicContext.GetValue(scopeKey).(*BeanScope)
+	return scope
+}
+
+// resolveDependsOn 查找依赖的Bean，任一依赖不存在时返回错误
+func resolveDependsOn(name string, dependsOn []string, lookup func(string) (*beanEntry, bool)) ([]*beanEntry, error) {
+	deps := make([]*beanEntry, 0, len(dependsOn))
+	for _, depName := range dependsOn {
+		dep, ok := lookup(depName)
+		if !ok {
+			return nil, fmt.Errorf("%s 依赖的 %s 不存在", name, depName)
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// initializeBean 执行初始化钩子，失败时释放该Bean
+func initializeBean(name string, instance any) error {
+	if bean, ok := instance.(InitializingBean); ok {
+		if err := bean.AfterPropertiesSet(); err != nil {
+			destroyBean(name, instance)
+			return fmt.Errorf("初始化 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// sameBean 判断是否为同一个Bean，不可比较的类型视为不同
+func sameBean(a, b any) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// destroyBean 执行释放钩子，失败只记录日志
+func destroyBean(name string, instance any) {
+	if bean, ok := instance.(DisposableBean); ok {
+		if err := bean.Close(); err != nil {
+			log.Printf("释放 %s 失败: %v", name, err)
+			return
+		}
+		log.Printf("已释放依赖: %s", name)
+	}
+}
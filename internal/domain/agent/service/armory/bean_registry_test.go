@@ -0,0 +1,240 @@
+package armory
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeBean 记录关闭次数的Bean，initErr 不为空时初始化失败
+type fakeBean struct {
+	name    string
+	initErr error
+	closes  atomic.Int32
+	closed  chan struct{}
+}
+
+func newFakeBean(name string) *fakeBean {
+	return &fakeBean{name: name, closed: make(chan struct{})}
+}
+
+func (b *fakeBean) AfterPropertiesSet() error {
+	return b.initErr
+}
+
+func (b *fakeBean) Close() error {
+	if b.closes.Add(1) == 1 {
+		close(b.closed)
+	}
+	return nil
+}
+
+// assertOpen 断言Bean未被释放
+func (b *fakeBean) assertOpen(t *testing.T) {
+	t.Helper()
+	if closes := b.closes.Load(); closes != 0 {
+		t.Errorf("%s 不应释放, 实际释放 %d 次", b.name, closes)
+	}
+}
+
+// waitClosed 等待Bean被释放，释放在后台执行时使用
+func (b *fakeBean) waitClosed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-b.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s 未释放", b.name)
+	}
+	if closes := b.closes.Load(); closes != 1 {
+		t.Errorf("%s 释放 %d 次, 期望 1", b.name, closes)
+	}
+}
+
+// mustRegister 注册Bean，失败时终止测试
+func mustRegister(t *testing.T, register func(string, any, ...string) error, name string, instance any, dependsOn ...string) {
+	t.Helper()
+	if err := register(name, instance, dependsOn...); err != nil {
+		t.Fatalf("注册 %s 失败: %v", name, err)
+	}
+}
+
+func TestBeanRegistryAcquireDefersCloseUntilReleased(t *testing.T) {
+	registry := NewBeanRegistry()
+	oldBean, newBean := newFakeBean("old"), newFakeBean("new")
+	mustRegister(t, registry.Register, "AiClient_1", oldBean)
+
+	leased, release, ok := Acquire[*fakeBean](registry, "AiClient_1")
+	if !ok || leased != oldBean {
+		t.Fatalf("租用结果 = %v, %v", leased, ok)
+	}
+	if refs := registry.List()[0].Refs; refs != 2 {
+		t.Errorf("租用后引用数 = %d, 期望 2", refs)
+	}
+
+	// 租用期间被替换，旧Bean等最后一个租约释放后才释放
+	mustRegister(t, registry.Register, "AiClient_1", newBean)
+	if current, _ := Get[*fakeBean](registry, "AiClient_1"); current != newBean {
+		t.Errorf("替换后获取到 %v, 期望新Bean", current)
+	}
+	oldBean.assertOpen(t)
+
+	release()
+	release() // 重复释放无效
+	oldBean.waitClosed(t)
+	newBean.assertOpen(t)
+	if refs := registry.List()[0].Refs; refs != 1 {
+		t.Errorf("新Bean引用数 = %d, 期望 1", refs)
+	}
+
+	if _, _, ok := Acquire[string](registry, "AiClient_1"); ok {
+		t.Error("类型不匹配时租用应失败")
+	}
+	if _, _, ok := registry.Acquire("AiClient_2"); ok {
+		t.Error("不存在的Bean租用应失败")
+	}
+	if refs := registry.List()[0].Refs; refs != 1 {
+		t.Errorf("租用失败后引用数 = %d, 期望 1", refs)
+	}
+}
+
+func TestBeanRegistryDependencyKeptUntilDependentReleased(t *testing.T) {
+	registry := NewBeanRegistry()
+	oldModel, newModel := newFakeBean("model-old"), newFakeBean("model-new")
+	oldClient, newClient := newFakeBean("client-old"), newFakeBean("client-new")
+	mustRegister(t, registry.Register, "AiClientModel_1", oldModel)
+	mustRegister(t, registry.Register, "AiClient_1", oldClient, "AiClientModel_1")
+
+	// 模型被替换，旧客户端仍依赖旧模型
+	mustRegister(t, registry.Register, "AiClientModel_1", newModel)
+	oldModel.assertOpen(t)
+
+	// 客户端被替换后，旧客户端和旧模型依次释放
+	mustRegister(t, registry.Register, "AiClient_1", newClient, "AiClientModel_1")
+	oldClient.waitClosed(t)
+	oldModel.waitClosed(t)
+	newModel.assertOpen(t)
+
+	infos := registry.List()
+	if len(infos) != 2 || infos[0].Name != "AiClientModel_1" || infos[0].Refs != 2 ||
+		infos[1].Name != "AiClient_1" || strings.Join(infos[1].DependsOn, ",") != "AiClientModel_1" {
+		t.Errorf("Bean 列表 = %+v", infos)
+	}
+
+	// 移除客户端后模型只剩容器的引用
+	if !registry.Remove("AiClient_1") {
+		t.Fatal("移除客户端失败")
+	}
+	newClient.waitClosed(t)
+	newModel.assertOpen(t)
+	registry.Close()
+	newModel.waitClosed(t)
+}
+
+func TestBeanRegistryRegisterSameInstanceKeepsIt(t *testing.T) {
+	registry := NewBeanRegistry()
+	bean := newFakeBean("shared")
+	mustRegister(t, registry.Register, "AiClientToolMcp_1", bean)
+	mustRegister(t, registry.Register, "AiClientToolMcp_1", bean)
+	bean.assertOpen(t)
+
+	registry.Close()
+	bean.waitClosed(t)
+}
+
+func TestBeanRegistryRegisterFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		bean      *fakeBean
+		dependsOn []string
+		wantErr   string
+	}{
+		{name: "初始化失败", bean: &fakeBean{name: "init", initErr: errors.New("connect refused"), closed: make(chan struct{})}, wantErr: "初始化 AiClient_1 失败: connect refused"},
+		{name: "依赖不存在", bean: newFakeBean("missing"), dependsOn: []string{"AiClientModel_9"}, wantErr: "AiClient_1 依赖的 AiClientModel_9 不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewBeanRegistry()
+			err := registry.Register("AiClient_1", tt.bean, tt.dependsOn...)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("错误 = %v, 期望 %s", err, tt.wantErr)
+			}
+			tt.bean.waitClosed(t)
+			if _, ok := registry.Get("AiClient_1"); ok {
+				t.Error("注册失败的Bean不应可见")
+			}
+		})
+	}
+}
+
+func TestBeanScopeCommitReplacesAtomically(t *testing.T) {
+	registry := NewBeanRegistry()
+	oldModel, oldClient := newFakeBean("model-old"), newFakeBean("client-old")
+	mustRegister(t, registry.Register, "AiClientModel_1", oldModel)
+	mustRegister(t, registry.Register, "AiClient_1", oldClient, "AiClientModel_1")
+
+	scope := registry.NewScope()
+	newModel, newClient := newFakeBean("model-new"), newFakeBean("client-new")
+	mustRegister(t, scope.Register, "AiClientModel_1", newModel)
+	mustRegister(t, scope.Register, "AiClient_1", newClient, "AiClientModel_1")
+
+	// 提交前容器中仍为旧Bean，作用域内优先取新Bean
+	if current, _ := Get[*fakeBean](registry, "AiClient_1"); current != oldClient {
+		t.Errorf("提交前容器中的客户端 = %v, 期望旧客户端", current)
+	}
+	if current, _ := Get[*fakeBean](scope, "AiClient_1"); current != newClient {
+		t.Errorf("作用域内的客户端 = %v, 期望新客户端", current)
+	}
+	if names := strings.Join(scope.Names(), ","); names != "AiClientModel_1,AiClient_1" {
+		t.Errorf("作用域内的Bean = %s", names)
+	}
+
+	scope.Commit()
+	if model, _ := Get[*fakeBean](registry, "AiClientModel_1"); model != newModel {
+		t.Errorf("提交后模型 = %v, 期望新模型", model)
+	}
+	if client, _ := Get[*fakeBean](registry, "AiClient_1"); client != newClient {
+		t.Errorf("提交后客户端 = %v, 期望新客户端", client)
+	}
+	oldClient.waitClosed(t)
+	oldModel.waitClosed(t)
+	newModel.assertOpen(t)
+	newClient.assertOpen(t)
+
+	// 结束后的作用域不能再注册，重复提交和丢弃无效
+	late := newFakeBean("late")
+	if err := scope.Register("AiClient_2", late); err == nil {
+		t.Error("作用域结束后注册应失败")
+	}
+	late.waitClosed(t)
+	scope.Commit()
+	scope.Discard()
+	newClient.assertOpen(t)
+}
+
+func TestBeanScopeDiscardReleasesOnlyScopedBeans(t *testing.T) {
+	registry := NewBeanRegistry()
+	model := newFakeBean("model")
+	mustRegister(t, registry.Register, "AiClientModel_1", model)
+
+	scope := registry.NewScope()
+	first, second := newFakeBean("client-first"), newFakeBean("client-second")
+	mustRegister(t, scope.Register, "AiClient_1", first, "AiClientModel_1")
+	// 作用域内重复注册替换之前的Bean
+	mustRegister(t, scope.Register, "AiClient_1", second, "AiClientModel_1")
+	first.waitClosed(t)
+	if refs := registry.List()[0].Refs; refs != 2 {
+		t.Errorf("模型引用数 = %d, 期望 2", refs)
+	}
+
+	scope.Discard()
+	second.waitClosed(t)
+	model.assertOpen(t)
+	if _, ok := registry.Get("AiClient_1"); ok {
+		t.Error("丢弃的作用域不应写入容器")
+	}
+	if refs := registry.List()[0].Refs; refs != 1 {
+		t.Errorf("丢弃后模型引用数 = %d, 期望 1", refs)
+	}
+}
@@ -1,22 +1,81 @@
 package factory
 
 import (
+	"strconv"
+	"time"
+
+	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory"
 	"smart-weaver/internal/domain/agent/service/armory/node"
 )
 
+// defaultArmoryWorkers 构建链并发查询配置使用的协程数
+const defaultArmoryWorkers = 8
+
 // DefaultArmoryStrategyFactory 工厂类
 type DefaultArmoryStrategyFactory struct {
 	rootNode *node.RootNode
+	registry *armory.BeanRegistry // 各节点共享的Bean容器
 }
 
 // NewDefaultArmoryStrategyFactory 创建工厂实例
 func NewDefaultArmoryStrategyFactory(rootNode *node.RootNode) *DefaultArmoryStrategyFactory {
 	return &DefaultArmoryStrategyFactory{
 		rootNode: rootNode,
+		registry: rootNode.Registry,
 	}
 }
 
+// NewArmoryStrategyFactory 组装构建链 Root → ToolMcp → Model → Advisor → SystemPrompt → Client
+// 各节点注入同一个Bean容器，后面的节点才能取到前面节点注册的Bean，如顾问依赖模型节点注册的嵌入模型；usageRecorder 为空时不记录模型用量
+func NewArmoryStrategyFactory(repository node.Repository, vectorStore config.VectorStore, chatMemories map[string]node.ChatMemory, usageRecorder node.UsageRecorder) *DefaultArmoryStrategyFactory {
+	registry := armory.NewBeanRegistry()
+	support := armory.NewAbstractArmorySupport(registry, defaultArmoryWorkers)
+
+	aiClientNode := node.NewAiClientNode(support)
+	systemPromptNode := node.NewAiClientSystemPromptNode(support, aiClientNode)
+	advisorNode := node.NewAiClientAdvisorNode(support, systemPromptNode, vectorStore)
+	for storage, chatMemory := range chatMemories {
+		advisorNode.RegisterChatMemory(storage, chatMemory)
+	}
+	modelNode := node.NewAiClientModelNode(support, advisorNode)
+	if usageRecorder != nil {
+		modelNode.SetUsageRecorder(usageRecorder)
+	}
+	modelNode.SetEmbeddingModelProvider(newOpenAiEmbeddingModel)
+	toolMcpNode := node.NewAiClientToolMcpNode(support, modelNode)
+	rootNode := node.NewRootNode(support, toolMcpNode, repository)
+
+	return &DefaultArmoryStrategyFactory{
+		rootNode: rootNode,
+		registry: registry,
+	}
+}
+
+// newOpenAiEmbeddingModel 按模型配置创建OpenAI嵌入模型
+func newOpenAiEmbeddingModel(modelVO valobj.AiClientModelVO) (config.EmbeddingModel, error) {
+	embeddingModel := config.NewOpenAiEmbeddingModel(&config.OpenAiApi{
+		BaseUrl:        modelVO.BaseURL,
+		ApiKey:         modelVO.APIKey,
+		EmbeddingsPath: modelVO.EmbeddingsPath,
+	}, modelVO.ModelVersion)
+	embeddingModel.Timeout = time.Duration(modelVO.Timeout) * time.Second
+	return embeddingModel, nil
+}
+
 // StrategyHandler 返回策略处理器
 func (f *DefaultArmoryStrategyFactory) StrategyHandler() node.StrategyHandler {
 	return f.rootNode
 }
+
+// Registry 返回Bean容器
+func (f *DefaultArmoryStrategyFactory) Registry() *armory.BeanRegistry {
+	return f.registry
+}
+
+// AcquireChatClient 租用已构建的对话客户端 AiClient_<clientId>，请求结束后调用 release
+// 客户端被重新构建替换时，旧客户端及其 MCP 连接在所有租约释放后才关闭
+func (f *DefaultArmoryStrategyFactory) AcquireChatClient(clientID int64) (*node.ChatClient, func(), bool) {
+	return armory.Acquire[*node.ChatClient](f.registry, "AiClient_"+strconv.FormatInt(clientID, 10))
+}
@@ -0,0 +1,103 @@
+package node
+
+import (
+	stdcontext "context"
+	"sort"
+)
+
+// AdvisedRequest 经过顾问处理的对话请求（模拟Java中的AdvisedRequest）
+type AdvisedRequest struct {
+	SystemText    string             // 系统提示词，可为空
+	Messages      []Message          // 历史消息，位于系统提示词与本轮用户消息之间
+	UserText      string             // 本轮用户消息
+	Options       *OpenAiChatOptions // 为空时使用模型默认选项
+	AdviseContext map[string]any     // 顾问之间及请求、响应阶段共享的上下文
+	ChatModel     ChatModel          // 处理本次请求的模型，供顾问复用（如生成摘要），可为空
+}
+
+// AdvisedResponse 经过顾问处理的对话响应（模拟Java中的AdvisedResponse）
+type AdvisedResponse struct {
+	Response      *ChatResponse
+	AdviseContext map[string]any
+}
+
+// ToPrompt 组装为提示词
+func (r *AdvisedRequest) ToPrompt() *Prompt {
+	messages := make([]Message, 0, len(r.Messages)+2)
+	if r.SystemText != "" {
+		messages = append(messages, NewSystemMessage(r.SystemText))
+	}
+	messages = append(messages, r.Messages...)
+	messages = append(messages, NewUserMessage(r.UserText))
+	return &Prompt{Messages: messages, Options: r.Options}
+}
+
+// Advisor 对话顾问，在调用模型前改写请求、调用后处理响应（模拟Java中的RequestResponseAdvisor）
+type Advisor interface {
+	// Name 顾问名称
+	Name() string
+	// Order 执行顺序，越小越先处理请求、越后处理响应
+	Order() int
+	// AdviseRequest 调用模型前处理请求
+	AdviseRequest(ctx stdcontext.Context, request *AdvisedRequest) (*AdvisedRequest, error)
+	// AdviseResponse 调用模型后处理响应，流式调用时在响应聚合完成后执行
+	AdviseResponse(ctx stdcontext.Context, response *AdvisedResponse) (*AdvisedResponse, error)
+}
+
+// FailureAwareAdvisor 对话失败时收到通知的顾问（可选实现），用于回滚或释放请求阶段占用的资源
+// 请求阶段、模型调用或响应阶段任一步失败时调用，未执行过请求阶段的顾问也可能收到通知，需自行忽略
+type FailureAwareAdvisor interface {
+	Advisor
+	// AdviseError 对话失败时执行
+	AdviseError(ctx stdcontext.Context, adviseContext map[string]any, err error)
+}
+
+// AdvisorChain 按顺序执行的顾问链
+type AdvisorChain []Advisor
+
+// NewAdvisorChain 创建顾问链，按 Order 排序，相同顺序保持传入次序
+func NewAdvisorChain(advisors ...Advisor) AdvisorChain {
+	chain := append(AdvisorChain{}, advisors...)
+	sort.SliceStable(chain, func(i, j int) bool {
+		return chain[i].Order() < chain[j].Order()
+	})
+	return chain
+}
+
+// AdviseRequest 依次执行请求阶段，失败时通知已执行的顾问
+func (chain AdvisorChain) AdviseRequest(ctx stdcontext.Context, request *AdvisedRequest) (*AdvisedRequest, error) {
+	if request.AdviseContext == nil {
+		request.AdviseContext = make(map[string]any)
+	}
+	adviseContext := request.AdviseContext
+	for i, advisor := range chain {
+		var err error
+		if request, err = advisor.AdviseRequest(ctx, request); err != nil {
+			chain[:i+1].AdviseError(ctx, adviseContext, err)
+			return nil, err
+		}
+	}
+	return request, nil
+}
+
+// AdviseResponse 逆序执行响应阶段，失败时通知全部顾问
+func (chain AdvisorChain) AdviseResponse(ctx stdcontext.Context, response *AdvisedResponse) (*AdvisedResponse, error) {
+	adviseContext := response.AdviseContext
+	for i := len(chain) - 1; i >= 0; i-- {
+		var err error
+		if response, err = chain[i].AdviseResponse(ctx, response); err != nil {
+			chain.AdviseError(ctx, adviseContext, err)
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// AdviseError 逆序通知实现了 FailureAwareAdvisor 的顾问对话失败
+func (chain AdvisorChain) AdviseError(ctx stdcontext.Context, adviseContext map[string]any, err error) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if advisor, ok := chain[i].(FailureAwareAdvisor); ok {
+			advisor.AdviseError(ctx, adviseContext, err)
+		}
+	}
+}
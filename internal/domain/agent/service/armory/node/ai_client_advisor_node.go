@@ -0,0 +1,168 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory"
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+)
+
+// AiClientAdvisorNode 顾问节点
+type AiClientAdvisorNode struct {
+	*armory.AbstractArmorySupport
+	AiClientSystemPromptNode StrategyHandler
+	VectorStore              config.VectorStore
+	ChatMemories             map[string]ChatMemory // 存储类型 -> 对话记忆存储
+}
+
+// NewAiClientAdvisorNode 创建AiClientAdvisorNode实例，vectorStore 为空时无法构建知识库问答顾问
+// 默认只注册进程内对话记忆，其它存储通过 RegisterChatMemory 注册
+func NewAiClientAdvisorNode(support *armory.AbstractArmorySupport, aiClientSystemPromptNode StrategyHandler, vectorStore config.VectorStore) *AiClientAdvisorNode {
+	return &AiClientAdvisorNode{
+		AbstractArmorySupport:    support,
+		AiClientSystemPromptNode: aiClientSystemPromptNode,
+		VectorStore:              vectorStore,
+		ChatMemories: map[string]ChatMemory{
+			ChatMemoryStorageInMemory: NewInMemoryChatMemory(),
+		},
+	}
+}
+
+// RegisterChatMemory 注册对话记忆存储，同类型重复注册时覆盖
+func (node *AiClientAdvisorNode) RegisterChatMemory(storage string, memory ChatMemory) {
+	node.ChatMemories[storage] = memory
+}
+
+// DoApply 执行应用逻辑
+func (node *AiClientAdvisorNode) DoApply(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (string, error) {
+	reqJSON, _ := json.Marshal(requestParameter)
+	log.Printf("Ai Agent 构建，advisor 节点 %s", string(reqJSON))
+
+	// 从动态上下文获取顾问列表
+	aiClientAdvisorListVal := dynamicContext.GetValue("aiClientAdvisorList")
+	if aiClientAdvisorListVal == nil {
+		log.Println("没有可用的AI客户端顾问配置")
+		return node.Router(requestParameter, dynamicContext)
+	}
+
+	aiClientAdvisorList, ok := aiClientAdvisorListVal.([]valobj.AiClientAdvisorVO)
+	if !ok || len(aiClientAdvisorList) == 0 {
+		log.Println("没有可用的AI客户端顾问配置")
+		return node.Router(requestParameter, dynamicContext)
+	}
+
+	// 遍历处理每个顾问配置
+	report := GetArmoryReport(dynamicContext)
+	for _, advisorVO := range aiClientAdvisorList {
+		beanName := node.beanName(advisorVO.ID)
+		advisor, dependsOn, err := node.createAdvisor(dynamicContext, advisorVO)
+		if err != nil {
+			log.Printf("创建顾问失败: %v", err)
+			report.AddFailed(beanName, err)
+			continue
+		}
+
+		// 注册Bean
+		if err := node.RegisterBean(dynamicContext, beanName, advisor, dependsOn...); err != nil {
+			log.Printf("注册Bean %s 失败: %v", beanName, err)
+			report.AddFailed(beanName, err)
+			continue
+		}
+		report.AddBuilt(beanName)
+	}
+
+	return node.Router(requestParameter, dynamicContext)
+}
+
+// Get 获取下一个处理器
+func (node *AiClientAdvisorNode) Get(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (StrategyHandler, error) {
+	return node.AiClientSystemPromptNode, nil
+}
+
+// Router 路由到下一个处理器
+func (node *AiClientAdvisorNode) Router(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (string, error) {
+	nextHandler, err := node.Get(requestParameter, dynamicContext)
+	if err != nil {
+		return "", err
+	}
+	if nextHandler == nil {
+		return "completed", nil
+	}
+	return nextHandler.DoApply(requestParameter, dynamicContext)
+}
+
+// beanName 生成Bean名称
+func (node *AiClientAdvisorNode) beanName(id int64) string {
+	return "AiClientAdvisor_" + strconv.FormatInt(id, 10)
+}
+
+// createAdvisor 按类型创建顾问，同时返回顾问依赖的Bean
+func (node *AiClientAdvisorNode) createAdvisor(dynamicContext *context.DynamicContext, advisorVO valobj.AiClientAdvisorVO) (Advisor, []string, error) {
+	switch advisorVO.AdvisorType {
+	case valobj.AdvisorTypeRagAnswer:
+		return node.createQuestionAnswerAdvisor(dynamicContext, advisorVO)
+	case valobj.AdvisorTypeChatMemory:
+		advisor, err := node.createChatMemoryAdvisor(advisorVO)
+		return advisor, nil, err
+	default:
+		return nil, nil, fmt.Errorf("err! advisorType %s not exist!", advisorVO.AdvisorType)
+	}
+}
+
+// createQuestionAnswerAdvisor 创建知识库问答顾问，指定嵌入模型时使用模型节点注册的 AiClientEmbeddingModel_<id>
+func (node *AiClientAdvisorNode) createQuestionAnswerAdvisor(dynamicContext *context.DynamicContext, advisorVO valobj.AiClientAdvisorVO) (Advisor, []string, error) {
+	if node.VectorStore == nil {
+		return nil, nil, errors.New("向量存储未配置，无法创建知识库问答顾问")
+	}
+
+	ragAnswer := advisorVO.RagAnswer
+	if ragAnswer == nil {
+		ragAnswer = &valobj.RagAnswerVO{}
+	}
+
+	advisor := NewQuestionAnswerAdvisor(advisorVO.AdvisorName, advisorVO.OrderNum, node.VectorStore, SearchRequest{
+		TopK:                ragAnswer.TopK,
+		SimilarityThreshold: ragAnswer.SimilarityThreshold,
+		FilterExpression:    ragAnswer.FilterExpression,
+	}, ragAnswer.UserTextAdvise)
+	if ragAnswer.EmbeddingModelID == 0 {
+		return advisor, nil, nil
+	}
+
+	embeddingBeanName := embeddingModelBeanName(ragAnswer.EmbeddingModelID)
+	embeddingModel, ok := armory.Get[config.EmbeddingModel](node.Beans(dynamicContext), embeddingBeanName)
+	if !ok {
+		return nil, nil, fmt.Errorf("嵌入模型 %s 不存在或构建失败", embeddingBeanName)
+	}
+	advisor.EmbeddingModel = embeddingModel
+	return advisor, []string{embeddingBeanName}, nil
+}
+
+// createChatMemoryAdvisor 创建对话记忆顾问，不同客户端的会话互相隔离
+func (node *AiClientAdvisorNode) createChatMemoryAdvisor(advisorVO valobj.AiClientAdvisorVO) (Advisor, error) {
+	chatMemoryVO := advisorVO.ChatMemory
+	if chatMemoryVO == nil {
+		chatMemoryVO = &valobj.ChatMemoryVO{}
+	}
+
+	storage := chatMemoryVO.Storage
+	if storage == "" {
+		storage = ChatMemoryStorageInMemory
+	}
+	memory, ok := node.ChatMemories[storage]
+	if !ok {
+		return nil, fmt.Errorf("对话记忆存储 %s 未注册", storage)
+	}
+
+	advisor := NewMessageChatMemoryAdvisor(advisorVO.AdvisorName, advisorVO.OrderNum, memory,
+		chatMemoryVO.MaxMessages, chatMemoryVO.MaxTokens, chatMemoryVO.Summarize)
+	advisor.Namespace = strconv.FormatInt(advisorVO.ClientID, 10) + ":"
+	return advisor, nil
+}
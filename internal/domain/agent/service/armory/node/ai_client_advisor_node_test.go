@@ -0,0 +1,84 @@
+package node
+
+import (
+	stdcontext "context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory"
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+)
+
+// fakeVectorStore 记录检索方式的向量存储
+type fakeVectorStore struct {
+	config.VectorStore
+	searches []string
+}
+
+func (s *fakeVectorStore) SimilaritySearch(_ stdcontext.Context, query string, _ int, _ float64, _ map[string]any) ([]config.Document, error) {
+	s.searches = append(s.searches, "query:"+query)
+	return []config.Document{{ID: "doc-1", Text: "西湖在杭州"}}, nil
+}
+
+func (s *fakeVectorStore) SimilaritySearchByVector(_ stdcontext.Context, embedding []float32, _ int, _ float64, _ map[string]any) ([]config.Document, error) {
+	s.searches = append(s.searches, "vector")
+	return []config.Document{{ID: "doc-1", Text: "西湖在杭州"}}, nil
+}
+
+func TestAiClientAdvisorNodeResolvesEmbeddingModel(t *testing.T) {
+	registry := armory.NewBeanRegistry()
+	support := armory.NewAbstractArmorySupport(registry, 1)
+	vectorStore := &fakeVectorStore{}
+	// 模型节点先于顾问节点执行，顾问才能取到嵌入模型
+	modelNode := NewAiClientModelNode(support, NewAiClientAdvisorNode(support, nil, vectorStore))
+	modelNode.SetEmbeddingModelProvider(func(valobj.AiClientModelVO) (config.EmbeddingModel, error) {
+		return fakeEmbeddingModel{}, nil
+	})
+
+	dynamicContext := context.NewDynamicContext()
+	dynamicContext.SetValue("aiClientModelList", []valobj.AiClientModelVO{
+		{ID: 2, ModelType: "openai-embedding", ModelVersion: "text-embedding-test", BaseURL: "http://localhost"},
+	})
+	dynamicContext.SetValue("aiClientAdvisorList", []valobj.AiClientAdvisorVO{
+		{ID: 10, AdvisorName: "指定嵌入模型", AdvisorType: valobj.AdvisorTypeRagAnswer, RagAnswer: &valobj.RagAnswerVO{EmbeddingModelID: 2}},
+		{ID: 11, AdvisorName: "使用向量存储的嵌入模型", AdvisorType: valobj.AdvisorTypeRagAnswer},
+		{ID: 12, AdvisorName: "嵌入模型不存在", AdvisorType: valobj.AdvisorTypeRagAnswer, RagAnswer: &valobj.RagAnswerVO{EmbeddingModelID: 9}},
+	})
+	if _, err := modelNode.DoApply(&entity.AiAgentEngineStarterEntity{}, dynamicContext); err != nil {
+		t.Fatalf("DoApply 失败: %v", err)
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, info := range registry.List() {
+		dependsOn[info.Name] = info.DependsOn
+	}
+	if got := dependsOn["AiClientAdvisor_10"]; !reflect.DeepEqual(got, []string{"AiClientEmbeddingModel_2"}) {
+		t.Errorf("AiClientAdvisor_10 依赖 = %v, 期望 [AiClientEmbeddingModel_2]", got)
+	}
+	if got, ok := dependsOn["AiClientAdvisor_11"]; !ok || len(got) != 0 {
+		t.Errorf("AiClientAdvisor_11 依赖 = %v, 注册 %v, 期望注册且无依赖", got, ok)
+	}
+	if _, ok := dependsOn["AiClientAdvisor_12"]; ok {
+		t.Error("嵌入模型不存在时不应注册顾问")
+	}
+	failed := GetArmoryReport(dynamicContext).Failed
+	if len(failed) != 1 || failed[0].Bean != "AiClientAdvisor_12" || !strings.Contains(failed[0].Reason, "AiClientEmbeddingModel_9") {
+		t.Errorf("构建失败 = %+v, 期望 AiClientAdvisor_12 缺少嵌入模型", failed)
+	}
+
+	// 指定嵌入模型的顾问自行计算查询向量，未指定的交给向量存储
+	for _, beanName := range []string{"AiClientAdvisor_10", "AiClientAdvisor_11"} {
+		advisor, _ := armory.Get[Advisor](registry, beanName)
+		request := &AdvisedRequest{UserText: "西湖在哪", AdviseContext: map[string]any{}}
+		if _, err := advisor.AdviseRequest(stdcontext.Background(), request); err != nil {
+			t.Fatalf("%s AdviseRequest 失败: %v", beanName, err)
+		}
+	}
+	if want := []string{"vector", "query:西湖在哪"}; !reflect.DeepEqual(vectorStore.searches, want) {
+		t.Errorf("检索方式 = %v, 期望 %v", vectorStore.searches, want)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"smart-weaver/internal/domain/agent/model/entity"
 	"smart-weaver/internal/domain/agent/model/valobj"
@@ -20,38 +22,23 @@ type OpenAiApi struct {
 	EmbeddingsPath  string
 }
 
-// OpenAiChatOptions OpenAI聊天选项（模拟Java中的OpenAiChatOptions）
+// OpenAiChatOptions OpenAI聊天选项（模拟Java中的OpenAiChatOptions），各供应商共用
 type OpenAiChatOptions struct {
 	Model         string
-	ToolCallbacks interface{} // 工具回调
+	Temperature   *float64       // 为空时使用服务端默认值
+	MaxTokens     int            // 0 表示不限制
+	ToolCallbacks []ToolCallback // 工具回调
+
+	MaxToolIterations    int             // 工具调用循环最大轮数，0 表示使用默认值
+	ResponseFormat       *ResponseFormat // 结构化输出格式，为空时输出文本
+	MaxStructuredRetries int             // 结构化输出校验失败后的最大重试次数，0 表示使用默认值
 }
 
 // OpenAiChatModel OpenAI聊天模型（模拟Java中的OpenAiChatModel）
 type OpenAiChatModel struct {
 	OpenAiApi      *OpenAiApi
 	DefaultOptions *OpenAiChatOptions
-}
-
-// SyncMcpToolCallbackProvider 同步MCP工具回调提供者（模拟Java中的SyncMcpToolCallbackProvider）
-type SyncMcpToolCallbackProvider struct {
-	McpSyncClients []McpSyncClient
-}
-
-// GetToolCallbacks 获取工具回调
-func (provider *SyncMcpToolCallbackProvider) GetToolCallbacks() interface{} {
-	// 模拟返回工具回调集合
-	callbacks := make(map[string]interface{})
-	for i, client := range provider.McpSyncClients {
-		callbacks[fmt.Sprintf("callback_%d", i)] = client
-	}
-	return callbacks
-}
-
-// NewSyncMcpToolCallbackProvider 创建同步MCP工具回调提供者
-func NewSyncMcpToolCallbackProvider(mcpSyncClients []McpSyncClient) *SyncMcpToolCallbackProvider {
-	return &SyncMcpToolCallbackProvider{
-		McpSyncClients: mcpSyncClients,
-	}
+	Timeout        time.Duration // 单次请求超时，0 表示不限制
 }
 
 // OpenAiApiBuilder OpenAI API构建器
@@ -104,7 +91,13 @@ func (b *OpenAiApiBuilder) Build() *OpenAiApi {
 // OpenAiChatOptionsBuilder OpenAI聊天选项构建器
 type OpenAiChatOptionsBuilder struct {
 	model         string
-	toolCallbacks interface{}
+	temperature   *float64
+	maxTokens     int
+	toolCallbacks []ToolCallback
+
+	maxToolIterations    int
+	responseFormat       *ResponseFormat
+	maxStructuredRetries int
 }
 
 // NewOpenAiChatOptionsBuilder 创建OpenAI聊天选项构建器
@@ -118,17 +111,53 @@ func (b *OpenAiChatOptionsBuilder) Model(model string) *OpenAiChatOptionsBuilder
 	return b
 }
 
+// Temperature 设置采样温度
+func (b *OpenAiChatOptionsBuilder) Temperature(temperature float64) *OpenAiChatOptionsBuilder {
+	b.temperature = &temperature
+	return b
+}
+
+// MaxTokens 设置最大输出token数
+func (b *OpenAiChatOptionsBuilder) MaxTokens(maxTokens int) *OpenAiChatOptionsBuilder {
+	b.maxTokens = maxTokens
+	return b
+}
+
 // ToolCallbacks 设置工具回调
-func (b *OpenAiChatOptionsBuilder) ToolCallbacks(toolCallbacks interface{}) *OpenAiChatOptionsBuilder {
+func (b *OpenAiChatOptionsBuilder) ToolCallbacks(toolCallbacks []ToolCallback) *OpenAiChatOptionsBuilder {
 	b.toolCallbacks = toolCallbacks
 	return b
 }
 
+// MaxToolIterations 设置工具调用循环最大轮数
+func (b *OpenAiChatOptionsBuilder) MaxToolIterations(maxToolIterations int) *OpenAiChatOptionsBuilder {
+	b.maxToolIterations = maxToolIterations
+	return b
+}
+
+// ResponseFormat 设置结构化输出格式
+func (b *OpenAiChatOptionsBuilder) ResponseFormat(responseFormat *ResponseFormat) *OpenAiChatOptionsBuilder {
+	b.responseFormat = responseFormat
+	return b
+}
+
+// MaxStructuredRetries 设置结构化输出校验失败后的最大重试次数
+func (b *OpenAiChatOptionsBuilder) MaxStructuredRetries(maxStructuredRetries int) *OpenAiChatOptionsBuilder {
+	b.maxStructuredRetries = maxStructuredRetries
+	return b
+}
+
 // Build 构建OpenAiChatOptions
 func (b *OpenAiChatOptionsBuilder) Build() *OpenAiChatOptions {
 	return &OpenAiChatOptions{
 		Model:         b.model,
+		Temperature:   b.temperature,
+		MaxTokens:     b.maxTokens,
 		ToolCallbacks: b.toolCallbacks,
+
+		MaxToolIterations:    b.maxToolIterations,
+		ResponseFormat:       b.responseFormat,
+		MaxStructuredRetries: b.maxStructuredRetries,
 	}
 }
 
@@ -136,6 +165,7 @@ func (b *OpenAiChatOptionsBuilder) Build() *OpenAiChatOptions {
 type OpenAiChatModelBuilder struct {
 	openAiApi      *OpenAiApi
 	defaultOptions *OpenAiChatOptions
+	timeout        time.Duration
 }
 
 // NewOpenAiChatModelBuilder 创建OpenAI聊天模型构建器
@@ -155,31 +185,57 @@ func (b *OpenAiChatModelBuilder) DefaultOptions(defaultOptions *OpenAiChatOption
 	return b
 }
 
+// Timeout 设置请求超时
+func (b *OpenAiChatModelBuilder) Timeout(timeout time.Duration) *OpenAiChatModelBuilder {
+	b.timeout = timeout
+	return b
+}
+
 // Build 构建OpenAiChatModel
 func (b *OpenAiChatModelBuilder) Build() *OpenAiChatModel {
 	return &OpenAiChatModel{
 		OpenAiApi:      b.openAiApi,
 		DefaultOptions: b.defaultOptions,
+		Timeout:        b.timeout,
 	}
 }
 
 // AiClientModelNode AI客户端模型节点
 type AiClientModelNode struct {
 	*armory.AbstractArmorySupport
-	AiClientNode StrategyHandler
+	AiClientAdvisorNode StrategyHandler
+	ChatModelProviders  map[string]ChatModelProvider // 模型类型 -> 供应商
+	UsageRecorder       UsageRecorder                // 用量记录器，为空时不记录
+
+	// EmbeddingModelProvider 嵌入模型供应商，为空时嵌入模型配置构建失败
+	EmbeddingModelProvider EmbeddingModelProvider
 }
 
 // NewAiClientModelNode 创建AiClientModelNode实例
-func NewAiClientModelNode(aiClientNode StrategyHandler) *AiClientModelNode {
+// 默认注册 openai / azure / anthropic / ollama，其它供应商通过 RegisterChatModelProvider 注册
+func NewAiClientModelNode(support *armory.AbstractArmorySupport, aiClientAdvisorNode StrategyHandler) *AiClientModelNode {
 	return &AiClientModelNode{
-		AbstractArmorySupport: &armory.AbstractArmorySupport{
-			ThreadPool: make(chan func(), 100),
-			Deps:       make(map[string]any),
-		},
-		AiClientNode: aiClientNode,
+		AbstractArmorySupport: support,
+		AiClientAdvisorNode:   aiClientAdvisorNode,
+		ChatModelProviders:    defaultChatModelProviders(),
 	}
 }
 
+// SetUsageRecorder 设置用量记录器
+func (node *AiClientModelNode) SetUsageRecorder(usageRecorder UsageRecorder) {
+	node.UsageRecorder = usageRecorder
+}
+
+// SetEmbeddingModelProvider 设置嵌入模型供应商
+func (node *AiClientModelNode) SetEmbeddingModelProvider(provider EmbeddingModelProvider) {
+	node.EmbeddingModelProvider = provider
+}
+
+// RegisterChatModelProvider 注册模型供应商，模型类型不区分大小写，重复注册时覆盖
+func (node *AiClientModelNode) RegisterChatModelProvider(modelType string, provider ChatModelProvider) {
+	node.ChatModelProviders[normalizeModelType(modelType)] = provider
+}
+
 // DoApply 执行应用逻辑
 func (node *AiClientModelNode) DoApply(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (string, error) {
 	reqJSON, _ := json.Marshal(requestParameter)
@@ -187,29 +243,44 @@ func (node *AiClientModelNode) DoApply(requestParameter *entity.AiAgentEngineSta
 
 	// 从动态上下文获取AI客户端模型列表
 	aiClientModelListVal := dynamicContext.GetValue("aiClientModelList")
+	// 没有模型时仍路由到后续节点，由客户端节点记录缺失模型的客户端
 	if aiClientModelListVal == nil {
 		log.Println("没有可用的AI客户端模型配置")
-		return "", nil
+		return node.Router(requestParameter, dynamicContext)
 	}
 
 	aiClientModelList, ok := aiClientModelListVal.([]valobj.AiClientModelVO)
 	if !ok || len(aiClientModelList) == 0 {
 		log.Println("没有可用的AI客户端模型配置")
-		return "", nil
+		return node.Router(requestParameter, dynamicContext)
 	}
 
 	// 遍历模型列表，为每个模型创建对应的Bean
+	report := GetArmoryReport(dynamicContext)
 	for _, modelVO := range aiClientModelList {
-		// 创建OpenAiChatModel对象
-		chatModel, err := node.createOpenAiChatModel(modelVO)
+		// 嵌入模型注册为 AiClientEmbeddingModel_<id>，不能被客户端当作对话模型使用
+		if isEmbeddingModelType(modelVO.ModelType) {
+			node.registerEmbeddingModel(dynamicContext, report, modelVO)
+			continue
+		}
+
+		beanName := node.beanName(modelVO.ID)
+
+		// 按模型类型创建对话模型
+		chatModel, dependsOn, err := node.createChatModel(node.Beans(dynamicContext), modelVO)
 		if err != nil {
-			log.Printf("创建OpenAiChatModel失败: %v", err)
+			log.Printf("创建对话模型失败: %v", err)
+			report.AddFailed(beanName, err)
 			continue
 		}
 
 		// 注册Bean
-		beanName := node.beanName(modelVO.ID)
-		node.RegisterDependency(beanName, chatModel)
+		if err := node.RegisterBean(dynamicContext, beanName, chatModel, dependsOn...); err != nil {
+			log.Printf("注册Bean %s 失败: %v", beanName, err)
+			report.AddFailed(beanName, err)
+			continue
+		}
+		report.AddBuilt(beanName)
 	}
 
 	return node.Router(requestParameter, dynamicContext)
@@ -217,7 +288,7 @@ func (node *AiClientModelNode) DoApply(requestParameter *entity.AiAgentEngineSta
 
 // Get 获取下一个处理器
 func (node *AiClientModelNode) Get(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (StrategyHandler, error) {
-	return node.AiClientNode, nil
+	return node.AiClientAdvisorNode, nil
 }
 
 // Router 路由到下一个处理器
@@ -237,18 +308,17 @@ func (node *AiClientModelNode) beanName(id int64) string {
 	return "AiClientModel_" + strconv.FormatInt(id, 10)
 }
 
-// createOpenAiChatModel 创建OpenAiChatModel对象
-func (node *AiClientModelNode) createOpenAiChatModel(modelVO valobj.AiClientModelVO) (*OpenAiChatModel, error) {
-	// 构建OpenAiApi
-	openAiApi := NewOpenAiApiBuilder().
-		BaseURL(modelVO.BaseURL).
-		APIKey(modelVO.APIKey).
-		CompletionsPath(modelVO.CompletionsPath).
-		EmbeddingsPath(modelVO.EmbeddingsPath).
-		Build()
+// createChatModel 按模型类型选择供应商创建对话模型，返回使用到的MCP Bean名称
+func (node *AiClientModelNode) createChatModel(beans armory.BeanGetter, modelVO valobj.AiClientModelVO) (ChatModel, []string, error) {
+	modelType := normalizeModelType(modelVO.ModelType)
+	provider, ok := node.ChatModelProviders[modelType]
+	if !ok {
+		return nil, nil, fmt.Errorf("不支持的模型类型 %s", modelVO.ModelType)
+	}
 
 	// 收集MCP客户端
 	var mcpSyncClients []McpSyncClient
+	var dependsOn []string
 	toolConfigs := modelVO.AIClientModelToolConfigs
 	if len(toolConfigs) > 0 {
 		for _, toolConfig := range toolConfigs {
@@ -256,32 +326,77 @@ func (node *AiClientModelNode) createOpenAiChatModel(modelVO valobj.AiClientMode
 			mcpBeanName := "AiClientToolMcp_" + strconv.FormatInt(toolID, 10)
 
 			// 从依赖容器获取MCP客户端
-			mcpClientInterface := node.GetDependency(mcpBeanName)
-			if mcpClientInterface != nil {
-				if mcpSyncClient, ok := mcpClientInterface.(McpSyncClient); ok {
-					mcpSyncClients = append(mcpSyncClients, mcpSyncClient)
-				} else {
-					log.Printf("警告: Bean %s 不是McpSyncClient类型", mcpBeanName)
-				}
-			} else {
-				log.Printf("警告: 未找到Bean %s", mcpBeanName)
+			mcpSyncClient, ok := armory.Get[McpSyncClient](beans, mcpBeanName)
+			if !ok {
+				log.Printf("警告: 未找到MCP Bean %s", mcpBeanName)
+				continue
 			}
+			mcpSyncClients = append(mcpSyncClients, mcpSyncClient)
+			dependsOn = append(dependsOn, mcpBeanName)
 		}
 	}
 
 	// 创建工具回调提供者
-	toolCallbackProvider := NewSyncMcpToolCallbackProvider(mcpSyncClients)
-
-	// 构建OpenAiChatModel
-	chatModel := NewOpenAiChatModelBuilder().
-		OpenAiApi(openAiApi).
-		DefaultOptions(
-			NewOpenAiChatOptionsBuilder().
-				Model(modelVO.ModelVersion).
-				ToolCallbacks(toolCallbackProvider.GetToolCallbacks()).
-				Build(),
-		).
+	prefixStrategy, err := ParseToolNamePrefixStrategy(modelVO.ToolNamePrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	toolCallbackProvider := NewSyncMcpToolCallbackProvider(mcpSyncClients).WithPrefixStrategy(prefixStrategy)
+
+	defaultOptions := NewOpenAiChatOptionsBuilder().
+		Model(modelVO.ModelVersion).
+		ToolCallbacks(toolCallbackProvider.GetToolCallbacks()).
 		Build()
 
-	return chatModel, nil
+	chatModel, err := provider(modelVO, defaultOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建 %s 模型失败: %w", modelType, err)
+	}
+
+	// 记录用量，被限流拒绝的请求不记录
+	if node.UsageRecorder != nil {
+		chatModel = &MeteredChatModel{
+			ChatModel: chatModel,
+			ModelID:   modelVO.ID,
+			ModelName: modelVO.ModelVersion,
+			Recorder:  node.UsageRecorder,
+		}
+	}
+
+	// 配置了限流时包装限流器，限流状态随模型 Bean 重建而重置
+	if modelVO.RpmLimit > 0 || modelVO.TpmLimit > 0 || modelVO.MaxConcurrency > 0 {
+		chatModel = &RateLimitedChatModel{
+			ChatModel: chatModel,
+			Limiter: NewRateLimiter(node.beanName(modelVO.ID), modelVO.RpmLimit, modelVO.TpmLimit,
+				modelVO.MaxConcurrency, time.Duration(modelVO.QueueTimeout)*time.Second),
+		}
+	}
+	return chatModel, dependsOn, nil
+}
+
+// isEmbeddingModelType 判断模型类型是否为嵌入模型，如 embedding / openai-embedding
+func isEmbeddingModelType(modelType string) bool {
+	return strings.Contains(strings.ToLower(modelType), "embedding")
+}
+
+// registerEmbeddingModel 通过嵌入模型供应商创建并注册嵌入模型
+func (node *AiClientModelNode) registerEmbeddingModel(dynamicContext *context.DynamicContext, report *ArmoryReport, modelVO valobj.AiClientModelVO) {
+	beanName := embeddingModelBeanName(modelVO.ID)
+	if node.EmbeddingModelProvider == nil {
+		report.AddFailed(beanName, fmt.Errorf("未配置嵌入模型供应商，无法创建 %s 模型", modelVO.ModelType))
+		return
+	}
+
+	embeddingModel, err := node.EmbeddingModelProvider(modelVO)
+	if err != nil {
+		log.Printf("创建嵌入模型失败: %v", err)
+		report.AddFailed(beanName, err)
+		return
+	}
+	if err := node.RegisterBean(dynamicContext, beanName, embeddingModel); err != nil {
+		log.Printf("注册Bean %s 失败: %v", beanName, err)
+		report.AddFailed(beanName, err)
+		return
+	}
+	report.AddBuilt(beanName)
 }
@@ -0,0 +1,56 @@
+package node
+
+import (
+	stdcontext "context"
+	"strings"
+	"testing"
+
+	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory"
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+)
+
+// fakeEmbeddingModel 固定返回零向量的嵌入模型
+type fakeEmbeddingModel struct{}
+
+func (fakeEmbeddingModel) Embed(_ stdcontext.Context, texts []string) ([][]float32, error) {
+	return make([][]float32, len(texts)), nil
+}
+
+func (fakeEmbeddingModel) Dimensions(stdcontext.Context) (int, error) { return 2, nil }
+
+func TestAiClientModelNodeRegistersEmbeddingModelSeparately(t *testing.T) {
+	registry := armory.NewBeanRegistry()
+	modelNode := NewAiClientModelNode(armory.NewAbstractArmorySupport(registry, 1), nil)
+	modelNode.SetEmbeddingModelProvider(func(valobj.AiClientModelVO) (config.EmbeddingModel, error) {
+		return fakeEmbeddingModel{}, nil
+	})
+
+	dynamicContext := context.NewDynamicContext()
+	dynamicContext.SetValue("aiClientModelList", []valobj.AiClientModelVO{
+		{ID: 1, ModelType: "openai", ModelVersion: "gpt-test", BaseURL: "http://localhost"},
+		{ID: 2, ModelType: "openai-embedding", ModelVersion: "text-embedding-test", BaseURL: "http://localhost"},
+	})
+	if _, err := modelNode.DoApply(&entity.AiAgentEngineStarterEntity{}, dynamicContext); err != nil {
+		t.Fatalf("DoApply 失败: %v", err)
+	}
+
+	if _, ok := armory.Get[ChatModel](registry, "AiClientModel_1"); !ok {
+		t.Error("对话模型应注册为 AiClientModel_1")
+	}
+	if _, ok := registry.Get("AiClientModel_2"); ok {
+		t.Error("嵌入模型不应注册为 AiClientModel_2")
+	}
+	if _, ok := armory.Get[config.EmbeddingModel](registry, "AiClientEmbeddingModel_2"); !ok {
+		t.Error("嵌入模型应注册为 AiClientEmbeddingModel_2")
+	}
+
+	// 客户端指向嵌入模型时给出明确错误
+	clientNode := NewAiClientNode(armory.NewAbstractArmorySupport(registry, 1))
+	_, _, err := clientNode.createChatModel(registry, valobj.AiClientVO{ClientID: 1, ModelID: 2})
+	if err == nil || !strings.Contains(err.Error(), "嵌入模型") {
+		t.Errorf("错误 = %v, 期望提示模型是嵌入模型", err)
+	}
+}
@@ -0,0 +1,198 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory"
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+)
+
+// AiClientNode 客户端节点，组合模型、顾问和工具构建对话客户端，是构建链的最后一个节点
+type AiClientNode struct {
+	*armory.AbstractArmorySupport
+	breakers *circuitBreakers // 模型组共用的熔断器，按模型 Bean 名称区分
+}
+
+// NewAiClientNode 创建AiClientNode实例
+func NewAiClientNode(support *armory.AbstractArmorySupport) *AiClientNode {
+	return &AiClientNode{
+		AbstractArmorySupport: support,
+		breakers:              newCircuitBreakers(),
+	}
+}
+
+// DoApply 执行应用逻辑
+func (node *AiClientNode) DoApply(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (string, error) {
+	reqJSON, _ := json.Marshal(requestParameter)
+	log.Printf("Ai Agent 构建，客户端节点 %s", string(reqJSON))
+
+	// 从动态上下文获取客户端列表，请求中不存在或未启用的客户端记为失败
+	report := GetArmoryReport(dynamicContext)
+	aiClientList, _ := dynamicContext.GetValue("aiClientList").([]valobj.AiClientVO)
+	existClientIds := make(map[int64]bool, len(aiClientList))
+	for _, clientVO := range aiClientList {
+		existClientIds[clientVO.ClientID] = true
+	}
+	for _, clientID := range requestParameter.ClientIDList {
+		if !existClientIds[clientID] {
+			report.AddFailed(node.beanName(clientID), errors.New("客户端不存在或未启用"))
+		}
+	}
+	if len(aiClientList) == 0 {
+		log.Println("没有可用的AI客户端配置")
+		return node.Router(requestParameter, dynamicContext)
+	}
+
+	// 顾问按客户端分组
+	aiClientAdvisorList, _ := dynamicContext.GetValue("aiClientAdvisorList").([]valobj.AiClientAdvisorVO)
+	advisorIDMap := make(map[int64][]int64)
+	for _, advisorVO := range aiClientAdvisorList {
+		advisorIDMap[advisorVO.ClientID] = append(advisorIDMap[advisorVO.ClientID], advisorVO.ID)
+	}
+
+	// 遍历处理每个客户端配置
+	for _, clientVO := range aiClientList {
+		beanName := node.beanName(clientVO.ClientID)
+		chatClient, dependsOn, err := node.createChatClient(node.Beans(dynamicContext), clientVO, advisorIDMap[clientVO.ClientID])
+		if err != nil {
+			log.Printf("创建ChatClient失败 clientId=%d: %v", clientVO.ClientID, err)
+			report.AddFailed(beanName, err)
+			continue
+		}
+
+		// 注册Bean
+		if err := node.RegisterBean(dynamicContext, beanName, chatClient, dependsOn...); err != nil {
+			log.Printf("注册Bean %s 失败: %v", beanName, err)
+			report.AddFailed(beanName, err)
+			continue
+		}
+		report.AddBuilt(beanName)
+	}
+
+	return node.Router(requestParameter, dynamicContext)
+}
+
+// Get 获取下一个处理器，客户端节点之后没有处理器
+func (node *AiClientNode) Get(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (StrategyHandler, error) {
+	return nil, nil
+}
+
+// Router 路由到下一个处理器
+func (node *AiClientNode) Router(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (string, error) {
+	nextHandler, err := node.Get(requestParameter, dynamicContext)
+	if err != nil {
+		return "", err
+	}
+	if nextHandler == nil {
+		return "completed", nil
+	}
+	return nextHandler.DoApply(requestParameter, dynamicContext)
+}
+
+// beanName 生成Bean名称
+func (node *AiClientNode) beanName(clientID int64) string {
+	return "AiClient_" + strconv.FormatInt(clientID, 10)
+}
+
+// createChatClient 创建ChatClient，返回使用到的Bean名称，模型必须已构建，缺失的顾问、工具和系统提示词跳过
+func (node *AiClientNode) createChatClient(beans armory.BeanGetter, clientVO valobj.AiClientVO, advisorIDList []int64) (*ChatClient, []string, error) {
+	chatModel, dependsOn, err := node.createChatModel(beans, clientVO)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 收集顾问
+	var advisors []Advisor
+	for _, advisorID := range advisorIDList {
+		advisorBeanName := "AiClientAdvisor_" + strconv.FormatInt(advisorID, 10)
+		advisor, ok := armory.Get[Advisor](beans, advisorBeanName)
+		if !ok {
+			log.Printf("警告: 未找到顾问 Bean %s", advisorBeanName)
+			continue
+		}
+		advisors = append(advisors, advisor)
+		dependsOn = append(dependsOn, advisorBeanName)
+	}
+
+	// 收集客户端级MCP工具
+	var mcpSyncClients []McpSyncClient
+	for _, mcpID := range clientVO.McpIDList {
+		mcpBeanName := "AiClientToolMcp_" + strconv.FormatInt(mcpID, 10)
+		mcpSyncClient, ok := armory.Get[McpSyncClient](beans, mcpBeanName)
+		if !ok {
+			log.Printf("警告: 未找到MCP Bean %s", mcpBeanName)
+			continue
+		}
+		mcpSyncClients = append(mcpSyncClients, mcpSyncClient)
+		dependsOn = append(dependsOn, mcpBeanName)
+	}
+
+	var toolCallbacks []ToolCallback
+	if len(mcpSyncClients) > 0 {
+		prefixStrategy, err := ParseToolNamePrefixStrategy(clientVO.ToolNamePrefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		toolCallbacks = NewSyncMcpToolCallbackProvider(mcpSyncClients).WithPrefixStrategy(prefixStrategy).GetToolCallbacks()
+	}
+
+	// 系统提示词由系统提示词节点渲染，未配置时为空
+	systemPrompt, _ := armory.Get[string](beans, "AiClientSystemPrompt_"+strconv.FormatInt(clientVO.ClientID, 10))
+
+	return NewChatClientBuilder(chatModel).
+		DefaultSystem(systemPrompt).
+		DefaultToolCallbacks(toolCallbacks).
+		DefaultAdvisors(advisors...).
+		Build(), dependsOn, nil
+}
+
+// createChatModel 获取客户端使用的对话模型，配置了多个模型时组合为模型组，缺失的成员跳过
+func (node *AiClientNode) createChatModel(beans armory.BeanGetter, clientVO valobj.AiClientVO) (ChatModel, []string, error) {
+	modelRels := clientVO.GetModelRels()
+	if len(modelRels) == 1 {
+		modelBeanName := "AiClientModel_" + strconv.FormatInt(modelRels[0].ModelID, 10)
+		chatModel, ok := armory.Get[ChatModel](beans, modelBeanName)
+		if !ok {
+			return nil, nil, missingChatModelError(beans, modelRels[0].ModelID)
+		}
+		return chatModel, []string{modelBeanName}, nil
+	}
+
+	var members []*ModelGroupMember
+	var dependsOn []string
+	for _, rel := range modelRels {
+		modelBeanName := "AiClientModel_" + strconv.FormatInt(rel.ModelID, 10)
+		chatModel, ok := armory.Get[ChatModel](beans, modelBeanName)
+		if !ok {
+			log.Printf("警告: %v", missingChatModelError(beans, rel.ModelID))
+			continue
+		}
+		members = append(members, &ModelGroupMember{
+			Name:     modelBeanName,
+			Model:    chatModel,
+			Priority: rel.Priority,
+			Weight:   rel.Weight,
+			Breaker:  node.breakers.get(modelBeanName),
+		})
+		dependsOn = append(dependsOn, modelBeanName)
+	}
+	if len(members) == 0 {
+		return nil, nil, errors.New("模型组中的对话模型均未构建")
+	}
+	return NewModelGroup(node.beanName(clientVO.ClientID), members), dependsOn, nil
+}
+
+// missingChatModelError 对话模型 Bean 缺失的原因，模型配置为嵌入模型时明确提示
+func missingChatModelError(beans armory.BeanGetter, modelID int64) error {
+	if _, ok := armory.Get[config.EmbeddingModel](beans, embeddingModelBeanName(modelID)); ok {
+		return fmt.Errorf("模型 %d 是嵌入模型，不能作为客户端的对话模型", modelID)
+	}
+	return fmt.Errorf("未找到对话模型 Bean AiClientModel_%d", modelID)
+}
@@ -0,0 +1,107 @@
+package node
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory"
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+)
+
+// AiClientSystemPromptNode 系统提示词节点，渲染每个客户端生效的提示词供客户端节点使用
+type AiClientSystemPromptNode struct {
+	*armory.AbstractArmorySupport
+	AiClientNode StrategyHandler
+}
+
+// NewAiClientSystemPromptNode 创建AiClientSystemPromptNode实例
+func NewAiClientSystemPromptNode(support *armory.AbstractArmorySupport, aiClientNode StrategyHandler) *AiClientSystemPromptNode {
+	return &AiClientSystemPromptNode{
+		AbstractArmorySupport: support,
+		AiClientNode:          aiClientNode,
+	}
+}
+
+// DoApply 执行应用逻辑
+func (node *AiClientSystemPromptNode) DoApply(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (string, error) {
+	reqJSON, _ := json.Marshal(requestParameter)
+	log.Printf("Ai Agent 构建，system prompt 节点 %s", string(reqJSON))
+
+	// 从动态上下文获取系统提示词列表
+	aiClientSystemPromptListVal := dynamicContext.GetValue("aiClientSystemPromptList")
+	if aiClientSystemPromptListVal == nil {
+		log.Println("没有可用的AI客户端系统提示词配置")
+		return node.Router(requestParameter, dynamicContext)
+	}
+
+	aiClientSystemPromptList, ok := aiClientSystemPromptListVal.([]valobj.AiClientSystemPromptVO)
+	if !ok || len(aiClientSystemPromptList) == 0 {
+		log.Println("没有可用的AI客户端系统提示词配置")
+		return node.Router(requestParameter, dynamicContext)
+	}
+
+	// 同一客户端的多个提示词按顺序拼接
+	lookup := node.variableLookup(requestParameter, dynamicContext)
+	var clientIdList []int64
+	promptMap := make(map[int64][]string)
+	for _, promptVO := range aiClientSystemPromptList {
+		if _, exists := promptMap[promptVO.ClientID]; !exists {
+			clientIdList = append(clientIdList, promptVO.ClientID)
+		}
+		promptMap[promptVO.ClientID] = append(promptMap[promptVO.ClientID], RenderPromptTemplate(promptVO.PromptContent, lookup))
+		log.Printf("客户端 %d 使用系统提示词 %s 版本 %d", promptVO.ClientID, promptVO.PromptName, promptVO.Version)
+	}
+
+	// 注册Bean
+	report := GetArmoryReport(dynamicContext)
+	for _, clientID := range clientIdList {
+		beanName := node.beanName(clientID)
+		if err := node.RegisterBean(dynamicContext, beanName, strings.Join(promptMap[clientID], "\n\n")); err != nil {
+			log.Printf("注册Bean %s 失败: %v", beanName, err)
+			report.AddFailed(beanName, err)
+			continue
+		}
+		report.AddBuilt(beanName)
+	}
+
+	return node.Router(requestParameter, dynamicContext)
+}
+
+// Get 获取下一个处理器
+func (node *AiClientSystemPromptNode) Get(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (StrategyHandler, error) {
+	return node.AiClientNode, nil
+}
+
+// Router 路由到下一个处理器
+func (node *AiClientSystemPromptNode) Router(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (string, error) {
+	nextHandler, err := node.Get(requestParameter, dynamicContext)
+	if err != nil {
+		return "", err
+	}
+	if nextHandler == nil {
+		return "completed", nil
+	}
+	return nextHandler.DoApply(requestParameter, dynamicContext)
+}
+
+// beanName 生成Bean名称
+func (node *AiClientSystemPromptNode) beanName(clientID int64) string {
+	return "AiClientSystemPrompt_" + strconv.FormatInt(clientID, 10)
+}
+
+// variableLookup 变量取值，请求参数优先，其次为动态上下文中的标量值
+func (node *AiClientSystemPromptNode) variableLookup(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		if value, ok := requestParameter.PromptVariables[name]; ok {
+			return value, true
+		}
+		if value, ok := dynamicContext.GetValueWithType(name); ok {
+			return promptScalar(value)
+		}
+		return "", false
+	}
+}
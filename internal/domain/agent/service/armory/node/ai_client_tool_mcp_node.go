@@ -1,6 +1,7 @@
 package node
 
 import (
+	stdcontext "context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,55 +18,28 @@ import (
 
 // McpSyncClient MCP同步客户端接口（模拟Java中的McpSyncClient）
 type McpSyncClient interface {
-	Initialize() (interface{}, error)
+	// Name 获取MCP名称
+	Name() string
+	Initialize() (*McpInitializeResult, error)
 	SetRequestTimeout(timeout time.Duration)
-}
-
-// HttpClientSseClientTransport SSE传输客户端（模拟Java中的HttpClientSseClientTransport）
-type HttpClientSseClientTransport struct {
-	BaseURI     string
-	SseEndpoint string
-}
-
-// StdioClientTransport Stdio传输客户端（模拟Java中的StdioClientTransport）
-type StdioClientTransport struct {
-	ServerParams *ServerParameters
-}
-
-// ServerParameters 服务器参数（模拟Java中的ServerParameters）
-type ServerParameters struct {
-	Command string
-	Args    []string
-}
-
-// MockMcpSyncClient 模拟MCP客户端实现
-type MockMcpSyncClient struct {
-	transport      interface{}
-	requestTimeout time.Duration
-}
-
-func (c *MockMcpSyncClient) Initialize() (interface{}, error) {
-	return map[string]interface{}{"status": "initialized"}, nil
-}
-
-func (c *MockMcpSyncClient) SetRequestTimeout(timeout time.Duration) {
-	c.requestTimeout = timeout
+	// ListTools 分页查询工具列表
+	ListTools(ctx stdcontext.Context, cursor string) (*McpListToolsResult, error)
+	// CallTool 调用工具
+	CallTool(ctx stdcontext.Context, request McpCallToolRequest) (*McpCallToolResult, error)
+	Close() error
 }
 
 // AiClientToolMcpNode Tool MCP节点
 type AiClientToolMcpNode struct {
 	*armory.AbstractArmorySupport
-	AiClientAdvisorNode StrategyHandler
+	AiClientModelNode StrategyHandler
 }
 
 // NewAiClientToolMcpNode 创建AiClientToolMcpNode实例
-func NewAiClientToolMcpNode(aiClientAdvisorNode StrategyHandler) *AiClientToolMcpNode {
+func NewAiClientToolMcpNode(support *armory.AbstractArmorySupport, aiClientModelNode StrategyHandler) *AiClientToolMcpNode {
 	return &AiClientToolMcpNode{
-		AbstractArmorySupport: &armory.AbstractArmorySupport{
-			ThreadPool: make(chan func(), 100),
-			Deps:       make(map[string]any),
-		},
-		AiClientAdvisorNode: aiClientAdvisorNode,
+		AbstractArmorySupport: support,
+		AiClientModelNode:     aiClientModelNode,
 	}
 }
 
@@ -88,17 +62,25 @@ func (node *AiClientToolMcpNode) DoApply(requestParameter *entity.AiAgentEngineS
 	}
 
 	// 遍历处理每个MCP配置
+	report := GetArmoryReport(dynamicContext)
 	for _, mcpVO := range aiClientToolMcpList {
+		beanName := node.beanName(mcpVO.ID)
+
 		// 创建McpSyncClient对象
 		mcpSyncClient, err := node.createMcpSyncClient(mcpVO)
 		if err != nil {
 			log.Printf("创建MCP客户端失败: %v", err)
+			report.AddFailed(beanName, err)
 			continue
 		}
 
 		// 注册Bean
-		beanName := node.beanName(mcpVO.ID)
-		node.RegisterDependency(beanName, mcpSyncClient)
+		if err := node.RegisterBean(dynamicContext, beanName, mcpSyncClient); err != nil {
+			log.Printf("注册Bean %s 失败: %v", beanName, err)
+			report.AddFailed(beanName, err)
+			continue
+		}
+		report.AddBuilt(beanName)
 	}
 
 	return node.Router(requestParameter, dynamicContext)
@@ -106,7 +88,7 @@ func (node *AiClientToolMcpNode) DoApply(requestParameter *entity.AiAgentEngineS
 
 // Get 获取下一个处理器
 func (node *AiClientToolMcpNode) Get(requestParameter *entity.AiAgentEngineStarterEntity, dynamicContext *context.DynamicContext) (StrategyHandler, error) {
-	return node.AiClientAdvisorNode, nil
+	return node.AiClientModelNode, nil
 }
 
 // Router 路由到下一个处理器
@@ -135,6 +117,8 @@ func (node *AiClientToolMcpNode) createMcpSyncClient(aiClientToolMcpVO valobj.Ai
 		return node.createSseMcpClient(aiClientToolMcpVO)
 	case "stdio":
 		return node.createStdioMcpClient(aiClientToolMcpVO)
+	case "streamable_http":
+		return node.createStreamableHttpMcpClient(aiClientToolMcpVO)
 	default:
 		return nil, fmt.Errorf("err! transportType %s not exist!", transportType)
 	}
@@ -170,20 +154,15 @@ func (node *AiClientToolMcpNode) createSseMcpClient(aiClientToolMcpVO valobj.AiC
 	}
 
 	// 创建SSE传输客户端
-	sseClientTransport := &HttpClientSseClientTransport{
-		BaseURI:     baseURI,
-		SseEndpoint: sseEndpoint,
-	}
+	sseClientTransport := NewHttpClientSseClientTransport(baseURI, sseEndpoint)
 
 	// 创建MCP客户端
-	mcpSyncClient := &MockMcpSyncClient{
-		transport:      sseClientTransport,
-		requestTimeout: time.Duration(aiClientToolMcpVO.RequestTimeout) * time.Minute,
-	}
+	mcpSyncClient := NewDefaultMcpSyncClient(aiClientToolMcpVO.McpName, sseClientTransport, time.Duration(aiClientToolMcpVO.RequestTimeout)*time.Minute)
 
-	// 初始化客户端
+	// 初始化客户端，失败时断开事件流
 	initResult, err := mcpSyncClient.Initialize()
 	if err != nil {
+		_ = mcpSyncClient.Close()
 		return nil, fmt.Errorf("SSE MCP初始化失败: %v", err)
 	}
 
@@ -208,25 +187,51 @@ func (node *AiClientToolMcpNode) createStdioMcpClient(aiClientToolMcpVO valobj.A
 	stdioParams := &ServerParameters{
 		Command: stdio.Command,
 		Args:    stdio.Args,
+		Env:     stdio.Env,
 	}
 
 	// 创建Stdio传输客户端
-	stdioClientTransport := &StdioClientTransport{
-		ServerParams: stdioParams,
-	}
+	stdioClientTransport := NewStdioClientTransport(stdioParams)
 
 	// 创建MCP客户端
-	mcpSyncClient := &MockMcpSyncClient{
-		transport:      stdioClientTransport,
-		requestTimeout: time.Duration(aiClientToolMcpVO.RequestTimeout) * time.Second,
-	}
+	mcpSyncClient := NewDefaultMcpSyncClient(aiClientToolMcpVO.McpName, stdioClientTransport, time.Duration(aiClientToolMcpVO.RequestTimeout)*time.Second)
 
-	// 初始化客户端
+	// 初始化客户端，失败时回收子进程
 	initResult, err := mcpSyncClient.Initialize()
 	if err != nil {
+		_ = mcpSyncClient.Close()
 		return nil, fmt.Errorf("Stdio MCP初始化失败: %v", err)
 	}
 
 	log.Printf("Tool Stdio MCP Initialized %+v", initResult)
 	return mcpSyncClient, nil
 }
+
+// createStreamableHttpMcpClient 创建Streamable HTTP MCP客户端
+func (node *AiClientToolMcpNode) createStreamableHttpMcpClient(aiClientToolMcpVO valobj.AiClientToolMcpVO) (McpSyncClient, error) {
+	transportConfig := aiClientToolMcpVO.TransportConfigStreamableHttp
+	if transportConfig == nil {
+		return nil, errors.New("Streamable HTTP传输配置为空")
+	}
+
+	endpoint := transportConfig.Endpoint
+	if endpoint == "" {
+		endpoint = "/mcp"
+	}
+
+	// 创建Streamable HTTP传输客户端
+	streamableHttpTransport := NewStreamableHttpClientTransport(transportConfig.BaseURI, endpoint, transportConfig.Headers)
+
+	// 创建MCP客户端
+	mcpSyncClient := NewDefaultMcpSyncClient(aiClientToolMcpVO.McpName, streamableHttpTransport, time.Duration(aiClientToolMcpVO.RequestTimeout)*time.Minute)
+
+	// 初始化客户端，失败时终止会话
+	initResult, err := mcpSyncClient.Initialize()
+	if err != nil {
+		_ = mcpSyncClient.Close()
+		return nil, fmt.Errorf("Streamable HTTP MCP初始化失败: %v", err)
+	}
+
+	log.Printf("Tool Streamable HTTP MCP Initialized %+v", initResult)
+	return mcpSyncClient, nil
+}
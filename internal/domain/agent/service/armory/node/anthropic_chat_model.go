@@ -0,0 +1,471 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultAnthropicMessagesPath 未配置时使用的 Messages 接口路径
+	defaultAnthropicMessagesPath = "/v1/messages"
+	// defaultAnthropicVersion anthropic-version 请求头
+	defaultAnthropicVersion = "2023-06-01"
+	// defaultAnthropicMaxTokens Messages 接口要求 max_tokens，未配置时使用的值
+	defaultAnthropicMaxTokens = 4096
+)
+
+// AnthropicApi Anthropic API配置（模拟Java中的AnthropicApi）
+type AnthropicApi struct {
+	BaseURL      string
+	APIKey       string // 通过 x-api-key 请求头传递
+	MessagesPath string // 为空时使用 /v1/messages
+	Version      string // anthropic-version，为空时使用 2023-06-01
+}
+
+// AnthropicChatModel Anthropic Messages API 聊天模型
+// 系统消息合并为 system 参数，工具结果以 tool_result 内容块放在 user 消息中
+// 接口不支持 response_format，结构化输出以强制调用同名工具的方式实现，工具参数作为文本内容返回
+type AnthropicChatModel struct {
+	AnthropicApi   *AnthropicApi
+	DefaultOptions *OpenAiChatOptions
+	Timeout        time.Duration // 单次请求超时，0 表示不限制
+}
+
+// anthropicMessagesRequest /v1/messages 请求体
+type anthropicMessagesRequest struct {
+	Model       string               `json:"model"`
+	System      string               `json:"system,omitempty"`
+	Messages    []anthropicMessage   `json:"messages"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	Stream      bool                 `json:"stream,omitempty"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// anthropicToolChoice 工具选择，any 要求调用任一工具，tool 要求调用指定工具
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// anthropicMessage 请求消息
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicContentBlock 内容块，按 Type 区分 text / tool_use / tool_result
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+// anthropicTool 工具定义
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// anthropicMessagesResponse /v1/messages 响应体
+type anthropicMessagesResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+// anthropicUsage token 用量
+type anthropicUsage struct {
+	InputTokens          int `json:"input_tokens"`
+	OutputTokens         int `json:"output_tokens"`
+	CacheReadInputTokens int `json:"cache_read_input_tokens"`
+}
+
+// anthropicStreamEvent 流式事件，按 Type 使用不同字段
+type anthropicStreamEvent struct {
+	Type         string                     `json:"type"`
+	Index        int                        `json:"index"`
+	Message      *anthropicMessagesResponse `json:"message"`
+	ContentBlock *anthropicContentBlock     `json:"content_block"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *anthropicUsage       `json:"usage"`
+	Error *anthropicErrorDetail `json:"error"`
+}
+
+// anthropicErrorDetail 错误信息
+type anthropicErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Call 同步调用 Messages 接口，配置了工具回调时自动执行工具调用循环
+func (model *AnthropicChatModel) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	return callWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.callOnce)
+}
+
+// Stream 流式调用 Messages 接口，配置了工具回调时在流内完成工具调用循环
+func (model *AnthropicChatModel) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	return streamWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.streamOnce)
+}
+
+// GetDefaultOptions 默认选项
+func (model *AnthropicChatModel) GetDefaultOptions() *OpenAiChatOptions {
+	return model.DefaultOptions
+}
+
+// callOnce 单次同步请求
+func (model *AnthropicChatModel) callOnce(ctx context.Context, messages []Message, options *OpenAiChatOptions) (*ChatResponse, error) {
+	resp, cancel, err := model.post(ctx, buildAnthropicRequest(messages, options, false))
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	var message anthropicMessagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return nil, fmt.Errorf("解析模型响应失败: %w", err)
+	}
+	return message.toChatResponse(structuredOutputTool(options)), nil
+}
+
+// streamOnce 单次流式请求，内容块按 index 转换为文本或工具调用片段，结构化输出工具的参数转换为文本片段
+func (model *AnthropicChatModel) streamOnce(ctx context.Context, messages []Message, options *OpenAiChatOptions) (<-chan ChatStreamChunk, error) {
+	outputTool := structuredOutputTool(options)
+	resp, cancel, err := model.post(ctx, buildAnthropicRequest(messages, options, true))
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan ChatStreamChunk)
+	go func() {
+		defer close(chunks)
+		defer cancel()
+		defer resp.Body.Close()
+
+		send := func(chunk ChatStreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var id, modelName string
+		outputBlocks := make(map[int]bool) // 结构化输出工具的内容块
+		toolUsed := false                  // 是否调用了结构化输出工具以外的工具
+		done := false
+		err := readSseEvents(resp.Body, func(event *sseEvent) bool {
+			data := strings.TrimSpace(event.Data)
+			if data == "" {
+				return true
+			}
+
+			var streamEvent anthropicStreamEvent
+			if err := json.Unmarshal([]byte(data), &streamEvent); err != nil {
+				send(ChatStreamChunk{Err: fmt.Errorf("解析模型流式响应失败: %w", err)})
+				return false
+			}
+
+			generation := Generation{Message: Message{Role: MessageRoleAssistant}}
+			response := &ChatResponse{ID: id, Model: modelName}
+			switch streamEvent.Type {
+			case "message_start":
+				if streamEvent.Message == nil {
+					return true
+				}
+				id, modelName = streamEvent.Message.ID, streamEvent.Message.Model
+				response.ID, response.Model = id, modelName
+				response.Usage = streamEvent.Message.Usage.toUsage()
+				return send(ChatStreamChunk{Response: response})
+			case "content_block_start":
+				block := streamEvent.ContentBlock
+				if block == nil || block.Type != "tool_use" {
+					return true
+				}
+				if outputTool != "" && block.Name == outputTool {
+					outputBlocks[streamEvent.Index] = true
+					return true
+				}
+				toolUsed = true
+				generation.Message.ToolCalls = []ToolCall{{Index: streamEvent.Index, ID: block.ID, Name: block.Name}}
+			case "content_block_delta":
+				if streamEvent.Delta == nil {
+					return true
+				}
+				switch streamEvent.Delta.Type {
+				case "text_delta":
+					generation.Message.Content = streamEvent.Delta.Text
+				case "input_json_delta":
+					if outputBlocks[streamEvent.Index] {
+						generation.Message.Content = streamEvent.Delta.PartialJSON
+						break
+					}
+					generation.Message.ToolCalls = []ToolCall{{Index: streamEvent.Index, Arguments: streamEvent.Delta.PartialJSON}}
+				default:
+					return true
+				}
+			case "message_delta":
+				if streamEvent.Delta != nil {
+					generation.FinishReason = anthropicFinishReason(streamEvent.Delta.StopReason)
+					if len(outputBlocks) > 0 && !toolUsed && generation.FinishReason == "tool_calls" {
+						generation.FinishReason = "stop"
+					}
+				}
+				if streamEvent.Usage != nil {
+					response.Usage = Usage{
+						CompletionTokens: streamEvent.Usage.OutputTokens,
+						TotalTokens:      streamEvent.Usage.OutputTokens,
+					}
+				}
+			case "message_stop":
+				done = true
+				return false
+			case "error":
+				apiErr := &ChatModelApiError{StatusCode: http.StatusOK}
+				if streamEvent.Error != nil {
+					apiErr.Type = streamEvent.Error.Type
+					apiErr.Message = streamEvent.Error.Message
+				}
+				send(ChatStreamChunk{Err: apiErr})
+				return false
+			default:
+				return true
+			}
+
+			response.Generations = []Generation{generation}
+			return send(ChatStreamChunk{Response: response})
+		})
+		if err != nil && ctx.Err() == nil {
+			send(ChatStreamChunk{Err: fmt.Errorf("读取模型流式响应失败: %w", err)})
+			return
+		}
+		if !done && ctx.Err() != nil && !errors.Is(ctx.Err(), context.Canceled) {
+			send(ChatStreamChunk{Err: ctx.Err()})
+		}
+	}()
+
+	return chunks, nil
+}
+
+// post 发送请求，非 2xx 响应转为 ChatModelApiError
+func (model *AnthropicChatModel) post(ctx context.Context, request *anthropicMessagesRequest) (*http.Response, context.CancelFunc, error) {
+	api := model.AnthropicApi
+	if api == nil {
+		return nil, nil, errors.New("AnthropicApi未配置")
+	}
+
+	path := api.MessagesPath
+	if path == "" {
+		path = defaultAnthropicMessagesPath
+	}
+	version := api.Version
+	if version == "" {
+		version = defaultAnthropicVersion
+	}
+
+	header := make(http.Header)
+	header.Set("anthropic-version", version)
+	if api.APIKey != "" {
+		header.Set("x-api-key", api.APIKey)
+	}
+	if request.Stream {
+		header.Set("Accept", "text/event-stream")
+	}
+	return postChatRequest(ctx, joinURL(api.BaseURL, path), header, request, model.Timeout, request.Stream, parseAnthropicError)
+}
+
+// buildAnthropicRequest 构建请求体，系统消息合并为 system，相邻同角色消息合并
+func buildAnthropicRequest(messages []Message, options *OpenAiChatOptions, stream bool) *anthropicMessagesRequest {
+	request := &anthropicMessagesRequest{
+		Model:       options.Model,
+		MaxTokens:   options.MaxTokens,
+		Temperature: options.Temperature,
+		Stream:      stream,
+	}
+	if request.MaxTokens <= 0 {
+		request.MaxTokens = defaultAnthropicMaxTokens
+	}
+
+	var system []string
+	for _, message := range messages {
+		role := MessageRoleUser
+		var blocks []anthropicContentBlock
+		switch message.Role {
+		case MessageRoleSystem:
+			system = append(system, message.Content)
+			continue
+		case MessageRoleAssistant:
+			role = MessageRoleAssistant
+			if message.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: message.Content})
+			}
+			for _, toolCall := range message.ToolCalls {
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    toolCall.ID,
+					Name:  toolCall.Name,
+					Input: toolArgumentsJSON(toolCall.Arguments),
+				})
+			}
+		case MessageRoleTool:
+			blocks = append(blocks, anthropicContentBlock{Type: "tool_result", ToolUseID: message.ToolCallID, Content: message.Content})
+		default:
+			blocks = append(blocks, anthropicContentBlock{Type: "text", Text: message.Content})
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		if last := len(request.Messages) - 1; last >= 0 && request.Messages[last].Role == role {
+			request.Messages[last].Content = append(request.Messages[last].Content, blocks...)
+			continue
+		}
+		request.Messages = append(request.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+	request.System = strings.Join(system, "\n\n")
+
+	for _, callback := range options.ToolCallbacks {
+		definition := callback.GetToolDefinition()
+		inputSchema := definition.InputSchema
+		if len(inputSchema) == 0 {
+			inputSchema = json.RawMessage(`{"type":"object"}`)
+		}
+		request.Tools = append(request.Tools, anthropicTool{
+			Name:        definition.Name,
+			Description: definition.Description,
+			InputSchema: inputSchema,
+		})
+	}
+
+	// 结构化输出：有其它工具时要求调用任一工具，工具调用循环结束后以结构化输出工具返回结果
+	if format := options.ResponseFormat; format != nil {
+		request.Tools = append(request.Tools, anthropicTool{
+			Name:        format.toolName(),
+			Description: "Return the final answer to the user as structured output. Call this tool exactly once, when the answer is complete.",
+			InputSchema: format.toolSchema(),
+		})
+		request.ToolChoice = &anthropicToolChoice{Type: "tool", Name: format.toolName()}
+		if len(options.ToolCallbacks) > 0 {
+			request.ToolChoice = &anthropicToolChoice{Type: "any"}
+		}
+	}
+	return request
+}
+
+// structuredOutputTool 结构化输出工具名称，未要求结构化输出时为空
+func structuredOutputTool(options *OpenAiChatOptions) string {
+	if options == nil || options.ResponseFormat == nil {
+		return ""
+	}
+	return options.ResponseFormat.toolName()
+}
+
+// toChatResponse 转换为通用响应，outputTool 不为空时该工具的参数作为文本内容返回
+func (message *anthropicMessagesResponse) toChatResponse(outputTool string) *ChatResponse {
+	generation := Generation{
+		FinishReason: anthropicFinishReason(message.StopReason),
+		Message:      Message{Role: MessageRoleAssistant},
+	}
+	structured := ""
+	for i, block := range message.Content {
+		switch block.Type {
+		case "text":
+			generation.Message.Content += block.Text
+		case "tool_use":
+			if outputTool != "" && block.Name == outputTool {
+				// 结构化输出只取工具参数，忽略模型附带的说明文本
+				structured = string(toolArgumentsJSON(string(block.Input)))
+				continue
+			}
+			generation.Message.ToolCalls = append(generation.Message.ToolCalls, ToolCall{
+				Index:     i,
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: string(toolArgumentsJSON(string(block.Input))),
+			})
+		}
+	}
+	if structured != "" {
+		generation.Message.Content = structured
+		if len(generation.Message.ToolCalls) == 0 && generation.FinishReason == "tool_calls" {
+			generation.FinishReason = "stop"
+		}
+	}
+	return &ChatResponse{
+		ID:          message.ID,
+		Model:       message.Model,
+		Generations: []Generation{generation},
+		Usage:       message.Usage.toUsage(),
+	}
+}
+
+// toUsage 转换为通用用量
+func (usage anthropicUsage) toUsage() Usage {
+	return Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.InputTokens + usage.OutputTokens,
+		CachedTokens:     usage.CacheReadInputTokens,
+	}
+}
+
+// anthropicFinishReason 将 stop_reason 转换为 OpenAI 风格的结束原因
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	default:
+		return stopReason
+	}
+}
+
+// parseAnthropicError 解析错误响应
+func parseAnthropicError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	apiErr := &ChatModelApiError{StatusCode: resp.StatusCode}
+
+	var errResp struct {
+		Error anthropicErrorDetail `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// toolArgumentsJSON 工具参数转为 JSON 对象，为空或非法时使用空对象
+func toolArgumentsJSON(arguments string) json.RawMessage {
+	arguments = strings.TrimSpace(arguments)
+	if arguments == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage(`{}`)
+	}
+	return json.RawMessage(arguments)
+}
@@ -0,0 +1,233 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// anthropicChatModel 创建指向模拟服务的模型
+func (p *fakeChatProvider) anthropicChatModel(toolCallbacks ...ToolCallback) *AnthropicChatModel {
+	return &AnthropicChatModel{
+		AnthropicApi:   &AnthropicApi{BaseURL: p.server.URL, APIKey: "test-key"},
+		DefaultOptions: &OpenAiChatOptions{Model: "claude-test", ToolCallbacks: toolCallbacks},
+	}
+}
+
+func TestAnthropicChatModelCallToolLoop(t *testing.T) {
+	provider := newFakeChatProvider(t,
+		jsonProviderReply(`{"id": "msg_1", "model": "claude-test", "stop_reason": "tool_use",
+			"content": [
+				{"type": "text", "text": "查询中"},
+				{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city":"杭州"}}
+			],
+			"usage": {"input_tokens": 10, "output_tokens": 5}}`),
+		jsonProviderReply(`{"id": "msg_2", "model": "claude-test", "stop_reason": "end_turn",
+			"content": [{"type": "text", "text": "杭州晴 25℃"}],
+			"usage": {"input_tokens": 30, "output_tokens": 6, "cache_read_input_tokens": 4}}`),
+	)
+	tool := &fakeWeatherTool{}
+
+	prompt := NewPrompt(NewSystemMessage("你是天气助手"), NewSystemMessage("回答要简短"), NewUserMessage("杭州天气"))
+	response, err := provider.anthropicChatModel(tool).Call(context.Background(), prompt)
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	if response.GetText() != "杭州晴 25℃" || response.GetResult().FinishReason != "stop" {
+		t.Errorf("最终回复 = %+v", response.GetResult())
+	}
+	if want := (Usage{PromptTokens: 40, CompletionTokens: 11, TotalTokens: 51, CachedTokens: 4}); response.Usage != want {
+		t.Errorf("用量 = %+v, 期望 %+v", response.Usage, want)
+	}
+	if len(tool.inputs) != 1 || tool.inputs[0] != `{"city":"杭州"}` {
+		t.Errorf("工具调用参数 = %v", tool.inputs)
+	}
+
+	requests := provider.recordedRequests()
+	if len(requests) != 2 {
+		t.Fatalf("请求次数 = %d, 期望 2", len(requests))
+	}
+	if requests[0].URL != defaultAnthropicMessagesPath {
+		t.Errorf("请求路径 = %s", requests[0].URL)
+	}
+	if requests[0].Header.Get("x-api-key") != "test-key" || requests[0].Header.Get("anthropic-version") != defaultAnthropicVersion {
+		t.Errorf("请求头 = %v", requests[0].Header)
+	}
+
+	var first anthropicMessagesRequest
+	requests[0].decode(t, &first)
+	if first.System != "你是天气助手\n\n回答要简短" || first.MaxTokens != defaultAnthropicMaxTokens {
+		t.Errorf("system = %q, max_tokens = %d", first.System, first.MaxTokens)
+	}
+	if len(first.Tools) != 1 || first.Tools[0].Name != "get_weather" || len(first.Tools[0].InputSchema) == 0 {
+		t.Errorf("工具定义 = %+v", first.Tools)
+	}
+
+	// 第二轮：助手消息带 tool_use 块，工具结果以 tool_result 块放在 user 消息中
+	var second anthropicMessagesRequest
+	requests[1].decode(t, &second)
+	if len(second.Messages) != 3 {
+		t.Fatalf("第二轮消息数量 = %d, 期望 3: %+v", len(second.Messages), second.Messages)
+	}
+	assistant := second.Messages[1]
+	if assistant.Role != MessageRoleAssistant || len(assistant.Content) != 2 ||
+		assistant.Content[1].Type != "tool_use" || assistant.Content[1].ID != "toolu_1" {
+		t.Errorf("助手消息 = %+v", assistant)
+	}
+	var input map[string]string
+	if err := json.Unmarshal(assistant.Content[1].Input, &input); err != nil || input["city"] != "杭州" {
+		t.Errorf("tool_use 参数 = %s", assistant.Content[1].Input)
+	}
+	toolResult := second.Messages[2]
+	if toolResult.Role != MessageRoleUser || len(toolResult.Content) != 1 ||
+		toolResult.Content[0].Type != "tool_result" || toolResult.Content[0].ToolUseID != "toolu_1" ||
+		toolResult.Content[0].Content != "晴 25℃" {
+		t.Errorf("工具结果消息 = %+v", toolResult)
+	}
+}
+
+func TestAnthropicChatModelStructuredOutputTool(t *testing.T) {
+	provider := newFakeChatProvider(t, jsonProviderReply(`{"stop_reason": "tool_use", "content": [
+		{"type": "text", "text": "结果如下"},
+		{"type": "tool_use", "id": "toolu_1", "name": "answer", "input": {"city":"杭州"}}
+	]}`))
+	model := provider.anthropicChatModel()
+	model.DefaultOptions.ResponseFormat = &ResponseFormat{Type: ResponseFormatTypeJSONObject, Name: "answer"}
+
+	response, err := model.Call(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	if response.GetText() != `{"city":"杭州"}` || response.GetResult().FinishReason != "stop" {
+		t.Errorf("结构化输出 = %q, 结束原因 %s", response.GetText(), response.GetResult().FinishReason)
+	}
+
+	var request anthropicMessagesRequest
+	provider.recordedRequests()[0].decode(t, &request)
+	if request.ToolChoice == nil || request.ToolChoice.Type != "tool" || request.ToolChoice.Name != "answer" {
+		t.Errorf("tool_choice = %+v", request.ToolChoice)
+	}
+}
+
+func TestAnthropicChatModelStream(t *testing.T) {
+	provider := newFakeChatProvider(t, sseProviderReply(namedSseBody(
+		[2]string{"message_start", `{"type": "message_start", "message": {"id": "msg_1", "model": "claude-test", "usage": {"input_tokens": 8, "output_tokens": 0}}}`},
+		[2]string{"content_block_start", `{"type": "content_block_start", "index": 0, "content_block": {"type": "text", "text": ""}}`},
+		[2]string{"ping", `{"type": "ping"}`},
+		[2]string{"content_block_delta", `{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "你"}}`},
+		[2]string{"content_block_delta", `{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "好"}}`},
+		[2]string{"content_block_stop", `{"type": "content_block_stop", "index": 0}`},
+		[2]string{"message_delta", `{"type": "message_delta", "delta": {"stop_reason": "end_turn"}, "usage": {"output_tokens": 2}}`},
+		[2]string{"message_stop", `{"type": "message_stop"}`},
+	)))
+
+	chunks, err := provider.anthropicChatModel().Stream(context.Background(), NewPrompt(NewUserMessage("hi")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, usage, err := collectStream(t, chunks)
+	if err != nil {
+		t.Fatalf("读取流失败: %v", err)
+	}
+	if content != "你好" {
+		t.Errorf("内容 = %q", content)
+	}
+	if want := (Usage{PromptTokens: 8, CompletionTokens: 2, TotalTokens: 10}); usage != want {
+		t.Errorf("用量 = %+v, 期望 %+v", usage, want)
+	}
+
+	var request anthropicMessagesRequest
+	provider.recordedRequests()[0].decode(t, &request)
+	if !request.Stream {
+		t.Error("流式请求应设置 stream")
+	}
+}
+
+func TestAnthropicChatModelStreamToolUse(t *testing.T) {
+	provider := newFakeChatProvider(t,
+		sseProviderReply(namedSseBody(
+			[2]string{"message_start", `{"type": "message_start", "message": {"id": "msg_1", "usage": {"input_tokens": 8}}}`},
+			[2]string{"content_block_start", `{"type": "content_block_start", "index": 1, "content_block": {"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {}}}`},
+			[2]string{"content_block_delta", `{"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": "{\"city\":"}}`},
+			[2]string{"content_block_delta", `{"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": "\"杭州\"}"}}`},
+			[2]string{"message_delta", `{"type": "message_delta", "delta": {"stop_reason": "tool_use"}}`},
+			[2]string{"message_stop", `{"type": "message_stop"}`},
+		)),
+		sseProviderReply(namedSseBody(
+			[2]string{"content_block_delta", `{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "晴"}}`},
+			[2]string{"message_stop", `{"type": "message_stop"}`},
+		)),
+	)
+	tool := &fakeWeatherTool{}
+
+	chunks, err := provider.anthropicChatModel(tool).Stream(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, _, err := collectStream(t, chunks)
+	if err != nil {
+		t.Fatalf("读取流失败: %v", err)
+	}
+	if content != "晴" {
+		t.Errorf("内容 = %q", content)
+	}
+	if len(tool.inputs) != 1 || tool.inputs[0] != `{"city":"杭州"}` {
+		t.Errorf("聚合后的工具参数 = %v", tool.inputs)
+	}
+
+	var second anthropicMessagesRequest
+	provider.recordedRequests()[1].decode(t, &second)
+	if len(second.Messages) != 3 || second.Messages[1].Content[0].ID != "toolu_1" || second.Messages[2].Content[0].ToolUseID != "toolu_1" {
+		t.Errorf("第二轮消息 = %+v", second.Messages)
+	}
+}
+
+func TestAnthropicChatModelErrorMapping(t *testing.T) {
+	provider := newFakeChatProvider(t,
+		errorProviderReply(http.StatusTooManyRequests, `{"type": "error", "error": {"type": "rate_limit_error", "message": "slow down"}}`),
+		errorProviderReply(http.StatusBadGateway, `upstream unavailable`),
+		sseProviderReply(namedSseBody(
+			[2]string{"error", `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`},
+		)),
+	)
+	model := provider.anthropicChatModel()
+
+	tests := []struct {
+		name     string
+		status   int
+		errType  string
+		message  string
+		stream   bool
+		retrying bool
+	}{
+		{name: "限流", status: http.StatusTooManyRequests, errType: "rate_limit_error", message: "slow down", retrying: true},
+		{name: "非 JSON 错误体", status: http.StatusBadGateway, message: "upstream unavailable", retrying: true},
+		{name: "流内错误事件", status: http.StatusOK, errType: "overloaded_error", message: "Overloaded", stream: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.stream {
+				var chunks <-chan ChatStreamChunk
+				if chunks, err = model.Stream(context.Background(), NewPrompt(NewUserMessage("hi"))); err == nil {
+					_, _, err = collectStream(t, chunks)
+				}
+			} else {
+				_, err = model.Call(context.Background(), NewPrompt(NewUserMessage("hi")))
+			}
+
+			var apiErr *ChatModelApiError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("期望 ChatModelApiError, 实际 %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Type != tt.errType || apiErr.Message != tt.message {
+				t.Errorf("错误 = %+v", apiErr)
+			}
+			if isRetryableModelError(err) != tt.retrying {
+				t.Errorf("isRetryableModelError = %v, 期望 %v", !tt.retrying, tt.retrying)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package node
+
+import (
+	"sync"
+
+	"smart-weaver/internal/domain/agent/service/armory/factory/context"
+)
+
+// armoryReportKey 构建结果在动态上下文中的键
+const armoryReportKey = "armoryReport"
+
+// ArmoryReport 一次构建的结果，各节点记录成功注册和失败的Bean
+type ArmoryReport struct {
+	Built  []string        `json:"built"`
+	Failed []ArmoryFailure `json:"failed"`
+	mu     sync.Mutex
+}
+
+// ArmoryFailure 构建失败的Bean及原因
+type ArmoryFailure struct {
+	Bean   string `json:"bean"`
+	Reason string `json:"reason"`
+}
+
+// GetArmoryReport 获取动态上下文中的构建结果，不存在时创建
+func GetArmoryReport(dynamicContext *context.DynamicContext) *ArmoryReport {
+	if report, ok := dynamicContext.GetValue(armoryReportKey).(*ArmoryReport); ok {
+		return report
+	}
+	report := &ArmoryReport{Built: []string{}, Failed: []ArmoryFailure{}}
+	dynamicContext.SetValue(armoryReportKey, report)
+	return report
+}
+
+// AddBuilt 记录构建成功的Bean
+func (r *ArmoryReport) AddBuilt(bean string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Built = append(r.Built, bean)
+}
+
+// AddFailed 记录构建失败的Bean
+func (r *ArmoryReport) AddFailed(bean string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Failed = append(r.Failed, ArmoryFailure{Bean: bean, Reason: err.Error()})
+}
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultAzureApiVersion 未配置时使用的 Azure OpenAI api-version
+const defaultAzureApiVersion = "2024-10-21"
+
+// AzureOpenAiApi Azure OpenAI API配置（模拟Java中的AzureOpenAiApi），按部署名称拼接接口地址
+type AzureOpenAiApi struct {
+	Endpoint       string // 资源地址，如 https://{resource}.openai.azure.com
+	APIKey         string // 通过 api-key 请求头传递
+	DeploymentName string
+	ApiVersion     string // 为空时使用 defaultAzureApiVersion
+}
+
+// AzureOpenAiChatModel Azure OpenAI 聊天模型，请求和响应格式与 OpenAI 一致
+type AzureOpenAiChatModel struct {
+	AzureOpenAiApi *AzureOpenAiApi
+	DefaultOptions *OpenAiChatOptions
+	Timeout        time.Duration // 单次请求超时，0 表示不限制
+}
+
+// Call 同步调用对话接口，配置了工具回调时自动执行工具调用循环
+func (model *AzureOpenAiChatModel) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	return callWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.client().callOnce)
+}
+
+// Stream 流式调用对话接口，配置了工具回调时在流内完成工具调用循环
+func (model *AzureOpenAiChatModel) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	return streamWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.client().streamOnce)
+}
+
+// GetDefaultOptions 默认选项
+func (model *AzureOpenAiChatModel) GetDefaultOptions() *OpenAiChatOptions {
+	return model.DefaultOptions
+}
+
+// client 接口客户端
+func (model *AzureOpenAiChatModel) client() *openAiCompletionsClient {
+	client := &openAiCompletionsClient{timeout: model.Timeout}
+	if model.AzureOpenAiApi != nil {
+		client.api = model.AzureOpenAiApi
+	}
+	return client
+}
+
+// completionsURL 拼接部署的对话接口地址 {endpoint}/openai/deployments/{deployment}/chat/completions?api-version=
+func (api *AzureOpenAiApi) completionsURL() string {
+	apiVersion := api.ApiVersion
+	if apiVersion == "" {
+		apiVersion = defaultAzureApiVersion
+	}
+	path := "/openai/deployments/" + url.PathEscape(api.DeploymentName) + "/chat/completions"
+	return joinURL(strings.TrimSuffix(strings.TrimRight(api.Endpoint, "/"), "/openai"), path) + "?api-version=" + url.QueryEscape(apiVersion)
+}
+
+// authorize 设置 api-key 鉴权
+func (api *AzureOpenAiApi) authorize(header http.Header) {
+	if api.APIKey != "" {
+		header.Set("api-key", api.APIKey)
+	}
+}
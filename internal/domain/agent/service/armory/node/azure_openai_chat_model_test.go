@@ -0,0 +1,95 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// azureChatModel 创建指向模拟服务的模型，endpoint 带 /openai/ 后缀以验证地址拼接
+func (p *fakeChatProvider) azureChatModel(toolCallbacks ...ToolCallback) *AzureOpenAiChatModel {
+	return &AzureOpenAiChatModel{
+		AzureOpenAiApi: &AzureOpenAiApi{Endpoint: p.server.URL + "/openai/", APIKey: "test-key", DeploymentName: "gpt 4o"},
+		DefaultOptions: &OpenAiChatOptions{ToolCallbacks: toolCallbacks},
+	}
+}
+
+func TestAzureOpenAiChatModelCallToolLoop(t *testing.T) {
+	provider := newFakeChatProvider(t,
+		jsonProviderReply(`{"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [
+			{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"杭州\"}"}}
+		]}, "finish_reason": "tool_calls"}], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`),
+		jsonProviderReply(`{"choices": [{"message": {"role": "assistant", "content": "杭州晴"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 30, "completion_tokens": 6, "total_tokens": 36}}`),
+	)
+	tool := &fakeWeatherTool{}
+
+	response, err := provider.azureChatModel(tool).Call(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	if response.GetText() != "杭州晴" {
+		t.Errorf("最终回复 = %s", response.GetText())
+	}
+	if want := (Usage{PromptTokens: 40, CompletionTokens: 11, TotalTokens: 51}); response.Usage != want {
+		t.Errorf("用量 = %+v, 期望 %+v", response.Usage, want)
+	}
+	if len(tool.inputs) != 1 || tool.inputs[0] != `{"city":"杭州"}` {
+		t.Errorf("工具调用参数 = %v", tool.inputs)
+	}
+
+	requests := provider.recordedRequests()
+	if len(requests) != 2 {
+		t.Fatalf("请求次数 = %d, 期望 2", len(requests))
+	}
+	if want := "/openai/deployments/gpt%204o/chat/completions?api-version=" + defaultAzureApiVersion; requests[0].URL != want {
+		t.Errorf("请求地址 = %s, 期望 %s", requests[0].URL, want)
+	}
+	if requests[0].Header.Get("api-key") != "test-key" || requests[0].Header.Get("Authorization") != "" {
+		t.Errorf("请求头 = %v, 期望只使用 api-key 鉴权", requests[0].Header)
+	}
+
+	var second openAiChatCompletionRequest
+	requests[1].decode(t, &second)
+	if len(second.Messages) != 3 || len(second.Messages[1].ToolCalls) != 1 || second.Messages[2].ToolCallID != "call_1" {
+		t.Errorf("第二轮消息 = %+v", second.Messages)
+	}
+}
+
+func TestAzureOpenAiChatModelStream(t *testing.T) {
+	provider := newFakeChatProvider(t, sseProviderReply(sseBody(
+		`{"choices": [{"index": 0, "delta": {"role": "assistant", "content": "你"}}]}`,
+		`{"choices": [{"index": 0, "delta": {"content": "好"}, "finish_reason": "stop"}]}`,
+		`{"choices": [], "usage": {"prompt_tokens": 8, "completion_tokens": 2, "total_tokens": 10}}`,
+	)))
+
+	chunks, err := provider.azureChatModel().Stream(context.Background(), NewPrompt(NewUserMessage("hi")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, usage, err := collectStream(t, chunks)
+	if err != nil {
+		t.Fatalf("读取流失败: %v", err)
+	}
+	if content != "你好" || usage.TotalTokens != 10 {
+		t.Errorf("内容 = %q, 用量 = %+v", content, usage)
+	}
+}
+
+func TestAzureOpenAiChatModelErrorMapping(t *testing.T) {
+	provider := newFakeChatProvider(t, errorProviderReply(http.StatusTooManyRequests,
+		`{"error": {"code": "429", "message": "Requests to the deployment have exceeded the rate limit"}}`))
+
+	_, err := provider.azureChatModel().Call(context.Background(), NewPrompt(NewUserMessage("hi")))
+	var apiErr *ChatModelApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("期望 ChatModelApiError, 实际 %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != "429" || apiErr.Message == "" {
+		t.Errorf("错误 = %+v", apiErr)
+	}
+	if !isRetryableModelError(err) {
+		t.Error("429 应可切换模型重试")
+	}
+}
@@ -0,0 +1,203 @@
+package node
+
+import (
+	stdcontext "context"
+	"errors"
+	"strings"
+)
+
+// ChatClientRequest 对话客户端请求
+type ChatClientRequest struct {
+	UserText      string             // 本轮用户消息
+	Messages      []Message          // 调用方附加的历史消息，可为空
+	Options       *OpenAiChatOptions // 为空时使用客户端默认选项
+	AdviseContext map[string]any     // 传递给顾问的上下文，如会话ID
+	SystemParams  map[string]any     // 替换系统提示词中构建时未替换的 {{变量}}，可为空
+}
+
+// ChatClient 对话客户端（模拟Java中的ChatClient），组合模型、系统提示词、顾问和工具
+type ChatClient struct {
+	ChatModel     ChatModel
+	DefaultSystem string         // 默认系统提示词，可为空
+	ToolCallbacks []ToolCallback // 客户端级工具，与模型默认工具合并
+	Advisors      AdvisorChain
+}
+
+// ChatClientBuilder 对话客户端构建器
+type ChatClientBuilder struct {
+	chatModel     ChatModel
+	defaultSystem string
+	toolCallbacks []ToolCallback
+	advisors      []Advisor
+}
+
+// NewChatClientBuilder 创建对话客户端构建器
+func NewChatClientBuilder(chatModel ChatModel) *ChatClientBuilder {
+	return &ChatClientBuilder{chatModel: chatModel}
+}
+
+// DefaultSystem 设置默认系统提示词
+func (b *ChatClientBuilder) DefaultSystem(defaultSystem string) *ChatClientBuilder {
+	b.defaultSystem = defaultSystem
+	return b
+}
+
+// DefaultToolCallbacks 设置客户端级工具
+func (b *ChatClientBuilder) DefaultToolCallbacks(toolCallbacks []ToolCallback) *ChatClientBuilder {
+	b.toolCallbacks = toolCallbacks
+	return b
+}
+
+// DefaultAdvisors 设置顾问，按 Order 排序
+func (b *ChatClientBuilder) DefaultAdvisors(advisors ...Advisor) *ChatClientBuilder {
+	b.advisors = advisors
+	return b
+}
+
+// Build 构建ChatClient
+func (b *ChatClientBuilder) Build() *ChatClient {
+	return &ChatClient{
+		ChatModel:     b.chatModel,
+		DefaultSystem: b.defaultSystem,
+		ToolCallbacks: b.toolCallbacks,
+		Advisors:      NewAdvisorChain(b.advisors...),
+	}
+}
+
+// Call 同步对话，依次经过顾问请求阶段、模型调用和顾问响应阶段
+func (c *ChatClient) Call(ctx stdcontext.Context, request *ChatClientRequest) (*ChatResponse, error) {
+	advisedRequest, err := c.advise(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.ChatModel.Call(ctx, advisedRequest.ToPrompt())
+	if err != nil {
+		c.Advisors.AdviseError(ctx, advisedRequest.AdviseContext, err)
+		return nil, err
+	}
+
+	advisedResponse, err := c.Advisors.AdviseResponse(ctx, &AdvisedResponse{
+		Response:      response,
+		AdviseContext: advisedRequest.AdviseContext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return advisedResponse.Response, nil
+}
+
+// Stream 流式对话，片段原样转发，流正常结束后以聚合的完整响应执行顾问响应阶段
+// 顾问响应阶段失败时通过最后一个片段的 Err 返回
+func (c *ChatClient) Stream(ctx stdcontext.Context, request *ChatClientRequest) (<-chan ChatStreamChunk, error) {
+	advisedRequest, err := c.advise(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	upstream, err := c.ChatModel.Stream(ctx, advisedRequest.ToPrompt())
+	if err != nil {
+		c.Advisors.AdviseError(ctx, advisedRequest.AdviseContext, err)
+		return nil, err
+	}
+
+	chunks := make(chan ChatStreamChunk)
+	go func() {
+		defer close(chunks)
+
+		send := func(chunk ChatStreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		aggregated := &ChatResponse{}
+		var content strings.Builder
+		finishReason := ""
+		for chunk := range upstream {
+			if chunk.Err != nil {
+				c.Advisors.AdviseError(ctx, advisedRequest.AdviseContext, chunk.Err)
+				send(chunk)
+				return
+			}
+
+			if chunk.Response.ID != "" {
+				aggregated.ID = chunk.Response.ID
+			}
+			if chunk.Response.Model != "" {
+				aggregated.Model = chunk.Response.Model
+			}
+			aggregated.Usage.Add(chunk.Response.Usage)
+			if result := chunk.Response.GetResult(); result != nil {
+				content.WriteString(result.Message.Content)
+				if result.FinishReason != "" {
+					finishReason = result.FinishReason
+				}
+			}
+
+			if !send(chunk) {
+				c.Advisors.AdviseError(ctx, advisedRequest.AdviseContext, ctx.Err())
+				return
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			c.Advisors.AdviseError(ctx, advisedRequest.AdviseContext, err)
+			return
+		}
+
+		aggregated.Generations = []Generation{{
+			Message:      NewAssistantMessage(content.String()),
+			FinishReason: finishReason,
+		}}
+		if _, err := c.Advisors.AdviseResponse(ctx, &AdvisedResponse{
+			Response:      aggregated,
+			AdviseContext: advisedRequest.AdviseContext,
+		}); err != nil {
+			send(ChatStreamChunk{Err: err})
+		}
+	}()
+
+	return chunks, nil
+}
+
+// advise 组装请求并执行顾问请求阶段
+func (c *ChatClient) advise(ctx stdcontext.Context, request *ChatClientRequest) (*AdvisedRequest, error) {
+	if c.ChatModel == nil {
+		return nil, errors.New("ChatClient 未配置对话模型")
+	}
+
+	// 复制上下文，避免顾问写入调用方的 map
+	adviseContext := make(map[string]any, len(request.AdviseContext))
+	for key, value := range request.AdviseContext {
+		adviseContext[key] = value
+	}
+
+	return c.Advisors.AdviseRequest(ctx, &AdvisedRequest{
+		SystemText:    RenderPromptTemplate(c.DefaultSystem, PromptVariablesLookup(request.SystemParams)),
+		Messages:      append([]Message(nil), request.Messages...),
+		UserText:      request.UserText,
+		Options:       c.options(request.Options),
+		AdviseContext: adviseContext,
+		ChatModel:     c.ChatModel,
+	})
+}
+
+// options 合并客户端级工具，请求显式指定工具时以请求为准
+func (c *ChatClient) options(options *OpenAiChatOptions) *OpenAiChatOptions {
+	if len(c.ToolCallbacks) == 0 || (options != nil && options.ToolCallbacks != nil) {
+		return options
+	}
+
+	merged := &OpenAiChatOptions{}
+	if options != nil {
+		*merged = *options
+	}
+	if defaultOptions := c.ChatModel.GetDefaultOptions(); defaultOptions != nil {
+		merged.ToolCallbacks = append(merged.ToolCallbacks, defaultOptions.ToolCallbacks...)
+	}
+	merged.ToolCallbacks = append(merged.ToolCallbacks, c.ToolCallbacks...)
+	return merged
+}
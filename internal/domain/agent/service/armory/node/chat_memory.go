@@ -0,0 +1,117 @@
+package node
+
+import (
+	stdcontext "context"
+	"sync"
+)
+
+// 对话记忆存储类型
+const (
+	ChatMemoryStorageInMemory = "in_memory"
+	ChatMemoryStorageMysql    = "mysql"
+	ChatMemoryStorageRedis    = "redis"
+)
+
+// ChatMemory 对话记忆存储（模拟Java中的ChatMemory），按会话ID保存消息
+type ChatMemory interface {
+	// Get 按时间顺序获取会话的全部消息
+	Get(ctx stdcontext.Context, conversationID string) ([]Message, error)
+	// Add 追加消息
+	Add(ctx stdcontext.Context, conversationID string, messages ...Message) error
+	// Replace 用给定消息整体替换会话消息，用于窗口淘汰和摘要
+	Replace(ctx stdcontext.Context, conversationID string, messages []Message) error
+	// Clear 清空会话
+	Clear(ctx stdcontext.Context, conversationID string) error
+	// LockConversation 获取进程内的会话锁，串行化同一会话的读改写，返回的函数释放锁
+	// 锁由存储持有，客户端重新构建后新旧顾问仍使用同一把锁
+	LockConversation(conversationID string) func()
+}
+
+// ConversationLocks 按会话ID引用计数的锁表，零值可用，嵌入 ChatMemory 实现即可提供 LockConversation
+// 会话锁在没有持有者和等待者时从表中删除，锁表大小不超过进行中的对话数
+type ConversationLocks struct {
+	locks map[string]*conversationLock
+	mu    sync.Mutex
+}
+
+// conversationLock 会话锁，refs 为持有和等待的数量
+type conversationLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// LockConversation 获取会话锁
+func (l *ConversationLocks) LockConversation(conversationID string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*conversationLock)
+	}
+	lock, ok := l.locks[conversationID]
+	if !ok {
+		lock = &conversationLock{}
+		l.locks[conversationID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, conversationID)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// size 锁表中的会话数
+func (l *ConversationLocks) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
+
+// InMemoryChatMemory 进程内对话记忆，重启后丢失
+type InMemoryChatMemory struct {
+	ConversationLocks
+	conversations map[string][]Message
+	mu            sync.RWMutex
+}
+
+// NewInMemoryChatMemory 创建进程内对话记忆
+func NewInMemoryChatMemory() *InMemoryChatMemory {
+	return &InMemoryChatMemory{conversations: make(map[string][]Message)}
+}
+
+// Get 获取会话消息
+func (m *InMemoryChatMemory) Get(ctx stdcontext.Context, conversationID string) ([]Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Message(nil), m.conversations[conversationID]...), nil
+}
+
+// Add 追加消息
+func (m *InMemoryChatMemory) Add(ctx stdcontext.Context, conversationID string, messages ...Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conversations[conversationID] = append(m.conversations[conversationID], messages...)
+	return nil
+}
+
+// Replace 替换会话消息
+func (m *InMemoryChatMemory) Replace(ctx stdcontext.Context, conversationID string, messages []Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conversations[conversationID] = append([]Message(nil), messages...)
+	return nil
+}
+
+// Clear 清空会话
+func (m *InMemoryChatMemory) Clear(ctx stdcontext.Context, conversationID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.conversations, conversationID)
+	return nil
+}
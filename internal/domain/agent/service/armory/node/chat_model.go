@@ -0,0 +1,149 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// 消息角色
+const (
+	MessageRoleSystem    = "system"
+	MessageRoleUser      = "user"
+	MessageRoleAssistant = "assistant"
+	MessageRoleTool      = "tool"
+)
+
+// Message 对话消息（模拟Java中的Message）
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // assistant 消息发起的工具调用
+	ToolCallID string     `json:"tool_call_id,omitempty"` // tool 消息对应的工具调用ID
+	Name       string     `json:"name,omitempty"`         // tool 消息对应的工具名称
+}
+
+// ToolCall 模型发起的工具调用
+type ToolCall struct {
+	Index     int    `json:"index"` // 流式响应中用于聚合同一工具调用的分片
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// NewSystemMessage 创建系统消息
+func NewSystemMessage(content string) Message {
+	return Message{Role: MessageRoleSystem, Content: content}
+}
+
+// NewUserMessage 创建用户消息
+func NewUserMessage(content string) Message {
+	return Message{Role: MessageRoleUser, Content: content}
+}
+
+// NewAssistantMessage 创建助手消息
+func NewAssistantMessage(content string) Message {
+	return Message{Role: MessageRoleAssistant, Content: content}
+}
+
+// NewToolResponseMessage 创建工具结果消息
+func NewToolResponseMessage(toolCallID, name, content string) Message {
+	return Message{Role: MessageRoleTool, ToolCallID: toolCallID, Name: name, Content: content}
+}
+
+// ChatModel 对话模型（模拟Java中的ChatModel），各供应商实现统一接口，工具调用循环在模型内完成
+type ChatModel interface {
+	// Call 同步调用
+	Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error)
+	// Stream 流式调用，返回的 channel 在流结束或 ctx 取消后关闭
+	Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error)
+	// GetDefaultOptions 默认选项，可为空
+	GetDefaultOptions() *OpenAiChatOptions
+}
+
+// Prompt 提示词（模拟Java中的Prompt），Options 为空时使用模型默认选项
+type Prompt struct {
+	Messages []Message
+	Options  *OpenAiChatOptions
+}
+
+// NewPrompt 创建提示词
+func NewPrompt(messages ...Message) *Prompt {
+	return &Prompt{Messages: messages}
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	CachedTokens     int `json:"cached_tokens"` // 命中缓存的输入 token
+}
+
+// Add 累加用量
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.CachedTokens += other.CachedTokens
+}
+
+// Generation 单个候选结果
+type Generation struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// ChatResponse 对话响应（模拟Java中的ChatResponse）
+type ChatResponse struct {
+	ID          string       `json:"id"`
+	Model       string       `json:"model"`
+	Generations []Generation `json:"generations"`
+	Usage       Usage        `json:"usage"`
+}
+
+// GetResult 获取第一个候选结果，没有结果时返回 nil
+func (r *ChatResponse) GetResult() *Generation {
+	if r == nil || len(r.Generations) == 0 {
+		return nil
+	}
+	return &r.Generations[0]
+}
+
+// GetText 获取第一个候选结果的文本
+func (r *ChatResponse) GetText() string {
+	if result := r.GetResult(); result != nil {
+		return result.Message.Content
+	}
+	return ""
+}
+
+// ChatStreamChunk 流式响应片段，Err 不为空时表示流异常结束
+type ChatStreamChunk struct {
+	Response *ChatResponse
+	Err      error
+}
+
+// ChatModelApiError 模型服务返回的错误
+type ChatModelApiError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+}
+
+func (e *ChatModelApiError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("模型接口调用失败 status=%d", e.StatusCode))
+	if e.Type != "" {
+		sb.WriteString(" type=" + e.Type)
+	}
+	if e.Code != "" {
+		sb.WriteString(" code=" + e.Code)
+	}
+	if e.Message != "" {
+		sb.WriteString(" message=" + e.Message)
+	}
+	return sb.String()
+}
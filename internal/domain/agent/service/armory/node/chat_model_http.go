@@ -0,0 +1,62 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// errChatModelTimeout 流式请求等待响应头超时
+var errChatModelTimeout = errors.New("等待模型响应超时")
+
+// postChatRequest 发送模型请求，非 2xx 响应由 parseError 转换为错误
+// 同步请求 timeout 约束整个请求；流式请求 timeout 仅约束等待响应头的时间
+// 成功时调用方读完响应体后需关闭响应体并调用返回的 cancel
+func postChatRequest(ctx context.Context, url string, header http.Header, payload any, timeout time.Duration, stream bool, parseError func(resp *http.Response) error) (*http.Response, context.CancelFunc, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("序列化模型请求失败: %w", err)
+	}
+
+	var cancel context.CancelFunc
+	var timer *time.Timer
+	if timeout > 0 && !stream {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+		if timeout > 0 {
+			timer = time.AfterFunc(timeout, cancel)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if timer != nil && !timer.Stop() && err == nil {
+		resp.Body.Close()
+		cancel()
+		return nil, nil, fmt.Errorf("%w(%s)", errChatModelTimeout, timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("请求模型接口失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		cancel()
+		return nil, nil, parseError(resp)
+	}
+	return resp, cancel, nil
+}
@@ -0,0 +1,107 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeProviderReply 模拟模型服务的一次响应
+type fakeProviderReply struct {
+	status      int
+	contentType string
+	body        string
+}
+
+// jsonProviderReply 200 JSON 响应
+func jsonProviderReply(body string) fakeProviderReply {
+	return fakeProviderReply{status: http.StatusOK, contentType: "application/json", body: body}
+}
+
+// sseProviderReply 200 SSE 响应
+func sseProviderReply(body string) fakeProviderReply {
+	return fakeProviderReply{status: http.StatusOK, contentType: "text/event-stream", body: body}
+}
+
+// errorProviderReply 错误响应
+func errorProviderReply(status int, body string) fakeProviderReply {
+	return fakeProviderReply{status: status, contentType: "application/json", body: body}
+}
+
+// recordedProviderRequest 模拟服务收到的请求
+type recordedProviderRequest struct {
+	URL    string // 路径及查询参数
+	Header http.Header
+	Body   []byte
+}
+
+// decode 按供应商的请求体结构解析
+func (r recordedProviderRequest) decode(t *testing.T, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("解析请求体失败: %v, body=%s", err, r.Body)
+	}
+}
+
+// fakeChatProvider 按请求顺序返回预设响应的模型服务，不区分供应商，记录原始请求供断言
+type fakeChatProvider struct {
+	t       *testing.T
+	server  *httptest.Server
+	replies []fakeProviderReply
+
+	mu       sync.Mutex
+	requests []recordedProviderRequest
+}
+
+// newFakeChatProvider 启动模拟服务，测试结束时关闭
+func newFakeChatProvider(t *testing.T, replies ...fakeProviderReply) *fakeChatProvider {
+	t.Helper()
+	provider := &fakeChatProvider{t: t, replies: replies}
+	provider.server = httptest.NewServer(http.HandlerFunc(provider.handle))
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func (p *fakeChatProvider) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	p.mu.Lock()
+	p.requests = append(p.requests, recordedProviderRequest{URL: r.URL.RequestURI(), Header: r.Header.Clone(), Body: body})
+	index := len(p.requests) - 1
+	p.mu.Unlock()
+
+	if index >= len(p.replies) {
+		p.t.Errorf("第 %d 次请求没有预设响应", index+1)
+		http.Error(w, "no response", http.StatusInternalServerError)
+		return
+	}
+	reply := p.replies[index]
+	w.Header().Set("Content-Type", reply.contentType)
+	w.WriteHeader(reply.status)
+	fmt.Fprint(w, reply.body)
+}
+
+// recordedRequests 已收到的请求
+func (p *fakeChatProvider) recordedRequests() []recordedProviderRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]recordedProviderRequest(nil), p.requests...)
+}
+
+// namedSseBody 将 event 名称与 JSON 数据拼成流式响应体
+func namedSseBody(events ...[2]string) string {
+	var body strings.Builder
+	for _, event := range events {
+		body.WriteString("event: " + event[0] + "\ndata: " + event[1] + "\n\n")
+	}
+	return body.String()
+}
+
+// ndjsonBody 逐行 JSON 响应体
+func ndjsonBody(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,99 @@
+package node
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"smart-weaver/internal/domain/agent/model/valobj"
+)
+
+// ChatModelProvider 按模型配置创建对话模型，defaultOptions 已包含模型名称和 MCP 工具
+type ChatModelProvider func(modelVO valobj.AiClientModelVO, defaultOptions *OpenAiChatOptions) (ChatModel, error)
+
+// defaultChatModelProviders 内置的模型供应商，键为小写的模型类型
+func defaultChatModelProviders() map[string]ChatModelProvider {
+	return map[string]ChatModelProvider{
+		valobj.ModelTypeOpenAI:    newOpenAiChatModel,
+		valobj.ModelTypeAzure:     newAzureOpenAiChatModel,
+		valobj.ModelTypeAnthropic: newAnthropicChatModel,
+		valobj.ModelTypeOllama:    newOllamaChatModel,
+	}
+}
+
+// newOpenAiChatModel OpenAI 及兼容接口
+func newOpenAiChatModel(modelVO valobj.AiClientModelVO, defaultOptions *OpenAiChatOptions) (ChatModel, error) {
+	openAiApi := NewOpenAiApiBuilder().
+		BaseURL(modelVO.BaseURL).
+		APIKey(modelVO.APIKey).
+		CompletionsPath(modelVO.CompletionsPath).
+		EmbeddingsPath(modelVO.EmbeddingsPath).
+		Build()
+
+	return NewOpenAiChatModelBuilder().
+		OpenAiApi(openAiApi).
+		DefaultOptions(defaultOptions).
+		Timeout(modelTimeout(modelVO)).
+		Build(), nil
+}
+
+// newAzureOpenAiChatModel Azure OpenAI，BaseURL 为资源地址，可带 ?api-version=，ModelVersion 为部署名称
+func newAzureOpenAiChatModel(modelVO valobj.AiClientModelVO, defaultOptions *OpenAiChatOptions) (ChatModel, error) {
+	endpoint, err := url.Parse(modelVO.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	apiVersion := endpoint.Query().Get("api-version")
+	endpoint.RawQuery = ""
+
+	return &AzureOpenAiChatModel{
+		AzureOpenAiApi: &AzureOpenAiApi{
+			Endpoint:       endpoint.String(),
+			APIKey:         modelVO.APIKey,
+			DeploymentName: modelVO.ModelVersion,
+			ApiVersion:     apiVersion,
+		},
+		DefaultOptions: defaultOptions,
+		Timeout:        modelTimeout(modelVO),
+	}, nil
+}
+
+// newAnthropicChatModel Anthropic Messages API，CompletionsPath 不为空时作为接口路径
+func newAnthropicChatModel(modelVO valobj.AiClientModelVO, defaultOptions *OpenAiChatOptions) (ChatModel, error) {
+	return &AnthropicChatModel{
+		AnthropicApi: &AnthropicApi{
+			BaseURL:      modelVO.BaseURL,
+			APIKey:       modelVO.APIKey,
+			MessagesPath: modelVO.CompletionsPath,
+		},
+		DefaultOptions: defaultOptions,
+		Timeout:        modelTimeout(modelVO),
+	}, nil
+}
+
+// newOllamaChatModel Ollama 原生接口，CompletionsPath 不为空时作为接口路径
+func newOllamaChatModel(modelVO valobj.AiClientModelVO, defaultOptions *OpenAiChatOptions) (ChatModel, error) {
+	return &OllamaChatModel{
+		OllamaApi: &OllamaApi{
+			BaseURL:  modelVO.BaseURL,
+			APIKey:   modelVO.APIKey,
+			ChatPath: modelVO.CompletionsPath,
+		},
+		DefaultOptions: defaultOptions,
+		Timeout:        modelTimeout(modelVO),
+	}, nil
+}
+
+// normalizeModelType 模型类型转为小写，为空时按 openai 处理
+func normalizeModelType(modelType string) string {
+	modelType = strings.ToLower(strings.TrimSpace(modelType))
+	if modelType == "" {
+		return valobj.ModelTypeOpenAI
+	}
+	return modelType
+}
+
+// modelTimeout 单次请求超时
+func modelTimeout(modelVO valobj.AiClientModelVO) time.Duration {
+	return time.Duration(modelVO.Timeout) * time.Second
+}
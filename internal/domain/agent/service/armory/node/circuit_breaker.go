@@ -0,0 +1,139 @@
+package node
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBreakerFailureThreshold 连续失败多少次后熔断
+	defaultBreakerFailureThreshold = 3
+	// defaultBreakerOpenDuration 熔断持续时间，到期后放行一个探测请求
+	defaultBreakerOpenDuration = 30 * time.Second
+)
+
+// 熔断器状态
+const (
+	BreakerStateClosed   = "closed"    // 正常
+	BreakerStateOpen     = "open"      // 熔断中，拒绝请求
+	BreakerStateHalfOpen = "half_open" // 熔断到期，放行一个探测请求
+)
+
+// CircuitBreaker 模型熔断器，连续失败达到阈值后暂时摘除模型，到期后由一个探测请求决定是否恢复
+type CircuitBreaker struct {
+	Name             string
+	FailureThreshold int
+	OpenDuration     time.Duration
+
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool             // 半开状态下是否已有探测请求
+	now      func() time.Time // 当前时间，测试中可替换
+	mu       sync.Mutex
+}
+
+// NewCircuitBreaker 创建熔断器，使用默认阈值和熔断时间
+func NewCircuitBreaker(name string) *CircuitBreaker {
+	return &CircuitBreaker{
+		Name:             name,
+		FailureThreshold: defaultBreakerFailureThreshold,
+		OpenDuration:     defaultBreakerOpenDuration,
+		state:            BreakerStateClosed,
+		now:              time.Now,
+	}
+}
+
+// Allow 判断是否放行请求，放行后必须调用 OnSuccess、OnFailure 或 OnCancel 之一
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerStateOpen:
+		if b.now().Sub(b.openedAt) < b.OpenDuration {
+			return false
+		}
+		b.state = BreakerStateHalfOpen
+		b.probing = true
+		return true
+	case BreakerStateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// OnSuccess 请求成功，恢复正常状态
+func (b *CircuitBreaker) OnSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerStateClosed {
+		log.Printf("模型 %s 熔断恢复", b.Name)
+	}
+	b.state = BreakerStateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// OnFailure 请求失败，连续失败达到阈值或探测失败时熔断
+func (b *CircuitBreaker) OnFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerStateHalfOpen || b.failures >= b.FailureThreshold {
+		if b.state != BreakerStateOpen {
+			log.Printf("模型 %s 连续失败 %d 次，熔断 %s", b.Name, b.failures, b.OpenDuration)
+		}
+		b.state = BreakerStateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// OnCancel 请求被调用方取消，不计入成功或失败，释放探测名额
+func (b *CircuitBreaker) OnCancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State 当前状态，熔断到期但尚未探测时返回半开
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerStateOpen && b.now().Sub(b.openedAt) >= b.OpenDuration {
+		return BreakerStateHalfOpen
+	}
+	return b.state
+}
+
+// circuitBreakers 按模型 Bean 名称共享熔断器，同一模型被多个客户端的模型组引用时共用健康状态
+type circuitBreakers struct {
+	breakers map[string]*CircuitBreaker
+	mu       sync.Mutex
+}
+
+// newCircuitBreakers 创建熔断器集合
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{breakers: make(map[string]*CircuitBreaker)}
+}
+
+// get 获取模型的熔断器，不存在时创建
+func (c *circuitBreakers) get(name string) *CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	breaker, ok := c.breakers[name]
+	if !ok {
+		breaker = NewCircuitBreaker(name)
+		c.breakers[name] = breaker
+	}
+	return breaker
+}
@@ -0,0 +1,165 @@
+package node
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动推进的时钟，After 创建的定时器在 Advance 到期时触发
+type fakeClock struct {
+	mu      sync.Mutex
+	current time.Time
+	timers  []*fakeTimer
+}
+
+// fakeTimer 手动时钟上的定时器
+type fakeTimer struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{current: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+func (c *fakeClock) After(d time.Duration) (<-chan time.Time, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{deadline: c.current.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.ch <- c.current
+		return timer.ch, func() {}
+	}
+	c.timers = append(c.timers, timer)
+	return timer.ch, func() { c.removeTimer(timer) }
+}
+
+// Advance 推进时间并触发到期的定时器
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = c.current.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.current) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- c.current
+	}
+	c.timers = pending
+}
+
+// waitForTimers 等待至少 n 个定时器处于等待状态
+func (c *fakeClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		pending := len(c.timers)
+		c.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("等待中的定时器数量 = %d, 期望至少 %d", pending, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *fakeClock) removeTimer(target *fakeTimer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, timer := range c.timers {
+		if timer == target {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+// newTestCircuitBreaker 使用手动时钟的熔断器
+func newTestCircuitBreaker(name string, clock *fakeClock) *CircuitBreaker {
+	breaker := NewCircuitBreaker(name)
+	breaker.now = clock.Now
+	return breaker
+}
+
+func TestCircuitBreakerOpenHalfOpenClose(t *testing.T) {
+	clock := newFakeClock()
+	breaker := newTestCircuitBreaker("model-a", clock)
+
+	// 连续失败达到阈值前保持正常
+	for i := 0; i < breaker.FailureThreshold-1; i++ {
+		if !breaker.Allow() {
+			t.Fatalf("第 %d 次请求被拒绝", i+1)
+		}
+		breaker.OnFailure()
+	}
+	if state := breaker.State(); state != BreakerStateClosed {
+		t.Fatalf("未达到阈值时状态 = %s", state)
+	}
+
+	breaker.Allow()
+	breaker.OnFailure()
+	if state := breaker.State(); state != BreakerStateOpen {
+		t.Fatalf("达到阈值后状态 = %s, 期望 %s", state, BreakerStateOpen)
+	}
+	if breaker.Allow() {
+		t.Fatal("熔断中不应放行请求")
+	}
+
+	// 到期后只放行一个探测请求，探测失败重新熔断
+	clock.Advance(breaker.OpenDuration)
+	if state := breaker.State(); state != BreakerStateHalfOpen {
+		t.Fatalf("熔断到期后状态 = %s, 期望 %s", state, BreakerStateHalfOpen)
+	}
+	if !breaker.Allow() {
+		t.Fatal("熔断到期后应放行探测请求")
+	}
+	if breaker.Allow() {
+		t.Fatal("探测请求未结束时不应放行第二个请求")
+	}
+	breaker.OnFailure()
+	if state := breaker.State(); state != BreakerStateOpen {
+		t.Fatalf("探测失败后状态 = %s, 期望 %s", state, BreakerStateOpen)
+	}
+
+	// 再次到期，探测被取消时释放名额，探测成功后恢复
+	clock.Advance(breaker.OpenDuration)
+	if !breaker.Allow() {
+		t.Fatal("熔断再次到期后应放行探测请求")
+	}
+	breaker.OnCancel()
+	if !breaker.Allow() {
+		t.Fatal("探测请求取消后应重新放行探测请求")
+	}
+	breaker.OnSuccess()
+	if state := breaker.State(); state != BreakerStateClosed {
+		t.Fatalf("探测成功后状态 = %s, 期望 %s", state, BreakerStateClosed)
+	}
+	if !breaker.Allow() || !breaker.Allow() {
+		t.Error("恢复后应放行所有请求")
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	breaker := newTestCircuitBreaker("model-a", newFakeClock())
+
+	for i := 0; i < breaker.FailureThreshold-1; i++ {
+		breaker.OnFailure()
+	}
+	breaker.OnSuccess()
+	breaker.OnFailure()
+	if state := breaker.State(); state != BreakerStateClosed {
+		t.Errorf("成功后失败次数应清零，状态 = %s", state)
+	}
+}
@@ -0,0 +1,16 @@
+package node
+
+import (
+	"strconv"
+
+	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/model/valobj"
+)
+
+// EmbeddingModelProvider 按模型配置创建嵌入模型，由构建链装配时注入
+type EmbeddingModelProvider func(modelVO valobj.AiClientModelVO) (config.EmbeddingModel, error)
+
+// embeddingModelBeanName 嵌入模型Bean名称，与对话模型 AiClientModel_<id> 区分
+func embeddingModelBeanName(id int64) string {
+	return "AiClientEmbeddingModel_" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,337 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JSON Schema 类型
+const (
+	JSONSchemaTypeObject  = "object"
+	JSONSchemaTypeArray   = "array"
+	JSONSchemaTypeString  = "string"
+	JSONSchemaTypeNumber  = "number"
+	JSONSchemaTypeInteger = "integer"
+	JSONSchemaTypeBoolean = "boolean"
+	JSONSchemaTypeNull    = "null"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// JSONSchema JSON Schema 的子集，用于约束和校验结构化输出
+// Type 为 string，可为空字段为 []string（如 ["string","null"]）；AdditionalProperties 为 false 或 *JSONSchema
+type JSONSchema struct {
+	Type                 any                    `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+}
+
+// GenerateJSONSchema 根据 Go 类型生成 JSON Schema，顶层必须为结构体
+// 字段名取 json 标签，description、enum（逗号分隔，仅字符串字段）标签分别生成描述和枚举
+// 所有字段均列为必填，指针和 omitempty 字段允许为 null；返回的 strict 表示 Schema 是否满足 OpenAI 严格模式（不含 map 和 any）
+func GenerateJSONSchema(t reflect.Type) (schema *JSONSchema, strict bool, err error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil, false, fmt.Errorf("结构化输出类型必须为结构体: %s", t)
+	}
+
+	generator := &jsonSchemaGenerator{visiting: make(map[reflect.Type]bool), strict: true}
+	schema, err = generator.generate(t)
+	if err != nil {
+		return nil, false, err
+	}
+	return schema, generator.strict, nil
+}
+
+// jsonSchemaGenerator 反射生成 Schema，visiting 用于检测递归类型
+type jsonSchemaGenerator struct {
+	visiting map[reflect.Type]bool
+	strict   bool
+}
+
+// generate 生成类型对应的 Schema
+func (g *jsonSchemaGenerator) generate(t reflect.Type) (*JSONSchema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &JSONSchema{Type: JSONSchemaTypeString, Format: "date-time"}, nil
+	case t == rawMessageType:
+		g.strict = false
+		return &JSONSchema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: JSONSchemaTypeBoolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: JSONSchemaTypeInteger}, nil
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: JSONSchemaTypeNumber}, nil
+	case reflect.String:
+		return &JSONSchema{Type: JSONSchemaTypeString}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte 按 base64 字符串编码
+			return &JSONSchema{Type: JSONSchemaTypeString}, nil
+		}
+		items, err := g.generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: JSONSchemaTypeArray, Items: items}, nil
+	case reflect.Map:
+		g.strict = false
+		values, err := g.generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: JSONSchemaTypeObject, AdditionalProperties: values}, nil
+	case reflect.Interface:
+		g.strict = false
+		return &JSONSchema{}, nil
+	case reflect.Struct:
+		if g.visiting[t] {
+			return nil, fmt.Errorf("结构化输出不支持递归类型: %s", t)
+		}
+		g.visiting[t] = true
+		defer delete(g.visiting, t)
+
+		schema := &JSONSchema{
+			Type:                 JSONSchemaTypeObject,
+			Properties:           make(map[string]*JSONSchema),
+			AdditionalProperties: false,
+		}
+		if err := g.addFields(schema, t); err != nil {
+			return nil, err
+		}
+		return schema, nil
+	default:
+		return nil, fmt.Errorf("结构化输出不支持的类型: %s", t)
+	}
+}
+
+// addFields 将结构体字段加入对象 Schema，未指定 json 名称的匿名结构体字段展开到外层
+func (g *jsonSchemaGenerator) addFields(schema *JSONSchema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if err := g.addFields(schema, fieldType); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := g.generate(field.Type)
+		if err != nil {
+			return fmt.Errorf("字段 %s.%s: %w", t.Name(), field.Name, err)
+		}
+		property.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			if property.Type != JSONSchemaTypeString {
+				return fmt.Errorf("字段 %s.%s: enum 标签只支持字符串字段", t.Name(), field.Name)
+			}
+			for _, value := range strings.Split(enum, ",") {
+				property.Enum = append(property.Enum, strings.TrimSpace(value))
+			}
+		}
+		optional := field.Type.Kind() == reflect.Pointer || strings.Contains(","+options+",", ",omitempty,")
+		if optional && property.Type != nil {
+			property.Type = []string{property.Type.(string), JSONSchemaTypeNull}
+			if property.Enum != nil {
+				property.Enum = append(property.Enum, nil)
+			}
+		}
+
+		if _, exists := schema.Properties[name]; !exists {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	return nil
+}
+
+// Validate 校验 JSON 值（json.Decoder 开启 UseNumber 解码），返回全部校验错误，路径以 $ 表示根
+// 错误信息会回传给模型，使用英文
+func (s *JSONSchema) Validate(value any) []string {
+	var errs []string
+	s.validate("$", value, &errs)
+	return errs
+}
+
+// validate 递归校验
+func (s *JSONSchema) validate(path string, value any, errs *[]string) {
+	if types := s.types(); len(types) > 0 {
+		matched := false
+		for _, schemaType := range types {
+			if matchJSONSchemaType(schemaType, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonValueType(value)))
+			return
+		}
+	}
+	if value == nil {
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, candidate := range s.Enum {
+			if candidate == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowed, _ := json.Marshal(s.Enum)
+			*errs = append(*errs, fmt.Sprintf("%s: value must be one of %s", path, allowed))
+		}
+	}
+
+	switch typed := value.(type) {
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, typed); err != nil {
+				*errs = append(*errs, fmt.Sprintf("%s: expected RFC 3339 date-time, got %q", path, typed))
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range typed {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := typed[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propertyPath := path + "." + name
+			if property, ok := s.Properties[name]; ok {
+				property.validate(propertyPath, typed[name], errs)
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					*errs = append(*errs, fmt.Sprintf("%s: property is not allowed", propertyPath))
+				}
+			case *JSONSchema:
+				additional.validate(propertyPath, typed[name], errs)
+			}
+		}
+	}
+}
+
+// types Schema 允许的类型，为空表示不限制
+func (s *JSONSchema) types() []string {
+	switch schemaType := s.Type.(type) {
+	case string:
+		return []string{schemaType}
+	case []string:
+		return schemaType
+	case []any:
+		types := make([]string, 0, len(schemaType))
+		for _, item := range schemaType {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchJSONSchemaType 判断值是否为指定类型，integer 要求可转换为 int64
+func matchJSONSchemaType(schemaType string, value any) bool {
+	switch schemaType {
+	case JSONSchemaTypeObject:
+		_, ok := value.(map[string]any)
+		return ok
+	case JSONSchemaTypeArray:
+		_, ok := value.([]any)
+		return ok
+	case JSONSchemaTypeString:
+		_, ok := value.(string)
+		return ok
+	case JSONSchemaTypeNumber:
+		_, ok := value.(json.Number)
+		return ok
+	case JSONSchemaTypeInteger:
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := number.Int64()
+		return err == nil
+	case JSONSchemaTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case JSONSchemaTypeNull:
+		return value == nil
+	}
+	return false
+}
+
+// jsonValueType 值的 JSON 类型名称
+func jsonValueType(value any) string {
+	switch value.(type) {
+	case nil:
+		return JSONSchemaTypeNull
+	case map[string]any:
+		return JSONSchemaTypeObject
+	case []any:
+		return JSONSchemaTypeArray
+	case string:
+		return JSONSchemaTypeString
+	case json.Number:
+		return JSONSchemaTypeNumber
+	case bool:
+		return JSONSchemaTypeBoolean
+	}
+	return fmt.Sprintf("%T", value)
+}
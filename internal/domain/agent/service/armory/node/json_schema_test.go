@@ -0,0 +1,193 @@
+package node
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reflectTypeOf T 的反射类型
+func reflectTypeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// schemaTestBase 嵌入字段展开到外层
+type schemaTestBase struct {
+	ID string `json:"id"`
+}
+
+// schemaTestItem 数组元素
+type schemaTestItem struct {
+	Name string `json:"name"`
+}
+
+// schemaTestOrder 覆盖常见标签和字段类型
+type schemaTestOrder struct {
+	schemaTestBase
+	Status    string           `json:"status" description:"订单状态" enum:"paid, shipped"`
+	Amount    float64          `json:"amount"`
+	Note      *string          `json:"note"`
+	Coupon    string           `json:"coupon,omitempty"`
+	Items     []schemaTestItem `json:"items"`
+	CreatedAt time.Time        `json:"created_at"`
+	Internal  string           `json:"-"`
+	hidden    string
+}
+
+// schemaTestNode 递归类型
+type schemaTestNode struct {
+	Children []schemaTestNode `json:"children"`
+}
+
+func TestGenerateJSONSchemaFromTaggedStruct(t *testing.T) {
+	schema, strict, err := GenerateJSONSchema(reflectTypeOf[*schemaTestOrder]())
+	if err != nil {
+		t.Fatalf("生成 Schema 失败: %v", err)
+	}
+	if !strict {
+		t.Error("不含 map 和 any 的结构体应满足严格模式")
+	}
+	if schema.Type != JSONSchemaTypeObject || schema.AdditionalProperties != false {
+		t.Errorf("顶层 Schema = %+v", schema)
+	}
+	if want := "id,status,amount,note,coupon,items,created_at"; strings.Join(schema.Required, ",") != want {
+		t.Errorf("required = %v, 期望 %s", schema.Required, want)
+	}
+
+	tests := []struct {
+		name     string
+		property string
+		want     string
+	}{
+		{name: "嵌入字段", property: "id", want: `{"type":"string"}`},
+		{name: "描述和枚举", property: "status", want: `{"type":"string","description":"订单状态","enum":["paid","shipped"]}`},
+		{name: "浮点数", property: "amount", want: `{"type":"number"}`},
+		{name: "指针可为空", property: "note", want: `{"type":["string","null"]}`},
+		{name: "omitempty 可为空", property: "coupon", want: `{"type":["string","null"]}`},
+		{name: "结构体数组", property: "items", want: `{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}}`},
+		{name: "时间", property: "created_at", want: `{"type":"string","format":"date-time"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			property, ok := schema.Properties[tt.property]
+			if !ok {
+				t.Fatalf("缺少属性 %s", tt.property)
+			}
+			got, _ := json.Marshal(property)
+			if string(got) != tt.want {
+				t.Errorf("属性 %s = %s, 期望 %s", tt.property, got, tt.want)
+			}
+		})
+	}
+	for _, name := range []string{"Internal", "hidden"} {
+		if _, ok := schema.Properties[name]; ok {
+			t.Errorf("字段 %s 不应出现在 Schema 中", name)
+		}
+	}
+}
+
+func TestGenerateJSONSchemaStrictAndErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		t          reflect.Type
+		wantStrict bool
+		wantErr    string
+	}{
+		{name: "map 不满足严格模式", t: reflectTypeOf[struct {
+			Labels map[string]string `json:"labels"`
+		}]()},
+		{name: "any 不满足严格模式", t: reflectTypeOf[struct {
+			Extra any `json:"extra"`
+		}]()},
+		{name: "RawMessage 不满足严格模式", t: reflectTypeOf[struct {
+			Raw json.RawMessage `json:"raw"`
+		}]()},
+		{name: "递归类型", t: reflectTypeOf[schemaTestNode](), wantErr: "递归类型"},
+		{name: "非结构体", t: reflectTypeOf[[]string](), wantErr: "必须为结构体"},
+		{name: "非字符串枚举", t: reflectTypeOf[struct {
+			Level int `json:"level" enum:"1,2"`
+		}](), wantErr: "enum 标签只支持字符串字段"},
+		{name: "不支持的类型", t: reflectTypeOf[struct {
+			Done chan int `json:"done"`
+		}](), wantErr: "不支持的类型"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, strict, err := GenerateJSONSchema(tt.t)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v, 期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("生成 Schema 失败: %v", err)
+			}
+			if strict != tt.wantStrict {
+				t.Errorf("strict = %v, 期望 %v", strict, tt.wantStrict)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, _, err := GenerateJSONSchema(reflectTypeOf[schemaTestOrder]())
+	if err != nil {
+		t.Fatalf("生成 Schema 失败: %v", err)
+	}
+	valid := `{"id": "o-1", "status": "paid", "amount": 9.9, "note": null, "coupon": "C1",
+		"items": [{"name": "书"}], "created_at": "2026-01-01T08:00:00+08:00"}`
+
+	tests := []struct {
+		name  string
+		patch map[string]string // 替换合法对象中的属性，值为 JSON，空字符串表示删除
+		want  []string
+	}{
+		{name: "合法"},
+		{name: "类型不符", patch: map[string]string{"amount": `"9.9"`}, want: []string{"$.amount: expected number, got string"}},
+		{name: "不可为空", patch: map[string]string{"status": `null`}, want: []string{"$.status: expected string, got null"}},
+		{name: "缺少必填字段", patch: map[string]string{"id": ""}, want: []string{`$: missing required property "id"`}},
+		{name: "多余字段", patch: map[string]string{"extra": `1`}, want: []string{"$.extra: property is not allowed"}},
+		{name: "枚举", patch: map[string]string{"status": `"lost"`}, want: []string{`$.status: value must be one of ["paid","shipped"]`}},
+		{name: "时间格式", patch: map[string]string{"created_at": `"2026-01-01"`}, want: []string{`$.created_at: expected RFC 3339 date-time, got "2026-01-01"`}},
+		{name: "数组元素", patch: map[string]string{"items": `[{"name": "书"}, {"name": 1}]`}, want: []string{"$.items[1].name: expected string, got number"}},
+		{
+			name:  "返回全部错误",
+			patch: map[string]string{"amount": `true`, "id": ""},
+			want:  []string{`$: missing required property "id"`, "$.amount: expected number, got boolean"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := decodeJSONValue(t, valid).(map[string]any)
+			for name, patch := range tt.patch {
+				if patch == "" {
+					delete(value, name)
+					continue
+				}
+				value[name] = decodeJSONValue(t, patch)
+			}
+			if got := schema.Validate(value); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("校验错误 = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := schema.Validate([]any{}); len(got) != 1 || got[0] != "$: expected object, got array" {
+		t.Errorf("根类型错误 = %q", got)
+	}
+}
+
+// decodeJSONValue 按 Validate 的要求使用 UseNumber 解码
+func decodeJSONValue(t *testing.T, data string) any {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatalf("解析 %s 失败: %v", data, err)
+	}
+	return value
+}
@@ -0,0 +1,179 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MCP 协议版本，按从新到旧排列，第一个为客户端首选版本
+const (
+	McpProtocolVersion20250618 = "2025-06-18"
+	McpProtocolVersion20250326 = "2025-03-26"
+	McpProtocolVersion20241105 = "2024-11-05"
+)
+
+// McpSupportedProtocolVersions 客户端支持的协议版本
+var McpSupportedProtocolVersions = []string{
+	McpProtocolVersion20250618,
+	McpProtocolVersion20250326,
+	McpProtocolVersion20241105,
+}
+
+// MCP 方法名
+const (
+	McpMethodInitialize              = "initialize"
+	McpMethodNotificationInitialized = "notifications/initialized"
+	McpMethodNotificationCancelled   = "notifications/cancelled"
+	McpMethodPing                    = "ping"
+	McpMethodToolsList               = "tools/list"
+	McpMethodToolsCall               = "tools/call"
+)
+
+// JSON-RPC 标准错误码
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// McpJSONRPCMessage JSON-RPC 2.0 消息，请求、响应、通知共用同一结构
+type McpJSONRPCMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *McpJSONRPCError `json:"error,omitempty"`
+}
+
+// IsRequest 是否为请求（带 id 与 method）
+func (m *McpJSONRPCMessage) IsRequest() bool {
+	return m.Method != "" && len(m.ID) > 0
+}
+
+// IsNotification 是否为通知（带 method 不带 id）
+func (m *McpJSONRPCMessage) IsNotification() bool {
+	return m.Method != "" && len(m.ID) == 0
+}
+
+// IsResponse 是否为响应（带 id 不带 method）
+func (m *McpJSONRPCMessage) IsResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+// McpJSONRPCError JSON-RPC 错误对象
+type McpJSONRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *McpJSONRPCError) Error() string {
+	return fmt.Sprintf("MCP JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// McpImplementation 客户端/服务端实现信息
+type McpImplementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// McpClientCapabilities 客户端能力声明
+type McpClientCapabilities struct {
+	Experimental map[string]any `json:"experimental,omitempty"`
+	Roots        *struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	} `json:"roots,omitempty"`
+	Sampling *struct{} `json:"sampling,omitempty"`
+}
+
+// McpServerCapabilities 服务端能力声明
+type McpServerCapabilities struct {
+	Experimental map[string]any `json:"experimental,omitempty"`
+	Logging      *struct{}      `json:"logging,omitempty"`
+	Completions  *struct{}      `json:"completions,omitempty"`
+	Prompts      *struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	} `json:"prompts,omitempty"`
+	Resources *struct {
+		Subscribe   bool `json:"subscribe,omitempty"`
+		ListChanged bool `json:"listChanged,omitempty"`
+	} `json:"resources,omitempty"`
+	Tools *struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	} `json:"tools,omitempty"`
+}
+
+// McpInitializeRequest initialize 请求参数
+type McpInitializeRequest struct {
+	ProtocolVersion string                `json:"protocolVersion"`
+	Capabilities    McpClientCapabilities `json:"capabilities"`
+	ClientInfo      McpImplementation     `json:"clientInfo"`
+}
+
+// McpInitializeResult initialize 响应结果
+type McpInitializeResult struct {
+	ProtocolVersion string                `json:"protocolVersion"`
+	Capabilities    McpServerCapabilities `json:"capabilities"`
+	ServerInfo      McpImplementation     `json:"serverInfo"`
+	Instructions    string                `json:"instructions,omitempty"`
+}
+
+// McpCancelledNotification 请求取消通知参数
+type McpCancelledNotification struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// McpTool 服务端声明的工具
+type McpTool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// McpListToolsRequest tools/list 请求参数
+type McpListToolsRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// McpListToolsResult tools/list 响应结果
+type McpListToolsResult struct {
+	Tools      []McpTool `json:"tools"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
+
+// McpCallToolRequest tools/call 请求参数
+type McpCallToolRequest struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// McpContent 工具调用返回的内容块
+type McpContent struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Data     string          `json:"data,omitempty"`
+	MimeType string          `json:"mimeType,omitempty"`
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// McpCallToolResult tools/call 响应结果
+type McpCallToolResult struct {
+	Content           []McpContent    `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// isSupportedMcpProtocolVersion 判断协议版本是否受支持
+func isSupportedMcpProtocolVersion(version string) bool {
+	for _, v := range McpSupportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
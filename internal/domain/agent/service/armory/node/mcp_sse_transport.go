@@ -0,0 +1,289 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// sseEndpointDiscoveryTimeout 等待服务端下发 endpoint 事件的超时时间
+	sseEndpointDiscoveryTimeout = 30 * time.Second
+	// sseDefaultReconnectDelay 断线重连的初始等待时间
+	sseDefaultReconnectDelay = time.Second
+	// sseMaxReconnectDelay 断线重连的最大等待时间
+	sseMaxReconnectDelay = 30 * time.Second
+	// sseMaxReconnectAttempts 连续重连失败次数上限
+	sseMaxReconnectAttempts = 5
+)
+
+// HttpClientSseClientTransport SSE传输客户端（模拟Java中的HttpClientSseClientTransport）
+// 通过 GET 订阅事件流接收消息，从 endpoint 事件获取消息 POST 地址
+type HttpClientSseClientTransport struct {
+	BaseURI     string
+	SseEndpoint string
+
+	httpClient *http.Client
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	mu              sync.Mutex
+	messageEndpoint string
+	lastEventID     string
+	reconnectDelay  time.Duration
+	endpointReady   chan struct{}
+	readyOnce       sync.Once
+}
+
+// NewHttpClientSseClientTransport 创建SSE传输客户端
+func NewHttpClientSseClientTransport(baseURI, sseEndpoint string) *HttpClientSseClientTransport {
+	return &HttpClientSseClientTransport{
+		BaseURI:        baseURI,
+		SseEndpoint:    sseEndpoint,
+		httpClient:     &http.Client{},
+		reconnectDelay: sseDefaultReconnectDelay,
+		endpointReady:  make(chan struct{}),
+	}
+}
+
+// Connect 订阅事件流并等待服务端下发消息端点
+func (t *HttpClientSseClientTransport) Connect(onMessage func(message *McpJSONRPCMessage), onClose func(err error)) error {
+	if t.ctx != nil {
+		return errors.New("SSE传输已连接")
+	}
+	sseURL, err := t.sseURL()
+	if err != nil {
+		return err
+	}
+
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+
+	// 首次连接失败直接返回给调用方，不进入重连流程
+	resp, err := t.openStream(sseURL)
+	if err != nil {
+		t.cancel()
+		return err
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		err := t.run(sseURL, resp, onMessage)
+		if onClose != nil {
+			onClose(err)
+		}
+	}()
+
+	select {
+	case <-t.endpointReady:
+		return nil
+	case <-t.ctx.Done():
+		return errors.New("SSE连接在获取消息端点前已断开")
+	case <-time.After(sseEndpointDiscoveryTimeout):
+		_ = t.Close()
+		return fmt.Errorf("等待SSE endpoint事件超时(%s)", sseEndpointDiscoveryTimeout)
+	}
+}
+
+// SendMessage 将消息 POST 到服务端下发的消息端点
+func (t *HttpClientSseClientTransport) SendMessage(ctx context.Context, message *McpJSONRPCMessage) error {
+	select {
+	case <-t.endpointReady:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	t.mu.Lock()
+	endpoint := t.messageEndpoint
+	t.mu.Unlock()
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("序列化MCP消息失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("MCP消息发送失败 status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close 断开事件流
+func (t *HttpClientSseClientTransport) Close() error {
+	if t.cancel == nil {
+		return nil
+	}
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+// run 读取事件流，断线后携带 Last-Event-ID 重连
+func (t *HttpClientSseClientTransport) run(sseURL string, resp *http.Response, onMessage func(message *McpJSONRPCMessage)) error {
+	failures := 0
+	for {
+		streamErr := t.readStream(sseURL, resp, onMessage)
+		if t.ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("SSE事件流断开 %s: %v，准备重连", sseURL, streamErr)
+
+		for {
+			failures++
+			if failures > sseMaxReconnectAttempts {
+				t.cancel()
+				return fmt.Errorf("SSE重连失败次数超过上限 %d", sseMaxReconnectAttempts)
+			}
+
+			select {
+			case <-time.After(t.nextReconnectDelay(failures)):
+			case <-t.ctx.Done():
+				return nil
+			}
+
+			var err error
+			resp, err = t.openStream(sseURL)
+			if err == nil {
+				break
+			}
+			log.Printf("SSE重连失败(%d/%d) %s: %v", failures, sseMaxReconnectAttempts, sseURL, err)
+		}
+		failures = 0
+	}
+}
+
+// openStream 发起 GET 请求订阅事件流
+func (t *HttpClientSseClientTransport) openStream(sseURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, sseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	t.mu.Lock()
+	if t.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", t.lastEventID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("连接SSE端点 %s 失败: %w", sseURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("连接SSE端点 %s 失败 status=%d body=%s", sseURL, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return resp, nil
+}
+
+// readStream 分发事件直到流结束
+func (t *HttpClientSseClientTransport) readStream(sseURL string, resp *http.Response, onMessage func(message *McpJSONRPCMessage)) error {
+	defer resp.Body.Close()
+
+	return readSseEvents(resp.Body, func(event *sseEvent) bool {
+		t.mu.Lock()
+		if event.ID != "" {
+			t.lastEventID = event.ID
+		}
+		if event.Retry > 0 {
+			t.reconnectDelay = event.Retry
+		}
+		t.mu.Unlock()
+
+		switch event.Event {
+		case "endpoint":
+			endpoint, err := resolveURL(sseURL, strings.TrimSpace(event.Data))
+			if err != nil {
+				log.Printf("解析SSE消息端点失败: %v", err)
+				return true
+			}
+			t.mu.Lock()
+			t.messageEndpoint = endpoint
+			t.mu.Unlock()
+			t.readyOnce.Do(func() { close(t.endpointReady) })
+		case "", "message":
+			var message McpJSONRPCMessage
+			if err := json.Unmarshal([]byte(event.Data), &message); err != nil {
+				log.Printf("解析SSE消息失败: %v data=%s", err, event.Data)
+				return true
+			}
+			if onMessage != nil {
+				onMessage(&message)
+			}
+		default:
+			log.Printf("忽略未知SSE事件: %s", event.Event)
+		}
+		return true
+	})
+}
+
+// nextReconnectDelay 指数退避计算重连等待时间
+func (t *HttpClientSseClientTransport) nextReconnectDelay(failures int) time.Duration {
+	t.mu.Lock()
+	delay := t.reconnectDelay
+	t.mu.Unlock()
+
+	for i := 1; i < failures && delay < sseMaxReconnectDelay; i++ {
+		delay *= 2
+	}
+	if delay > sseMaxReconnectDelay {
+		delay = sseMaxReconnectDelay
+	}
+	return delay
+}
+
+// sseURL 拼接事件流地址
+func (t *HttpClientSseClientTransport) sseURL() (string, error) {
+	if t.BaseURI == "" {
+		return "", errors.New("SSE BaseURI为空")
+	}
+	endpoint := t.SseEndpoint
+	if endpoint != "" && !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+	sseURL := strings.TrimRight(t.BaseURI, "/") + endpoint
+	if _, err := url.Parse(sseURL); err != nil {
+		return "", fmt.Errorf("SSE地址不合法 %s: %w", sseURL, err)
+	}
+	return sseURL, nil
+}
+
+// resolveURL 以 base 为基准解析相对地址
+func resolveURL(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
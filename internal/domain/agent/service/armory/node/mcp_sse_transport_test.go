@@ -0,0 +1,226 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadSseEvents(t *testing.T) {
+	stream := ": ping\r\n" +
+		"id: 1\r\n" +
+		"event: endpoint\r\n" +
+		"data: /messages\r\n" +
+		"\r\n" +
+		"retry: 250\n" +
+		"data: line1\n" +
+		"data: line2\n" +
+		"\n" +
+		"id: 3\n" +
+		"data: tail"
+
+	var events []sseEvent
+	if err := readSseEvents(strings.NewReader(stream), func(event *sseEvent) bool {
+		events = append(events, *event)
+		return true
+	}); err != nil {
+		t.Fatalf("readSseEvents 失败: %v", err)
+	}
+
+	want := []sseEvent{
+		{ID: "1", Event: "endpoint", Data: "/messages"},
+		{Data: "line1\nline2", Retry: 250 * time.Millisecond},
+		{ID: "3", Data: "tail"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("事件数量 = %d, 期望 %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("事件[%d] = %+v, 期望 %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestSseTransportEndpointDiscovery(t *testing.T) {
+	received := make(chan *McpJSONRPCMessage, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/sse":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: endpoint\ndata: /messages?sessionId=abc\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case r.Method == http.MethodPost && r.URL.Path == "/messages":
+			if r.URL.Query().Get("sessionId") != "abc" {
+				http.Error(w, "unknown session", http.StatusNotFound)
+				return
+			}
+			var message McpJSONRPCMessage
+			if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			received <- &message
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	transport := NewHttpClientSseClientTransport(server.URL, "sse")
+	if err := transport.Connect(nil, nil); err != nil {
+		t.Fatalf("Connect 失败: %v", err)
+	}
+	defer transport.Close()
+
+	if want := server.URL + "/messages?sessionId=abc"; transport.messageEndpoint != want {
+		t.Errorf("消息端点 = %s, 期望 %s", transport.messageEndpoint, want)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := transport.SendMessage(ctx, &McpJSONRPCMessage{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: "ping"}); err != nil {
+		t.Fatalf("SendMessage 失败: %v", err)
+	}
+	select {
+	case message := <-received:
+		if message.Method != "ping" || string(message.ID) != "1" {
+			t.Errorf("服务端收到消息 = %+v", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("服务端未收到消息")
+	}
+}
+
+func TestSseTransportReconnectWithLastEventID(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		connections  int
+		lastEventIDs []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connections++
+		connection := connections
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch connection {
+		case 1:
+			// 首次连接下发端点和一条消息后断开，retry 缩短重连等待
+			fmt.Fprint(w, "retry: 10\nid: 1\nevent: endpoint\ndata: /messages\n\n")
+			fmt.Fprint(w, "id: 2\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/first\"}\n\n")
+		case 2:
+			// 第二次连接直接失败，触发退避重试
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, "id: 3\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/second\"}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	messages := make(chan string, 4)
+	transport := NewHttpClientSseClientTransport(server.URL, "/sse")
+	if err := transport.Connect(func(message *McpJSONRPCMessage) {
+		messages <- message.Method
+	}, nil); err != nil {
+		t.Fatalf("Connect 失败: %v", err)
+	}
+	defer transport.Close()
+
+	for _, want := range []string{"notifications/first", "notifications/second"} {
+		select {
+		case method := <-messages:
+			if method != want {
+				t.Fatalf("收到消息 = %s, 期望 %s", method, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("未收到消息 %s", want)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"", "2", "2"}
+	if len(lastEventIDs) != len(want) {
+		t.Fatalf("连接次数 = %d, 期望 %d", len(lastEventIDs), len(want))
+	}
+	for i := range want {
+		if lastEventIDs[i] != want[i] {
+			t.Errorf("第 %d 次连接 Last-Event-ID = %q, 期望 %q", i+1, lastEventIDs[i], want[i])
+		}
+	}
+}
+
+func TestSseTransportGiveUpAfterMaxReconnectAttempts(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		connections int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connections++
+		connection := connections
+		mu.Unlock()
+
+		if connection > 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 1\nevent: endpoint\ndata: /messages\n\n")
+	}))
+	defer server.Close()
+
+	closed := make(chan error, 1)
+	transport := NewHttpClientSseClientTransport(server.URL, "/sse")
+	if err := transport.Connect(nil, func(err error) { closed <- err }); err != nil {
+		t.Fatalf("Connect 失败: %v", err)
+	}
+	defer transport.Close()
+
+	select {
+	case err := <-closed:
+		if err == nil || !strings.Contains(err.Error(), "超过上限") {
+			t.Fatalf("关闭原因 = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("重连失败后未关闭")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := 1 + sseMaxReconnectAttempts; connections != want {
+		t.Errorf("连接次数 = %d, 期望 %d", connections, want)
+	}
+}
+
+func TestSseTransportReconnectBackoff(t *testing.T) {
+	transport := NewHttpClientSseClientTransport("http://localhost", "/sse")
+	want := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		16 * time.Second, sseMaxReconnectDelay, sseMaxReconnectDelay,
+	}
+	for i, delay := range want {
+		if got := transport.nextReconnectDelay(i + 1); got != delay {
+			t.Errorf("第 %d 次失败等待 = %s, 期望 %s", i+1, got, delay)
+		}
+	}
+
+	// 服务端通过 retry 字段调整初始等待时间
+	transport.reconnectDelay = 100 * time.Millisecond
+	if got := transport.nextReconnectDelay(3); got != 400*time.Millisecond {
+		t.Errorf("retry 调整后第 3 次失败等待 = %s, 期望 400ms", got)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package node
+
+import "os/exec"
+
+// setProcessGroup 非 unix 平台不支持进程组，孙进程由关闭管道兜底
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup 强制结束子进程
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package node
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 子进程使用独立进程组，关闭时连同其派生的孙进程一起结束
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup 强制结束子进程所在的进程组
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,210 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// stdioCloseGracePeriod 关闭 stdin 后等待子进程自行退出的时间
+	stdioCloseGracePeriod = 3 * time.Second
+	// stdioKillWaitTimeout 强制结束进程组后等待管道关闭的时间，超时后直接关闭管道
+	stdioKillWaitTimeout = 2 * time.Second
+)
+
+// ServerParameters 服务器参数（模拟Java中的ServerParameters）
+type ServerParameters struct {
+	Command string
+	Args    []string
+	Env     map[string]string
+}
+
+// StdioClientTransport Stdio传输客户端（模拟Java中的StdioClientTransport）
+// 启动 MCP 服务端子进程，通过 stdin/stdout 按行收发 JSON-RPC 消息
+type StdioClientTransport struct {
+	ServerParams *ServerParameters
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  io.ReadCloser
+	stderr  io.ReadCloser
+	writeMu sync.Mutex
+	done    chan struct{}
+
+	closeOnce sync.Once
+}
+
+// NewStdioClientTransport 创建Stdio传输客户端
+func NewStdioClientTransport(serverParams *ServerParameters) *StdioClientTransport {
+	return &StdioClientTransport{
+		ServerParams: serverParams,
+		done:         make(chan struct{}),
+	}
+}
+
+// Connect 启动子进程并开始读取 stdout
+func (t *StdioClientTransport) Connect(onMessage func(message *McpJSONRPCMessage), onClose func(err error)) error {
+	if t.ServerParams == nil || t.ServerParams.Command == "" {
+		return errors.New("Stdio服务器参数为空")
+	}
+	if t.cmd != nil {
+		return errors.New("Stdio传输已连接")
+	}
+
+	cmd := exec.Command(t.ServerParams.Command, t.ServerParams.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range t.ServerParams.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	setProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("创建stdin管道失败: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("创建stdout管道失败: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("创建stderr管道失败: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动MCP服务进程 %s 失败: %w", t.ServerParams.Command, err)
+	}
+
+	t.cmd = cmd
+	t.stdin = stdin
+	t.stdout = stdout
+	t.stderr = stderr
+
+	// stdout/stderr 读取完毕后才能调用 Wait
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		t.readStdout(stdout, onMessage)
+	}()
+	go func() {
+		defer readers.Done()
+		t.readStderr(stderr)
+	}()
+
+	go func() {
+		readers.Wait()
+		waitErr := cmd.Wait()
+		close(t.done)
+		if onClose != nil {
+			onClose(waitErr)
+		}
+	}()
+
+	return nil
+}
+
+// SendMessage 向子进程 stdin 写入一行 JSON
+func (t *StdioClientTransport) SendMessage(ctx context.Context, message *McpJSONRPCMessage) error {
+	if t.stdin == nil {
+		return errors.New("Stdio传输未连接")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("序列化MCP消息失败: %w", err)
+	}
+	data = append(data, '\n')
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	select {
+	case <-t.done:
+		return errors.New("MCP服务进程已退出")
+	default:
+	}
+	_, err = t.stdin.Write(data)
+	return err
+}
+
+// Close 关闭 stdin 通知子进程退出，超时后强制结束整个进程组
+// 孙进程可能继承 stdout/stderr，进程组结束后管道仍未关闭时直接关闭管道，避免一直等待
+func (t *StdioClientTransport) Close() error {
+	if t.cmd == nil {
+		return nil
+	}
+
+	t.closeOnce.Do(func() {
+		t.writeMu.Lock()
+		_ = t.stdin.Close()
+		t.writeMu.Unlock()
+
+		select {
+		case <-t.done:
+		case <-time.After(stdioCloseGracePeriod):
+			log.Printf("MCP服务进程 %s 未在 %s 内退出，强制结束", t.ServerParams.Command, stdioCloseGracePeriod)
+			if err := killProcessGroup(t.cmd); err != nil {
+				_ = t.cmd.Process.Kill()
+			}
+		}
+
+		select {
+		case <-t.done:
+		case <-time.After(stdioKillWaitTimeout):
+			log.Printf("MCP服务进程 %s 的输出管道未关闭，直接关闭管道", t.ServerParams.Command)
+			_ = t.stdout.Close()
+			_ = t.stderr.Close()
+			<-t.done
+		}
+	})
+	return nil
+}
+
+// readStdout 按行解析 JSON-RPC 消息
+func (t *StdioClientTransport) readStdout(stdout io.Reader, onMessage func(message *McpJSONRPCMessage)) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var message McpJSONRPCMessage
+			if jsonErr := json.Unmarshal(line, &message); jsonErr != nil {
+				log.Printf("MCP服务 %s 输出非JSON-RPC内容: %s", t.ServerParams.Command, string(line))
+			} else if onMessage != nil {
+				onMessage(&message)
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("读取MCP服务 %s stdout 失败: %v", t.ServerParams.Command, err)
+			}
+			return
+		}
+	}
+}
+
+// readStderr 转发子进程 stderr 到日志
+func (t *StdioClientTransport) readStderr(stderr io.Reader) {
+	reader := bufio.NewReader(stderr)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			log.Printf("MCP服务 %s stderr: %s", t.ServerParams.Command, string(line))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStdioClientTransportCloseKillsGrandchildren(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		// 子进程不读 stdin，关闭 stdin 后不会退出
+		{name: "子进程和孙进程都不退出", script: "sleep 60 & sleep 60"},
+		// 子进程退出后孙进程仍持有 stdout/stderr
+		{name: "孙进程持有输出管道", script: "sleep 60 &"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed := make(chan error, 1)
+			transport := NewStdioClientTransport(&ServerParameters{Command: "sh", Args: []string{"-c", tt.script}})
+			if err := transport.Connect(nil, func(err error) { closed <- err }); err != nil {
+				t.Fatalf("Connect 失败: %v", err)
+			}
+
+			start := time.Now()
+			if err := transport.Close(); err != nil {
+				t.Fatalf("Close 失败: %v", err)
+			}
+			// 结束进程组后管道随孙进程关闭，无需等到直接关闭管道
+			if elapsed := time.Since(start); elapsed >= stdioCloseGracePeriod+stdioKillWaitTimeout {
+				t.Errorf("Close 耗时 %s, 孙进程未随进程组结束", elapsed)
+			}
+			select {
+			case <-closed:
+			default:
+				t.Error("Close 返回时应已回调 onClose")
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// McpSessionIdHeader 会话ID请求头
+	McpSessionIdHeader = "Mcp-Session-Id"
+	// McpProtocolVersionHeader 协议版本请求头
+	McpProtocolVersionHeader = "MCP-Protocol-Version"
+)
+
+// ErrMcpSessionExpired 服务端会话已失效（HTTP 404），DefaultMcpSyncClient 收到后重新初始化并重发请求
+var ErrMcpSessionExpired = errors.New("MCP会话已失效")
+
+// StreamableHttpClientTransport Streamable HTTP传输客户端
+// 所有消息 POST 到同一端点，服务端可直接返回 JSON，也可升级为 SSE 流返回
+// 暂不开启 GET 监听流，服务端主动推送的消息只能随 POST 响应流下发
+type StreamableHttpClientTransport struct {
+	BaseURI  string
+	Endpoint string
+	Headers  map[string]string
+
+	httpClient *http.Client
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	onMessage  func(message *McpJSONRPCMessage)
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+}
+
+// NewStreamableHttpClientTransport 创建Streamable HTTP传输客户端
+func NewStreamableHttpClientTransport(baseURI, endpoint string, headers map[string]string) *StreamableHttpClientTransport {
+	return &StreamableHttpClientTransport{
+		BaseURI:    baseURI,
+		Endpoint:   endpoint,
+		Headers:    headers,
+		httpClient: &http.Client{},
+	}
+}
+
+// Connect Streamable HTTP 无常驻连接，仅记录回调
+func (t *StreamableHttpClientTransport) Connect(onMessage func(message *McpJSONRPCMessage), onClose func(err error)) error {
+	if t.ctx != nil {
+		return errors.New("Streamable HTTP传输已连接")
+	}
+	if t.BaseURI == "" {
+		return errors.New("Streamable HTTP BaseURI为空")
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	t.onMessage = onMessage
+	return nil
+}
+
+// SetProtocolVersion 记录协商后的协议版本，后续请求携带 MCP-Protocol-Version 头
+func (t *StreamableHttpClientTransport) SetProtocolVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocolVersion = version
+}
+
+// SessionID 获取当前会话ID
+func (t *StreamableHttpClientTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// SendMessage POST 消息，按响应类型解析 JSON 或 SSE 流
+func (t *StreamableHttpClientTransport) SendMessage(ctx context.Context, message *McpJSONRPCMessage) error {
+	if t.ctx == nil {
+		return errors.New("Streamable HTTP传输未连接")
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("序列化MCP消息失败: %w", err)
+	}
+
+	// 响应流的生命周期跟随传输层，ctx 只控制等待响应头的阶段
+	reqCtx, cancelReq := context.WithCancel(t.ctx)
+	stop := context.AfterFunc(ctx, cancelReq)
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, t.endpointURL(), bytes.NewReader(body))
+	if err != nil {
+		stop()
+		cancelReq()
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.applyHeaders(req)
+
+	resp, err := t.httpClient.Do(req)
+	stop()
+	if err != nil {
+		cancelReq()
+		return err
+	}
+
+	if sessionID := resp.Header.Get(McpSessionIdHeader); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		// 通知或响应已被接收，无内容返回
+		resp.Body.Close()
+		cancelReq()
+		return nil
+	case resp.StatusCode == http.StatusNotFound && req.Header.Get(McpSessionIdHeader) != "":
+		resp.Body.Close()
+		cancelReq()
+		// 只清除本次请求使用的会话，并发请求可能已重新初始化得到新会话
+		t.mu.Lock()
+		if t.sessionID == req.Header.Get(McpSessionIdHeader) {
+			t.sessionID = ""
+		}
+		t.mu.Unlock()
+		return ErrMcpSessionExpired
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		cancelReq()
+		return fmt.Errorf("MCP消息发送失败 status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream":
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			defer cancelReq()
+			t.readEventStream(resp)
+		}()
+		return nil
+	case "application/json":
+		defer cancelReq()
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("读取MCP响应失败: %w", err)
+		}
+		return t.dispatchJSON(respBody)
+	default:
+		resp.Body.Close()
+		cancelReq()
+		if len(message.ID) > 0 && message.Method != "" {
+			return fmt.Errorf("MCP响应类型不支持: %s", resp.Header.Get("Content-Type"))
+		}
+		return nil
+	}
+}
+
+// Close 终止会话并取消进行中的响应流
+func (t *StreamableHttpClientTransport) Close() error {
+	if t.cancel == nil {
+		return nil
+	}
+
+	if sessionID := t.SessionID(); sessionID != "" {
+		ctx, cancel := context.WithTimeout(t.ctx, 5*time.Second)
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.endpointURL(), nil)
+		if err == nil {
+			t.applyHeaders(req)
+			if resp, err := t.httpClient.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+		cancel()
+	}
+
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+// readEventStream 解析 POST 响应中的 SSE 流
+func (t *StreamableHttpClientTransport) readEventStream(resp *http.Response) {
+	defer resp.Body.Close()
+
+	err := readSseEvents(resp.Body, func(event *sseEvent) bool {
+		if event.Event != "" && event.Event != "message" {
+			return true
+		}
+		if strings.TrimSpace(event.Data) == "" {
+			return true
+		}
+		if err := t.dispatchJSON([]byte(event.Data)); err != nil {
+			log.Printf("解析Streamable HTTP事件失败: %v", err)
+		}
+		return true
+	})
+	if err != nil && t.ctx.Err() == nil {
+		log.Printf("读取Streamable HTTP响应流失败: %v", err)
+	}
+}
+
+// dispatchJSON 分发单条或批量 JSON-RPC 消息
+func (t *StreamableHttpClientTransport) dispatchJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var messages []*McpJSONRPCMessage
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("解析MCP批量消息失败: %w", err)
+		}
+	} else {
+		var message McpJSONRPCMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return fmt.Errorf("解析MCP消息失败: %w", err)
+		}
+		messages = append(messages, &message)
+	}
+
+	if t.onMessage != nil {
+		for _, message := range messages {
+			t.onMessage(message)
+		}
+	}
+	return nil
+}
+
+// applyHeaders 设置会话、协议版本及自定义请求头
+func (t *StreamableHttpClientTransport) applyHeaders(req *http.Request) {
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(McpSessionIdHeader, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(McpProtocolVersionHeader, t.protocolVersion)
+	}
+}
+
+// endpointURL 拼接消息端点地址
+func (t *StreamableHttpClientTransport) endpointURL() string {
+	endpoint := t.Endpoint
+	if endpoint == "" {
+		return t.BaseURI
+	}
+	if !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+	return strings.TrimRight(t.BaseURI, "/") + endpoint
+}
@@ -0,0 +1,245 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// connectStreamableTransport 连接到模拟服务端，收到的消息写入返回的 channel
+func connectStreamableTransport(t *testing.T, serverURL string, headers map[string]string) (*StreamableHttpClientTransport, <-chan *McpJSONRPCMessage) {
+	t.Helper()
+	messages := make(chan *McpJSONRPCMessage, 16)
+	transport := NewStreamableHttpClientTransport(serverURL, "mcp", headers)
+	if err := transport.Connect(func(message *McpJSONRPCMessage) { messages <- message }, nil); err != nil {
+		t.Fatalf("Connect 失败: %v", err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return transport, messages
+}
+
+// receiveMcpMessage 等待传输层分发的消息
+func receiveMcpMessage(t *testing.T, messages <-chan *McpJSONRPCMessage) *McpJSONRPCMessage {
+	t.Helper()
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到MCP消息")
+		return nil
+	}
+}
+
+// pingRequest id 为 id 的 ping 请求
+func pingRequest(id int) *McpJSONRPCMessage {
+	return &McpJSONRPCMessage{JSONRPC: "2.0", ID: json.RawMessage(fmt.Sprint(id)), Method: "ping"}
+}
+
+func TestStreamableHttpTransportSessionHeaders(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers []http.Header
+		deleted string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mcp" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodDelete {
+			deleted = r.Header.Get(McpSessionIdHeader)
+			return
+		}
+		headers = append(headers, r.Header.Clone())
+
+		var message McpJSONRPCMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		if len(headers) == 1 {
+			w.Header().Set(McpSessionIdHeader, "session-1")
+		}
+		writeJSONRPCResult(w, message.ID, map[string]any{})
+	}))
+	defer server.Close()
+
+	transport, messages := connectStreamableTransport(t, server.URL, map[string]string{"Authorization": "Bearer token"})
+	ctx := context.Background()
+
+	if err := transport.SendMessage(ctx, pingRequest(1)); err != nil {
+		t.Fatalf("第一次请求失败: %v", err)
+	}
+	if message := receiveMcpMessage(t, messages); string(message.ID) != "1" {
+		t.Errorf("响应ID = %s, 期望 1", message.ID)
+	}
+	if transport.SessionID() != "session-1" {
+		t.Fatalf("会话ID = %q, 期望 session-1", transport.SessionID())
+	}
+
+	transport.SetProtocolVersion("2025-03-26")
+	if err := transport.SendMessage(ctx, pingRequest(2)); err != nil {
+		t.Fatalf("第二次请求失败: %v", err)
+	}
+	receiveMcpMessage(t, messages)
+
+	mu.Lock()
+	first, second := headers[0], headers[1]
+	mu.Unlock()
+	if first.Get(McpSessionIdHeader) != "" || first.Get(McpProtocolVersionHeader) != "" {
+		t.Errorf("初始化前不应携带会话头: %v", first)
+	}
+	if first.Get("Accept") != "application/json, text/event-stream" || first.Get("Authorization") != "Bearer token" {
+		t.Errorf("请求头 = %v", first)
+	}
+	if second.Get(McpSessionIdHeader) != "session-1" || second.Get(McpProtocolVersionHeader) != "2025-03-26" {
+		t.Errorf("后续请求应回传会话ID和协议版本: %v", second)
+	}
+
+	// 关闭时发送 DELETE 终止会话
+	transport.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if deleted != "session-1" {
+		t.Errorf("DELETE 会话ID = %q, 期望 session-1", deleted)
+	}
+}
+
+func TestStreamableHttpTransportSessionExpired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var message McpJSONRPCMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		if message.Method == McpMethodInitialize {
+			w.Header().Set(McpSessionIdHeader, "session-1")
+			writeJSONRPCResult(w, message.ID, map[string]any{})
+			return
+		}
+		http.Error(w, "session not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	// 未建立会话时 404 是普通错误
+	transport, messages := connectStreamableTransport(t, server.URL, nil)
+	err := transport.SendMessage(context.Background(), pingRequest(1))
+	if err == nil || errors.Is(err, ErrMcpSessionExpired) {
+		t.Fatalf("无会话时 404 错误 = %v, 期望普通错误", err)
+	}
+
+	initialize := &McpJSONRPCMessage{JSONRPC: "2.0", ID: json.RawMessage("2"), Method: McpMethodInitialize}
+	if err := transport.SendMessage(context.Background(), initialize); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	receiveMcpMessage(t, messages)
+
+	// 携带会话时 404 表示会话失效，清除会话ID以便重新初始化
+	if err := transport.SendMessage(context.Background(), pingRequest(3)); !errors.Is(err, ErrMcpSessionExpired) {
+		t.Fatalf("会话失效错误 = %v, 期望 ErrMcpSessionExpired", err)
+	}
+	if transport.SessionID() != "" {
+		t.Errorf("会话失效后会话ID = %q, 期望清空", transport.SessionID())
+	}
+}
+
+func TestStreamableHttpTransportEventStreamResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var message McpJSONRPCMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+
+		// 升级为 SSE 流：先推送服务端请求，再返回响应，非 message 事件忽略
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		fmt.Fprint(w, "event: ping\ndata: {\"ignored\":true}\n\n")
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":\"s-1\",\"method\":\"roots/list\"}\n\n")
+		w.(http.Flusher).Flush()
+		fmt.Fprintf(w, "event: message\ndata: [{\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"},\n")
+		fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"id\":%s,\"result\":{}}]\n\n", message.ID)
+	}))
+	defer server.Close()
+
+	transport, messages := connectStreamableTransport(t, server.URL, nil)
+	if err := transport.SendMessage(context.Background(), pingRequest(7)); err != nil {
+		t.Fatalf("SendMessage 失败: %v", err)
+	}
+
+	if message := receiveMcpMessage(t, messages); message.Method != "roots/list" || string(message.ID) != `"s-1"` {
+		t.Errorf("第一条消息 = %+v, 期望服务端请求 roots/list", message)
+	}
+	if message := receiveMcpMessage(t, messages); message.Method != "notifications/progress" {
+		t.Errorf("第二条消息 = %+v, 期望批量中的通知", message)
+	}
+	if message := receiveMcpMessage(t, messages); string(message.ID) != "7" || message.Result == nil {
+		t.Errorf("第三条消息 = %+v, 期望 id=7 的响应", message)
+	}
+	select {
+	case message := <-messages:
+		t.Errorf("非 message 事件不应分发: %+v", message)
+	default:
+	}
+}
+
+func TestStreamableHttpTransportStaleSessionExpiryKeepsNewSession(t *testing.T) {
+	staleReceived := make(chan struct{})
+	releaseStale := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var message McpJSONRPCMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		switch {
+		case message.Method == McpMethodInitialize && r.Header.Get(McpSessionIdHeader) == "":
+			w.Header().Set(McpSessionIdHeader, "session-1")
+			writeJSONRPCResult(w, message.ID, map[string]any{})
+		case message.Method == McpMethodInitialize:
+			w.Header().Set(McpSessionIdHeader, "session-2")
+			writeJSONRPCResult(w, message.ID, map[string]any{})
+		case r.Header.Get(McpSessionIdHeader) == "session-1":
+			// 旧会话的请求在新会话建立后才返回 404
+			close(staleReceived)
+			<-releaseStale
+			http.Error(w, "session not found", http.StatusNotFound)
+		default:
+			writeJSONRPCResult(w, message.ID, map[string]any{})
+		}
+	}))
+	defer server.Close()
+
+	transport, messages := connectStreamableTransport(t, server.URL, nil)
+	initialize := func(id int) {
+		t.Helper()
+		message := &McpJSONRPCMessage{JSONRPC: "2.0", ID: json.RawMessage(fmt.Sprint(id)), Method: McpMethodInitialize}
+		if err := transport.SendMessage(context.Background(), message); err != nil {
+			t.Fatalf("初始化失败: %v", err)
+		}
+		receiveMcpMessage(t, messages)
+	}
+	initialize(1)
+
+	staleErr := make(chan error, 1)
+	go func() {
+		staleErr <- transport.SendMessage(context.Background(), pingRequest(2))
+	}()
+	<-staleReceived
+	initialize(3)
+	if transport.SessionID() != "session-2" {
+		t.Fatalf("重新初始化后会话ID = %q, 期望 session-2", transport.SessionID())
+	}
+
+	close(releaseStale)
+	if err := <-staleErr; !errors.Is(err, ErrMcpSessionExpired) {
+		t.Fatalf("旧会话请求错误 = %v, 期望 ErrMcpSessionExpired", err)
+	}
+	if transport.SessionID() != "session-2" {
+		t.Errorf("旧会话失效不应清除新会话ID, 实际 %q", transport.SessionID())
+	}
+}
@@ -0,0 +1,400 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// McpClientTransport MCP 客户端传输层（stdio / sse 等）
+type McpClientTransport interface {
+	// Connect 建立连接，收到的消息通过 onMessage 回调，连接断开时回调 onClose
+	Connect(onMessage func(message *McpJSONRPCMessage), onClose func(err error)) error
+	// SendMessage 发送一条 JSON-RPC 消息
+	SendMessage(ctx context.Context, message *McpJSONRPCMessage) error
+	// Close 关闭连接并释放资源
+	Close() error
+}
+
+// ErrMcpClientClosed MCP 客户端已关闭
+var ErrMcpClientClosed = errors.New("MCP客户端已关闭")
+
+// defaultMcpClientInfo 客户端实现信息
+var defaultMcpClientInfo = McpImplementation{
+	Name:    "smart-weaver",
+	Version: "1.0.0",
+}
+
+// DefaultMcpSyncClient 基于 JSON-RPC 2.0 的 MCP 同步客户端
+type DefaultMcpSyncClient struct {
+	name         string
+	transport    McpClientTransport
+	clientInfo   McpImplementation
+	capabilities McpClientCapabilities
+
+	reinitMu       sync.Mutex // 串行执行会话失效后的重新初始化
+	mu             sync.Mutex
+	sessionGen     int64 // 每次完成初始化加一，用于合并并发请求触发的重新初始化
+	requestTimeout time.Duration
+	nextID         int64
+	pending        map[string]chan *McpJSONRPCMessage
+	connected      bool
+	closed         bool
+	closeErr       error
+	initResult     *McpInitializeResult
+}
+
+// NewDefaultMcpSyncClient 创建MCP同步客户端，name 为配置中的 MCP 名称
+func NewDefaultMcpSyncClient(name string, transport McpClientTransport, requestTimeout time.Duration) *DefaultMcpSyncClient {
+	return &DefaultMcpSyncClient{
+		name:           name,
+		transport:      transport,
+		clientInfo:     defaultMcpClientInfo,
+		requestTimeout: requestTimeout,
+		pending:        make(map[string]chan *McpJSONRPCMessage),
+	}
+}
+
+// Initialize 建立连接并完成 initialize / initialized 握手
+func (c *DefaultMcpSyncClient) Initialize() (*McpInitializeResult, error) {
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c.handshake()
+}
+
+// handshake 完成 initialize / initialized 握手并记录协商结果
+func (c *DefaultMcpSyncClient) handshake() (*McpInitializeResult, error) {
+	params := McpInitializeRequest{
+		ProtocolVersion: McpSupportedProtocolVersions[0],
+		Capabilities:    c.capabilities,
+		ClientInfo:      c.clientInfo,
+	}
+
+	var result McpInitializeResult
+	if err := c.SendRequest(context.Background(), McpMethodInitialize, params, &result); err != nil {
+		return nil, fmt.Errorf("MCP initialize 请求失败: %w", err)
+	}
+
+	// 协议版本协商：服务端返回的版本必须是客户端支持的版本之一
+	if !isSupportedMcpProtocolVersion(result.ProtocolVersion) {
+		return nil, fmt.Errorf("MCP服务端协议版本 %s 不受支持，客户端支持 %v", result.ProtocolVersion, McpSupportedProtocolVersions)
+	}
+
+	if aware, ok := c.transport.(interface{ SetProtocolVersion(version string) }); ok {
+		aware.SetProtocolVersion(result.ProtocolVersion)
+	}
+
+	if err := c.SendNotification(context.Background(), McpMethodNotificationInitialized, nil); err != nil {
+		return nil, fmt.Errorf("MCP initialized 通知发送失败: %w", err)
+	}
+
+	c.mu.Lock()
+	c.initResult = &result
+	c.sessionGen++
+	c.mu.Unlock()
+
+	return &result, nil
+}
+
+// reinitialize 会话失效后重新握手，gen 为请求发送前的会话代数，其他请求已完成重新初始化时直接返回
+func (c *DefaultMcpSyncClient) reinitialize(gen int64) error {
+	c.reinitMu.Lock()
+	defer c.reinitMu.Unlock()
+
+	c.mu.Lock()
+	current := c.sessionGen
+	c.mu.Unlock()
+	if current != gen {
+		return nil
+	}
+
+	log.Printf("MCP会话已失效，重新初始化 name=%s", c.name)
+	if _, err := c.handshake(); err != nil {
+		return fmt.Errorf("MCP会话失效后重新初始化失败: %w", err)
+	}
+	return nil
+}
+
+// SetRequestTimeout 设置单次请求超时时间，<=0 表示不限制
+func (c *DefaultMcpSyncClient) SetRequestTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestTimeout = timeout
+}
+
+// GetServerCapabilities 获取协商后的服务端能力，未初始化时返回 nil
+func (c *DefaultMcpSyncClient) GetServerCapabilities() *McpServerCapabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.initResult == nil {
+		return nil
+	}
+	return &c.initResult.Capabilities
+}
+
+// GetServerInfo 获取服务端实现信息，未初始化时返回 nil
+func (c *DefaultMcpSyncClient) GetServerInfo() *McpImplementation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.initResult == nil {
+		return nil
+	}
+	return &c.initResult.ServerInfo
+}
+
+// Name 获取MCP名称
+func (c *DefaultMcpSyncClient) Name() string {
+	return c.name
+}
+
+// ListTools 分页查询服务端工具，cursor 为空表示第一页
+func (c *DefaultMcpSyncClient) ListTools(ctx context.Context, cursor string) (*McpListToolsResult, error) {
+	if capabilities := c.GetServerCapabilities(); capabilities != nil && capabilities.Tools == nil {
+		return &McpListToolsResult{}, nil
+	}
+
+	var result McpListToolsResult
+	if err := c.SendRequest(ctx, McpMethodToolsList, McpListToolsRequest{Cursor: cursor}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CallTool 调用服务端工具
+func (c *DefaultMcpSyncClient) CallTool(ctx context.Context, request McpCallToolRequest) (*McpCallToolResult, error) {
+	var result McpCallToolResult
+	if err := c.SendRequest(ctx, McpMethodToolsCall, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Ping 检测服务端存活
+func (c *DefaultMcpSyncClient) Ping(ctx context.Context) error {
+	return c.SendRequest(ctx, McpMethodPing, nil, nil)
+}
+
+// Close 关闭客户端及其传输层
+func (c *DefaultMcpSyncClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.closeErr = ErrMcpClientClosed
+	c.mu.Unlock()
+
+	c.failPending()
+	return c.transport.Close()
+}
+
+// SendRequest 发送请求并同步等待响应，result 为 nil 时忽略响应内容
+func (c *DefaultMcpSyncClient) SendRequest(ctx context.Context, method string, params any, result any) error {
+	c.mu.Lock()
+	if c.closed {
+		err := c.closeErr
+		c.mu.Unlock()
+		return err
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	respCh := make(chan *McpJSONRPCMessage, 1)
+	c.pending[id] = respCh
+	timeout := c.requestTimeout
+	sessionGen := c.sessionGen
+	c.mu.Unlock()
+
+	defer c.removePending(id)
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	message := &McpJSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(id),
+		Method:  method,
+	}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("序列化请求参数失败: %w", err)
+		}
+		message.Params = raw
+	}
+
+	// 会话失效时重新初始化一次并重发，initialize 请求本身不重试
+	err := c.transport.SendMessage(ctx, message)
+	if errors.Is(err, ErrMcpSessionExpired) && method != McpMethodInitialize {
+		if err = c.reinitialize(sessionGen); err == nil {
+			err = c.transport.SendMessage(ctx, message)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("发送MCP请求 %s 失败: %w", method, err)
+	}
+
+	select {
+	case resp, ok := <-respCh:
+		if !ok {
+			c.mu.Lock()
+			err := c.closeErr
+			c.mu.Unlock()
+			return err
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("解析MCP响应 %s 失败: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.cancelRequest(message.ID, ctx.Err())
+		return fmt.Errorf("MCP请求 %s 超时或被取消: %w", method, ctx.Err())
+	}
+}
+
+// SendNotification 发送通知（无需响应）
+func (c *DefaultMcpSyncClient) SendNotification(ctx context.Context, method string, params any) error {
+	message := &McpJSONRPCMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+	}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("序列化通知参数失败: %w", err)
+		}
+		message.Params = raw
+	}
+	return c.transport.SendMessage(ctx, message)
+}
+
+// connect 建立传输层连接（只执行一次）
+func (c *DefaultMcpSyncClient) connect() error {
+	c.mu.Lock()
+	if c.closed {
+		err := c.closeErr
+		c.mu.Unlock()
+		return err
+	}
+	if c.connected {
+		c.mu.Unlock()
+		return nil
+	}
+	c.connected = true
+	c.mu.Unlock()
+
+	if err := c.transport.Connect(c.handleMessage, c.handleClose); err != nil {
+		c.mu.Lock()
+		c.connected = false
+		c.mu.Unlock()
+		return fmt.Errorf("MCP传输层连接失败: %w", err)
+	}
+	return nil
+}
+
+// cancelRequest 通知服务端取消请求，尽力而为
+func (c *DefaultMcpSyncClient) cancelRequest(id json.RawMessage, reason error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = c.SendNotification(ctx, McpMethodNotificationCancelled, McpCancelledNotification{
+		RequestID: id,
+		Reason:    reason.Error(),
+	})
+}
+
+// handleMessage 处理传输层收到的消息
+func (c *DefaultMcpSyncClient) handleMessage(message *McpJSONRPCMessage) {
+	switch {
+	case message.IsResponse():
+		c.mu.Lock()
+		respCh, ok := c.pending[string(message.ID)]
+		if ok {
+			delete(c.pending, string(message.ID))
+		}
+		c.mu.Unlock()
+		if !ok {
+			log.Printf("收到未知请求ID的MCP响应: %s", string(message.ID))
+			return
+		}
+		respCh <- message
+	case message.IsRequest():
+		go c.handleServerRequest(message)
+	case message.IsNotification():
+		log.Printf("收到MCP通知: %s %s", message.Method, string(message.Params))
+	default:
+		log.Printf("收到无法识别的MCP消息: %+v", message)
+	}
+}
+
+// handleServerRequest 响应服务端发起的请求
+func (c *DefaultMcpSyncClient) handleServerRequest(message *McpJSONRPCMessage) {
+	response := &McpJSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      message.ID,
+	}
+
+	switch message.Method {
+	case McpMethodPing:
+		response.Result = json.RawMessage("{}")
+	default:
+		response.Error = &McpJSONRPCError{
+			Code:    JSONRPCMethodNotFound,
+			Message: "Method not found: " + message.Method,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.transport.SendMessage(ctx, response); err != nil {
+		log.Printf("响应MCP服务端请求 %s 失败: %v", message.Method, err)
+	}
+}
+
+// handleClose 传输层断开时，结束所有等待中的请求
+func (c *DefaultMcpSyncClient) handleClose(err error) {
+	if err == nil {
+		err = errors.New("MCP传输层连接已断开")
+	} else {
+		err = fmt.Errorf("MCP传输层连接已断开: %w", err)
+	}
+
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.closeErr = err
+	}
+	c.mu.Unlock()
+
+	c.failPending()
+}
+
+// failPending 关闭所有等待中的请求
+func (c *DefaultMcpSyncClient) failPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, respCh := range c.pending {
+		close(respCh)
+		delete(c.pending, id)
+	}
+}
+
+// removePending 移除等待中的请求
+func (c *DefaultMcpSyncClient) removePending(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
@@ -0,0 +1,287 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	fakeMcpServerOnce sync.Once
+	fakeMcpServerDir  string
+	fakeMcpServerPath string
+	fakeMcpServerErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if fakeMcpServerDir != "" {
+		_ = os.RemoveAll(fakeMcpServerDir)
+	}
+	os.Exit(code)
+}
+
+// buildFakeMcpServer 编译 testdata 中的 stdio MCP 服务端，同一次测试只编译一次
+func buildFakeMcpServer(t *testing.T) string {
+	t.Helper()
+	fakeMcpServerOnce.Do(func() {
+		fakeMcpServerDir, fakeMcpServerErr = os.MkdirTemp("", "fakemcpserver")
+		if fakeMcpServerErr != nil {
+			return
+		}
+		fakeMcpServerPath = filepath.Join(fakeMcpServerDir, "fakemcpserver")
+		out, err := exec.Command("go", "build", "-o", fakeMcpServerPath, "./testdata/fakemcpserver").CombinedOutput()
+		if err != nil {
+			fakeMcpServerErr = errors.New(string(out))
+		}
+	})
+	if fakeMcpServerErr != nil {
+		t.Fatalf("编译 fake MCP 服务端失败: %v", fakeMcpServerErr)
+	}
+	return fakeMcpServerPath
+}
+
+// newFakeMcpClient 启动 fake MCP 服务端并创建客户端，测试结束时关闭
+func newFakeMcpClient(t *testing.T, env map[string]string) *DefaultMcpSyncClient {
+	t.Helper()
+	transport := NewStdioClientTransport(&ServerParameters{Command: buildFakeMcpServer(t), Env: env})
+	client := NewDefaultMcpSyncClient("fake", transport, 5*time.Second)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestMcpSyncClientInitializeHandshake(t *testing.T) {
+	client := newFakeMcpClient(t, nil)
+
+	result, err := client.Initialize()
+	if err != nil {
+		t.Fatalf("Initialize 失败: %v", err)
+	}
+	if result.ProtocolVersion != McpSupportedProtocolVersions[0] {
+		t.Errorf("协议版本 = %s, 期望 %s", result.ProtocolVersion, McpSupportedProtocolVersions[0])
+	}
+	if info := client.GetServerInfo(); info == nil || info.Name != "fake-mcp-server" {
+		t.Errorf("服务端信息 = %+v", info)
+	}
+
+	// 服务端收到 initialized 通知后才允许调用工具
+	tools, err := client.ListTools(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListTools 失败: %v", err)
+	}
+	if len(tools.Tools) != 3 {
+		t.Errorf("工具数量 = %d, 期望 3", len(tools.Tools))
+	}
+
+	callResult, err := client.CallTool(context.Background(), McpCallToolRequest{Name: "echo", Arguments: map[string]any{"text": "hello"}})
+	if err != nil {
+		t.Fatalf("CallTool 失败: %v", err)
+	}
+	if len(callResult.Content) != 1 || callResult.Content[0].Text != "hello" {
+		t.Errorf("工具结果 = %+v", callResult.Content)
+	}
+}
+
+func TestMcpSyncClientProtocolVersionNegotiation(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverVersion string
+		wantErr       bool
+	}{
+		{name: "服务端返回较旧的受支持版本", serverVersion: McpProtocolVersion20241105},
+		{name: "服务端返回不支持的版本", serverVersion: "1999-01-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeMcpClient(t, map[string]string{"FAKE_MCP_PROTOCOL_VERSION": tt.serverVersion})
+
+			result, err := client.Initialize()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.serverVersion) {
+					t.Fatalf("期望协议版本错误, 实际 %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Initialize 失败: %v", err)
+			}
+			if result.ProtocolVersion != tt.serverVersion {
+				t.Errorf("协商版本 = %s, 期望 %s", result.ProtocolVersion, tt.serverVersion)
+			}
+		})
+	}
+}
+
+func TestMcpSyncClientRequestTimeout(t *testing.T) {
+	client := newFakeMcpClient(t, nil)
+	if _, err := client.Initialize(); err != nil {
+		t.Fatalf("Initialize 失败: %v", err)
+	}
+
+	client.SetRequestTimeout(100 * time.Millisecond)
+	start := time.Now()
+	_, err := client.CallTool(context.Background(), McpCallToolRequest{Name: "sleep", Arguments: map[string]any{"ms": 2000}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望超时错误, 实际 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("超时后等待了 %s", elapsed)
+	}
+
+	// 超时的请求不影响后续请求
+	client.SetRequestTimeout(5 * time.Second)
+	if _, err := client.CallTool(context.Background(), McpCallToolRequest{Name: "echo", Arguments: map[string]any{"text": "ok"}}); err != nil {
+		t.Fatalf("超时后再次调用失败: %v", err)
+	}
+}
+
+func TestMcpSyncClientPendingFailOnProcessExit(t *testing.T) {
+	client := newFakeMcpClient(t, nil)
+	client.SetRequestTimeout(0)
+	if _, err := client.Initialize(); err != nil {
+		t.Fatalf("Initialize 失败: %v", err)
+	}
+
+	// 先发出一个长时间等待的请求，进程退出后应立即失败
+	pending := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(context.Background(), McpCallToolRequest{Name: "sleep", Arguments: map[string]any{"ms": 30000}})
+		pending <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := client.CallTool(context.Background(), McpCallToolRequest{Name: "exit"}); err == nil {
+		t.Fatal("进程退出后请求应失败")
+	}
+	select {
+	case err := <-pending:
+		if err == nil || !strings.Contains(err.Error(), "连接已断开") {
+			t.Fatalf("等待中的请求错误 = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("进程退出后等待中的请求未失败")
+	}
+
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("进程退出后 Ping 应失败")
+	}
+}
+
+// fakeStreamableMcpServer 以 JSON 响应的 Streamable HTTP MCP 服务端，expire 使当前会话失效
+type fakeStreamableMcpServer struct {
+	mu          sync.Mutex
+	sessions    int
+	session     string
+	initialized bool
+}
+
+func (s *fakeStreamableMcpServer) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = ""
+	s.initialized = false
+}
+
+func (s *fakeStreamableMcpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	var message McpJSONRPCMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if message.Method == McpMethodInitialize {
+		s.sessions++
+		s.session = fmt.Sprintf("session-%d", s.sessions)
+		s.initialized = false
+		w.Header().Set(McpSessionIdHeader, s.session)
+		writeJSONRPCResult(w, message.ID, map[string]any{
+			"protocolVersion": McpSupportedProtocolVersions[0],
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      McpImplementation{Name: "fake-streamable", Version: "1.0.0"},
+		})
+		return
+	}
+	if r.Header.Get(McpSessionIdHeader) != s.session || s.session == "" {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	switch message.Method {
+	case McpMethodNotificationInitialized:
+		s.initialized = true
+		w.WriteHeader(http.StatusAccepted)
+	case McpMethodToolsList:
+		if !s.initialized {
+			http.Error(w, "not initialized", http.StatusBadRequest)
+			return
+		}
+		writeJSONRPCResult(w, message.ID, McpListToolsResult{Tools: []McpTool{{Name: "echo"}}})
+	default:
+		http.Error(w, "unexpected method "+message.Method, http.StatusBadRequest)
+	}
+}
+
+// writeJSONRPCResult 输出 JSON-RPC 成功响应
+func writeJSONRPCResult(w http.ResponseWriter, id json.RawMessage, result any) {
+	raw, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&McpJSONRPCMessage{JSONRPC: "2.0", ID: id, Result: raw})
+}
+
+func TestMcpSyncClientReinitializeOnSessionExpired(t *testing.T) {
+	server := &fakeStreamableMcpServer{}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	transport := NewStreamableHttpClientTransport(httpServer.URL, "/mcp", nil)
+	client := NewDefaultMcpSyncClient("streamable", transport, 5*time.Second)
+	defer client.Close()
+	if _, err := client.Initialize(); err != nil {
+		t.Fatalf("Initialize 失败: %v", err)
+	}
+
+	// 会话失效后并发请求只重新初始化一次
+	server.expire()
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ListTools(context.Background(), "")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("会话失效后请求失败: %v", err)
+		}
+	}
+
+	server.mu.Lock()
+	sessions := server.sessions
+	server.mu.Unlock()
+	if sessions != 2 {
+		t.Errorf("初始化次数 = %d, 期望 2", sessions)
+	}
+	if transport.SessionID() != "session-2" {
+		t.Errorf("会话ID = %s, 期望 session-2", transport.SessionID())
+	}
+}
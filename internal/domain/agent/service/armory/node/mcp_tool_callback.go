@@ -0,0 +1,241 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxToolNameLength 模型侧工具名称最大长度
+	maxToolNameLength = 64
+	// maxToolListPages tools/list 分页上限，防止服务端游标异常导致死循环
+	maxToolListPages = 100
+)
+
+// invalidToolNameChars 模型侧工具名称只允许字母、数字、下划线和中划线
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ToolDefinition 工具定义（模拟Java中的ToolDefinition）
+type ToolDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// ToolCallback 工具回调（模拟Java中的ToolCallback）
+type ToolCallback interface {
+	// GetToolDefinition 获取提供给模型的工具定义
+	GetToolDefinition() ToolDefinition
+	// Call 执行工具，toolInput 为模型生成的 JSON 参数
+	Call(ctx context.Context, toolInput string) (string, error)
+}
+
+// SyncMcpToolCallback 基于 MCP 工具的回调（模拟Java中的SyncMcpToolCallback）
+type SyncMcpToolCallback struct {
+	mcpClient McpSyncClient
+	tool      McpTool
+	name      string
+}
+
+// NewSyncMcpToolCallback 创建MCP工具回调，name 为暴露给模型的工具名称
+func NewSyncMcpToolCallback(mcpClient McpSyncClient, tool McpTool, name string) *SyncMcpToolCallback {
+	return &SyncMcpToolCallback{
+		mcpClient: mcpClient,
+		tool:      tool,
+		name:      name,
+	}
+}
+
+// GetToolDefinition 获取工具定义
+func (callback *SyncMcpToolCallback) GetToolDefinition() ToolDefinition {
+	inputSchema := callback.tool.InputSchema
+	if len(inputSchema) == 0 {
+		inputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	return ToolDefinition{
+		Name:        callback.name,
+		Description: callback.tool.Description,
+		InputSchema: inputSchema,
+	}
+}
+
+// GetMcpClient 获取所属MCP客户端
+func (callback *SyncMcpToolCallback) GetMcpClient() McpSyncClient {
+	return callback.mcpClient
+}
+
+// Call 通过 tools/call 调用 MCP 服务端工具
+func (callback *SyncMcpToolCallback) Call(ctx context.Context, toolInput string) (string, error) {
+	arguments := make(map[string]any)
+	if strings.TrimSpace(toolInput) != "" {
+		if err := json.Unmarshal([]byte(toolInput), &arguments); err != nil {
+			return "", fmt.Errorf("工具 %s 参数不是合法的JSON对象: %w", callback.name, err)
+		}
+	}
+
+	result, err := callback.mcpClient.CallTool(ctx, McpCallToolRequest{
+		Name:      callback.tool.Name,
+		Arguments: arguments,
+	})
+	if err != nil {
+		return "", fmt.Errorf("调用MCP工具 %s 失败: %w", callback.name, err)
+	}
+
+	output := mcpContentToText(result)
+	if result.IsError {
+		return output, fmt.Errorf("MCP工具 %s 返回错误: %s", callback.name, output)
+	}
+	return output, nil
+}
+
+// mcpContentToText 将工具返回内容转为文本，非文本内容以 JSON 形式保留
+func mcpContentToText(result *McpCallToolResult) string {
+	if len(result.Content) == 0 && len(result.StructuredContent) > 0 {
+		return string(result.StructuredContent)
+	}
+
+	parts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			parts = append(parts, content.Text)
+			continue
+		}
+		raw, err := json.Marshal(content)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, string(raw))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ToolNamePrefixStrategy 多个MCP服务存在同名工具时的命名策略
+type ToolNamePrefixStrategy string
+
+const (
+	// ToolNamePrefixOnConflict 仅对冲突的工具名称加 MCP 名称前缀（默认）
+	ToolNamePrefixOnConflict ToolNamePrefixStrategy = "on_conflict"
+	// ToolNamePrefixAlways 所有工具名称都加 MCP 名称前缀
+	ToolNamePrefixAlways ToolNamePrefixStrategy = "always"
+	// ToolNamePrefixNever 不加前缀，冲突时保留先注册的工具
+	ToolNamePrefixNever ToolNamePrefixStrategy = "never"
+)
+
+// ParseToolNamePrefixStrategy 解析配置中的工具命名策略，为空时为 on_conflict
+func ParseToolNamePrefixStrategy(value string) (ToolNamePrefixStrategy, error) {
+	switch strategy := ToolNamePrefixStrategy(strings.ToLower(strings.TrimSpace(value))); strategy {
+	case "":
+		return ToolNamePrefixOnConflict, nil
+	case ToolNamePrefixOnConflict, ToolNamePrefixAlways, ToolNamePrefixNever:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("不支持的工具命名策略 %s，可选 on_conflict / always / never", value)
+	}
+}
+
+// SyncMcpToolCallbackProvider 同步MCP工具回调提供者（模拟Java中的SyncMcpToolCallbackProvider）
+type SyncMcpToolCallbackProvider struct {
+	McpSyncClients []McpSyncClient
+	PrefixStrategy ToolNamePrefixStrategy
+}
+
+// NewSyncMcpToolCallbackProvider 创建同步MCP工具回调提供者
+func NewSyncMcpToolCallbackProvider(mcpSyncClients []McpSyncClient) *SyncMcpToolCallbackProvider {
+	return &SyncMcpToolCallbackProvider{
+		McpSyncClients: mcpSyncClients,
+		PrefixStrategy: ToolNamePrefixOnConflict,
+	}
+}
+
+// WithPrefixStrategy 设置工具命名策略
+func (provider *SyncMcpToolCallbackProvider) WithPrefixStrategy(strategy ToolNamePrefixStrategy) *SyncMcpToolCallbackProvider {
+	provider.PrefixStrategy = strategy
+	return provider
+}
+
+// GetToolCallbacks 查询所有MCP服务的工具并生成回调，单个服务查询失败时跳过该服务
+func (provider *SyncMcpToolCallbackProvider) GetToolCallbacks() []ToolCallback {
+	type clientTools struct {
+		client McpSyncClient
+		tools  []McpTool
+	}
+
+	var all []clientTools
+	nameCount := make(map[string]int) // 工具名称 -> 提供该工具的 MCP 服务数量
+	for _, client := range provider.McpSyncClients {
+		tools, err := listAllMcpTools(context.Background(), client)
+		if err != nil {
+			log.Printf("查询MCP %s 工具列表失败: %v", client.Name(), err)
+			continue
+		}
+		seen := make(map[string]bool, len(tools))
+		for _, tool := range tools {
+			if !seen[tool.Name] {
+				seen[tool.Name] = true
+				nameCount[tool.Name]++
+			}
+		}
+		all = append(all, clientTools{client: client, tools: tools})
+	}
+
+	var callbacks []ToolCallback
+	registered := make(map[string]string)
+	for _, ct := range all {
+		for _, tool := range ct.tools {
+			name := tool.Name
+			switch provider.PrefixStrategy {
+			case ToolNamePrefixAlways:
+				name = ct.client.Name() + "_" + name
+			case ToolNamePrefixNever:
+			default:
+				if nameCount[tool.Name] > 1 {
+					name = ct.client.Name() + "_" + name
+				}
+			}
+			name = sanitizeToolName(name)
+
+			if owner, exists := registered[name]; exists {
+				log.Printf("警告: MCP %s 的工具 %s 与 MCP %s 冲突，已忽略", ct.client.Name(), name, owner)
+				continue
+			}
+			registered[name] = ct.client.Name()
+			callbacks = append(callbacks, NewSyncMcpToolCallback(ct.client, tool, name))
+		}
+	}
+	return callbacks
+}
+
+// listAllMcpTools 按游标翻页查询全部工具
+func listAllMcpTools(ctx context.Context, client McpSyncClient) ([]McpTool, error) {
+	var tools []McpTool
+	seen := make(map[string]bool)
+	cursor := ""
+	for page := 0; page < maxToolListPages; page++ {
+		result, err := client.ListTools(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+
+		if result.NextCursor == "" || seen[result.NextCursor] {
+			return tools, nil
+		}
+		seen[result.NextCursor] = true
+		cursor = result.NextCursor
+	}
+	log.Printf("警告: MCP %s 工具列表分页超过 %d 页，已截断", client.Name(), maxToolListPages)
+	return tools, nil
+}
+
+// sanitizeToolName 规范化工具名称以满足模型侧命名限制
+func sanitizeToolName(name string) string {
+	name = invalidToolNameChars.ReplaceAllString(name, "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
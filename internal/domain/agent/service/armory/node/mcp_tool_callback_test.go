@@ -0,0 +1,84 @@
+package node
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeToolListMcpClient 只返回固定工具列表的 MCP 客户端
+type fakeToolListMcpClient struct {
+	name  string
+	tools []string
+}
+
+func (c *fakeToolListMcpClient) Name() string { return c.name }
+
+func (c *fakeToolListMcpClient) Initialize() (*McpInitializeResult, error) {
+	return &McpInitializeResult{}, nil
+}
+
+func (c *fakeToolListMcpClient) SetRequestTimeout(time.Duration) {}
+
+func (c *fakeToolListMcpClient) ListTools(context.Context, string) (*McpListToolsResult, error) {
+	result := &McpListToolsResult{}
+	for _, name := range c.tools {
+		result.Tools = append(result.Tools, McpTool{Name: name})
+	}
+	return result, nil
+}
+
+func (c *fakeToolListMcpClient) CallTool(context.Context, McpCallToolRequest) (*McpCallToolResult, error) {
+	return &McpCallToolResult{}, nil
+}
+
+func (c *fakeToolListMcpClient) Close() error { return nil }
+
+func TestSyncMcpToolCallbackProviderPrefixStrategy(t *testing.T) {
+	// weather 服务自身重复返回 forecast，不应视为与其他服务冲突
+	clients := []McpSyncClient{
+		&fakeToolListMcpClient{name: "weather", tools: []string{"search", "forecast", "forecast"}},
+		&fakeToolListMcpClient{name: "docs", tools: []string{"search", "read"}},
+	}
+
+	tests := []struct {
+		strategy string
+		want     []string
+	}{
+		{strategy: "", want: []string{"docs_search", "forecast", "read", "weather_search"}},
+		{strategy: "always", want: []string{"docs_read", "docs_search", "weather_forecast", "weather_search"}},
+		{strategy: "never", want: []string{"forecast", "read", "search"}},
+	}
+	for _, tt := range tests {
+		t.Run("strategy="+tt.strategy, func(t *testing.T) {
+			strategy, err := ParseToolNamePrefixStrategy(tt.strategy)
+			if err != nil {
+				t.Fatalf("ParseToolNamePrefixStrategy 失败: %v", err)
+			}
+
+			var names []string
+			for _, callback := range NewSyncMcpToolCallbackProvider(clients).WithPrefixStrategy(strategy).GetToolCallbacks() {
+				names = append(names, callback.GetToolDefinition().Name)
+			}
+			sort.Strings(names)
+			if len(names) != len(tt.want) {
+				t.Fatalf("工具名称 = %v, 期望 %v", names, tt.want)
+			}
+			for i := range tt.want {
+				if names[i] != tt.want[i] {
+					t.Fatalf("工具名称 = %v, 期望 %v", names, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseToolNamePrefixStrategyInvalid(t *testing.T) {
+	if _, err := ParseToolNamePrefixStrategy("sometimes"); err == nil {
+		t.Fatal("不支持的策略应返回错误")
+	}
+	if strategy, err := ParseToolNamePrefixStrategy(" Always "); err != nil || strategy != ToolNamePrefixAlways {
+		t.Errorf("策略 = %s, err = %v", strategy, err)
+	}
+}
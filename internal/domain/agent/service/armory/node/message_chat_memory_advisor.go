@@ -0,0 +1,252 @@
+package node
+
+import (
+	stdcontext "context"
+	"fmt"
+	"log"
+	"strings"
+
+	"smart-weaver/internal/config"
+)
+
+// AdviseContextConversationID 会话ID上下文键，类型 string，为空时使用默认会话
+const AdviseContextConversationID = "chat_memory_conversation_id"
+
+const (
+	// defaultConversationID 未指定会话ID时使用的会话
+	defaultConversationID = "default"
+	// defaultChatMemoryMaxMessages 默认保留的消息条数
+	defaultChatMemoryMaxMessages = 20
+	// messageTokenOverhead 每条消息角色等格式开销的估算 token 数
+	messageTokenOverhead = 4
+	// conversationSummaryPrefix 摘要消息前缀
+	conversationSummaryPrefix = "Summary of the earlier conversation:\n"
+)
+
+// conversationSummaryInstruction 摘要提示词
+const conversationSummaryInstruction = `Summarize the following conversation between a user and an assistant.
+Keep key facts, user preferences, decisions and any unfinished tasks.
+Be concise and write the summary in the same language as the conversation.`
+
+// MessageChatMemoryAdvisor 对话记忆顾问（模拟Java中的MessageChatMemoryAdvisor）
+// 按消息条数和 token 预算保留最近的对话窗口，开启摘要时被淘汰的消息由本次请求的模型压缩为一条摘要
+// 记忆中保存的是原始用户消息，应排在会改写用户消息的顾问（如知识库问答）之前执行
+type MessageChatMemoryAdvisor struct {
+	name        string
+	order       int
+	memory      ChatMemory
+	maxMessages int  // 不含摘要的最大消息条数
+	maxTokens   int  // 含摘要的 token 预算，0 表示不限制
+	summarize   bool // 是否对淘汰的消息生成摘要
+
+	// TokenEncoding 计算 token 预算使用的编码，为空时使用 cl100k_base
+	TokenEncoding string
+	// Namespace 存储键前缀，用于隔离不同客户端的同名会话
+	Namespace string
+}
+
+var _ FailureAwareAdvisor = (*MessageChatMemoryAdvisor)(nil)
+
+// NewMessageChatMemoryAdvisor 创建对话记忆顾问，maxMessages<=0 时使用默认值
+func NewMessageChatMemoryAdvisor(name string, order int, memory ChatMemory, maxMessages, maxTokens int, summarize bool) *MessageChatMemoryAdvisor {
+	if maxMessages <= 0 {
+		maxMessages = defaultChatMemoryMaxMessages
+	}
+	return &MessageChatMemoryAdvisor{
+		name:        name,
+		order:       order,
+		memory:      memory,
+		maxMessages: maxMessages,
+		maxTokens:   maxTokens,
+		summarize:   summarize,
+	}
+}
+
+// Name 顾问名称
+func (a *MessageChatMemoryAdvisor) Name() string {
+	return a.name
+}
+
+// Order 执行顺序
+func (a *MessageChatMemoryAdvisor) Order() int {
+	return a.order
+}
+
+// AdviseRequest 超出窗口的消息淘汰或摘要，并把保留的历史加入请求
+// 会话锁持有到本轮结束，本轮用户消息在响应阶段与助手回复一起写入，对话失败时不写入
+func (a *MessageChatMemoryAdvisor) AdviseRequest(ctx stdcontext.Context, request *AdvisedRequest) (*AdvisedRequest, error) {
+	conversationID := a.Namespace + conversationIDOf(request.AdviseContext)
+	unlock := a.memory.LockConversation(conversationID)
+
+	history, err := a.memory.Get(ctx, conversationID)
+	if err != nil {
+		unlock()
+		return nil, fmt.Errorf("顾问 %s 读取会话 %s 记忆失败: %w", a.name, conversationID, err)
+	}
+
+	turn := &chatMemoryTurn{conversationID: conversationID, userMessage: NewUserMessage(request.UserText), unlock: unlock}
+	kept, evicted := a.window(append(history, turn.userMessage))
+	if len(evicted) > 0 {
+		kept = a.compact(ctx, request.ChatModel, evicted, kept)
+		turn.replace = kept
+	}
+	request.AdviseContext[a.turnKey()] = turn
+
+	advised := *request
+	advised.Messages = append(append([]Message(nil), kept[:len(kept)-1]...), request.Messages...)
+	return &advised, nil
+}
+
+// AdviseResponse 一起写入本轮用户消息和助手回复并释放会话锁，回复为空时不写入
+func (a *MessageChatMemoryAdvisor) AdviseResponse(ctx stdcontext.Context, response *AdvisedResponse) (*AdvisedResponse, error) {
+	turn := a.takeTurn(response.AdviseContext)
+	if turn == nil {
+		return response, nil
+	}
+	defer turn.unlock()
+
+	text := response.Response.GetText()
+	if text == "" {
+		return response, nil
+	}
+
+	var err error
+	if turn.replace == nil {
+		err = a.memory.Add(ctx, turn.conversationID, turn.userMessage, NewAssistantMessage(text))
+	} else {
+		err = a.memory.Replace(ctx, turn.conversationID, append(turn.replace, NewAssistantMessage(text)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("顾问 %s 写入会话 %s 记忆失败: %w", a.name, turn.conversationID, err)
+	}
+	return response, nil
+}
+
+// AdviseError 对话失败时放弃本轮消息并释放会话锁
+func (a *MessageChatMemoryAdvisor) AdviseError(_ stdcontext.Context, adviseContext map[string]any, _ error) {
+	if turn := a.takeTurn(adviseContext); turn != nil {
+		turn.unlock()
+	}
+}
+
+// chatMemoryTurn 请求阶段到响应阶段之间待写入的一轮对话
+type chatMemoryTurn struct {
+	conversationID string
+	userMessage    Message
+	replace        []Message // 发生淘汰或摘要时替换后的会话消息（含本轮用户消息），为空表示直接追加
+	unlock         func()
+}
+
+// turnKey 本顾问待写入对话在上下文中的键，按顾问名称区分
+func (a *MessageChatMemoryAdvisor) turnKey() string {
+	return "chat_memory_turn:" + a.name
+}
+
+// takeTurn 取出并移除上下文中待写入的对话，保证会话锁只释放一次
+func (a *MessageChatMemoryAdvisor) takeTurn(adviseContext map[string]any) *chatMemoryTurn {
+	turn, _ := adviseContext[a.turnKey()].(*chatMemoryTurn)
+	delete(adviseContext, a.turnKey())
+	return turn
+}
+
+// window 按条数和 token 预算计算保留的消息，最后一条（本轮用户消息）始终保留
+// 返回的 evicted 包含旧摘要（如有）和被淘汰的消息，kept 不含旧摘要
+func (a *MessageChatMemoryAdvisor) window(messages []Message) (kept, evicted []Message) {
+	var summary []Message
+	if len(messages) > 0 && messages[0].Role == MessageRoleSystem {
+		summary, messages = messages[:1], messages[1:]
+	}
+
+	cut := 0
+	if len(messages) > a.maxMessages {
+		cut = len(messages) - a.maxMessages
+	}
+	if a.maxTokens > 0 {
+		total := a.countTokens(summary) + a.countTokens(messages[cut:])
+		for total > a.maxTokens && cut < len(messages)-1 {
+			total -= a.countTokens(messages[cut : cut+1])
+			cut++
+		}
+	}
+	// 按轮次淘汰，保留的历史从用户消息开始，避免孤立的助手或工具消息
+	for cut > 0 && cut < len(messages)-1 && messages[cut].Role != MessageRoleUser {
+		cut++
+	}
+
+	if cut == 0 {
+		return append(summary, messages...), nil
+	}
+	return messages[cut:], append(summary, messages[:cut]...)
+}
+
+// compact 开启摘要且有可用模型时，将淘汰的消息压缩为摘要放在保留消息之前；未生成新摘要时保留旧摘要
+func (a *MessageChatMemoryAdvisor) compact(ctx stdcontext.Context, chatModel ChatModel, evicted, kept []Message) []Message {
+	var previousSummary []Message
+	if evicted[0].Role == MessageRoleSystem {
+		previousSummary = evicted[:1]
+	}
+	if !a.summarize || chatModel == nil {
+		return append(append([]Message(nil), previousSummary...), kept...)
+	}
+
+	summary, err := summarizeConversation(ctx, chatModel, evicted)
+	if err != nil {
+		log.Printf("顾问 %s 生成对话摘要失败: %v", a.name, err)
+		return append(append([]Message(nil), previousSummary...), kept...)
+	}
+	return append([]Message{NewSystemMessage(conversationSummaryPrefix + summary)}, kept...)
+}
+
+// countTokens 估算消息 token 数
+func (a *MessageChatMemoryAdvisor) countTokens(messages []Message) int {
+	return estimateTokens(a.TokenEncoding, messages)
+}
+
+// estimateTokens 按指定编码估算消息 token 数，encoding 为空时使用 cl100k_base
+func estimateTokens(encoding string, messages []Message) int {
+	total := 0
+	for _, message := range messages {
+		tokens, err := config.CountTokens(encoding, message.Content)
+		if err != nil {
+			// 编码不可用时按字符数粗略估算
+			tokens = len([]rune(message.Content))
+		}
+		total += tokens + messageTokenOverhead
+	}
+	return total
+}
+
+// summarizeConversation 调用模型生成对话摘要，不携带工具
+func summarizeConversation(ctx stdcontext.Context, chatModel ChatModel, messages []Message) (string, error) {
+	var transcript strings.Builder
+	for _, message := range messages {
+		switch message.Role {
+		case MessageRoleSystem:
+			transcript.WriteString(strings.TrimPrefix(message.Content, conversationSummaryPrefix))
+		default:
+			transcript.WriteString(message.Role + ": " + message.Content)
+		}
+		transcript.WriteString("\n")
+	}
+
+	prompt := NewPrompt(NewSystemMessage(conversationSummaryInstruction), NewUserMessage(transcript.String()))
+	prompt.Options = &OpenAiChatOptions{ToolCallbacks: []ToolCallback{}}
+	response, err := chatModel.Call(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(response.GetText())
+	if summary == "" {
+		return "", fmt.Errorf("模型返回的摘要为空")
+	}
+	return summary, nil
+}
+
+// conversationIDOf 从上下文获取会话ID
+func conversationIDOf(adviseContext map[string]any) string {
+	if conversationID, ok := adviseContext[AdviseContextConversationID].(string); ok && conversationID != "" {
+		return conversationID
+	}
+	return defaultConversationID
+}
@@ -0,0 +1,243 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReplyChatModel 依次返回预设回复或错误，调用前等待 gate（为空时不等待）
+type fakeReplyChatModel struct {
+	mu      sync.Mutex
+	replies []error // nil 表示成功回复 "ok"
+	prompts []*Prompt
+	gate    chan struct{}
+}
+
+func (m *fakeReplyChatModel) Call(_ context.Context, prompt *Prompt) (*ChatResponse, error) {
+	if m.gate != nil {
+		<-m.gate
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, prompt)
+	var err error
+	if len(m.replies) > 0 {
+		err, m.replies = m.replies[0], m.replies[1:]
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ChatResponse{Generations: []Generation{{Message: NewAssistantMessage("ok")}}}, nil
+}
+
+func (m *fakeReplyChatModel) Stream(context.Context, *Prompt) (<-chan ChatStreamChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *fakeReplyChatModel) GetDefaultOptions() *OpenAiChatOptions { return nil }
+
+func TestMessageChatMemoryAdvisorSkipsFailedTurn(t *testing.T) {
+	memory := NewInMemoryChatMemory()
+	chatModel := &fakeReplyChatModel{replies: []error{errors.New("upstream failed"), nil}}
+	client := NewChatClientBuilder(chatModel).
+		DefaultAdvisors(NewMessageChatMemoryAdvisor("memory", 0, memory, 10, 0, false)).
+		Build()
+	request := &ChatClientRequest{AdviseContext: map[string]any{AdviseContextConversationID: "c1"}}
+
+	request.UserText = "first"
+	if _, err := client.Call(context.Background(), request); err == nil {
+		t.Fatal("模型失败时应返回错误")
+	}
+	messages, _ := memory.Get(context.Background(), "c1")
+	if len(messages) != 0 {
+		t.Fatalf("失败的对话不应写入记忆: %+v", messages)
+	}
+
+	// 失败后会话锁已释放，下一轮可以继续
+	request.UserText = "second"
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Call(context.Background(), request)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Call 失败: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("失败后会话锁未释放")
+	}
+
+	messages, _ = memory.Get(context.Background(), "c1")
+	if len(messages) != 2 || messages[0].Content != "second" || messages[1].Content != "ok" {
+		t.Errorf("记忆 = %+v, 期望只有第二轮的用户消息和回复", messages)
+	}
+}
+
+func TestMessageChatMemoryAdvisorSerializesTurns(t *testing.T) {
+	memory := NewInMemoryChatMemory()
+	chatModel := &fakeReplyChatModel{gate: make(chan struct{})}
+	client := NewChatClientBuilder(chatModel).
+		DefaultAdvisors(NewMessageChatMemoryAdvisor("memory", 0, memory, 10, 0, false)).
+		Build()
+
+	var wg sync.WaitGroup
+	for _, text := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(text string) {
+			defer wg.Done()
+			request := &ChatClientRequest{UserText: text, AdviseContext: map[string]any{AdviseContextConversationID: "c1"}}
+			if _, err := client.Call(context.Background(), request); err != nil {
+				t.Errorf("Call 失败: %v", err)
+			}
+		}(text)
+	}
+	chatModel.gate <- struct{}{}
+	chatModel.gate <- struct{}{}
+	wg.Wait()
+
+	// 第二轮在第一轮写入后才读取记忆，因此能看到完整的第一轮
+	if len(chatModel.prompts) != 2 || len(chatModel.prompts[1].Messages) != 3 {
+		t.Fatalf("第二轮提示词应包含第一轮的用户消息和回复: %+v", chatModel.prompts)
+	}
+	messages, _ := memory.Get(context.Background(), "c1")
+	if len(messages) != 4 {
+		t.Fatalf("记忆消息数 = %d, 期望 4", len(messages))
+	}
+	for i, message := range messages {
+		want := MessageRoleUser
+		if i%2 == 1 {
+			want = MessageRoleAssistant
+		}
+		if message.Role != want {
+			t.Errorf("记忆[%d] 角色 = %s, 期望 %s", i, message.Role, want)
+		}
+	}
+}
+
+func TestMessageChatMemoryAdvisorSharesLocksAcrossRebuilds(t *testing.T) {
+	memory := NewInMemoryChatMemory()
+	oldModel := &fakeReplyChatModel{gate: make(chan struct{})}
+	newModel := &fakeReplyChatModel{}
+	// 重新构建的客户端创建新的顾问实例，与旧客户端共享同一个记忆存储
+	oldClient := NewChatClientBuilder(oldModel).
+		DefaultAdvisors(NewMessageChatMemoryAdvisor("memory", 0, memory, 10, 0, false)).
+		Build()
+	newClient := NewChatClientBuilder(newModel).
+		DefaultAdvisors(NewMessageChatMemoryAdvisor("memory", 0, memory, 10, 0, false)).
+		Build()
+
+	oldDone := make(chan error, 1)
+	go func() {
+		_, err := oldClient.Call(context.Background(), &ChatClientRequest{UserText: "a", AdviseContext: map[string]any{AdviseContextConversationID: "c1"}})
+		oldDone <- err
+	}()
+	waitForLockedConversations(t, &memory.ConversationLocks, 1)
+
+	newDone := make(chan error, 1)
+	go func() {
+		_, err := newClient.Call(context.Background(), &ChatClientRequest{UserText: "b", AdviseContext: map[string]any{AdviseContextConversationID: "c1"}})
+		newDone <- err
+	}()
+	select {
+	case err := <-newDone:
+		t.Fatalf("旧客户端的对话未结束时新客户端不应开始同一会话: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	oldModel.gate <- struct{}{}
+	for _, done := range []chan error{oldDone, newDone} {
+		if err := <-done; err != nil {
+			t.Fatalf("Call 失败: %v", err)
+		}
+	}
+	if len(newModel.prompts) != 1 || len(newModel.prompts[0].Messages) != 3 {
+		t.Fatalf("新客户端的提示词应包含旧客户端写入的一轮: %+v", newModel.prompts)
+	}
+	if size := memory.size(); size != 0 {
+		t.Errorf("对话结束后锁表大小 = %d, 期望 0", size)
+	}
+}
+
+func TestConversationLocksRemovesReleasedEntries(t *testing.T) {
+	var locks ConversationLocks
+	for i := 0; i < 100; i++ {
+		locks.LockConversation(fmt.Sprintf("c%d", i))()
+	}
+	if size := locks.size(); size != 0 {
+		t.Fatalf("释放后锁表大小 = %d, 期望 0", size)
+	}
+
+	// 有等待者时保留会话锁，最后一个释放后删除
+	unlock := locks.LockConversation("c1")
+	acquired := make(chan func())
+	go func() { acquired <- locks.LockConversation("c1") }()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		locks.mu.Lock()
+		refs := locks.locks["c1"].refs
+		locks.mu.Unlock()
+		if refs == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("等待者未进入锁表")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	unlock()
+	second := <-acquired
+	if size := locks.size(); size != 1 {
+		t.Errorf("仍有持有者时锁表大小 = %d, 期望 1", size)
+	}
+	second()
+	if size := locks.size(); size != 0 {
+		t.Errorf("全部释放后锁表大小 = %d, 期望 0", size)
+	}
+}
+
+func TestMessageChatMemoryAdvisorKeepsSummaryWithoutModel(t *testing.T) {
+	ctx := context.Background()
+	memory := NewInMemoryChatMemory()
+	summary := NewSystemMessage(conversationSummaryPrefix + "用户住在杭州")
+	_ = memory.Add(ctx, "c1", summary,
+		NewUserMessage("u1"), NewAssistantMessage("a1"),
+		NewUserMessage("u2"), NewAssistantMessage("a2"))
+	advisor := NewMessageChatMemoryAdvisor("memory", 0, memory, 2, 0, true)
+
+	// 请求没有可用模型，无法生成新摘要，淘汰消息时保留旧摘要
+	adviseContext := map[string]any{AdviseContextConversationID: "c1"}
+	advised, err := advisor.AdviseRequest(ctx, &AdvisedRequest{UserText: "u3", AdviseContext: adviseContext})
+	if err != nil {
+		t.Fatalf("AdviseRequest 失败: %v", err)
+	}
+	if len(advised.Messages) != 1 || advised.Messages[0].Content != summary.Content {
+		t.Errorf("请求历史 = %+v, 期望只保留旧摘要", advised.Messages)
+	}
+
+	response := &ChatResponse{Generations: []Generation{{Message: NewAssistantMessage("a3")}}}
+	if _, err := advisor.AdviseResponse(ctx, &AdvisedResponse{Response: response, AdviseContext: adviseContext}); err != nil {
+		t.Fatalf("AdviseResponse 失败: %v", err)
+	}
+	messages, _ := memory.Get(ctx, "c1")
+	if len(messages) != 3 || messages[0].Content != summary.Content || messages[1].Content != "u3" || messages[2].Content != "a3" {
+		t.Errorf("记忆 = %+v, 期望旧摘要和本轮对话", messages)
+	}
+}
+
+// waitForLockedConversations 等待锁表中至少有 n 个会话
+func waitForLockedConversations(t *testing.T, locks *ConversationLocks, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for locks.size() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("锁表大小 = %d, 期望至少 %d", locks.size(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,279 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ModelGroupMember 模型组成员
+type ModelGroupMember struct {
+	Name     string // 模型 Bean 名称
+	Model    ChatModel
+	Priority int // 越小越优先
+	Weight   int // 同优先级按权重轮询，小于等于0时按1处理
+	Breaker  *CircuitBreaker
+
+	currentWeight int // 平滑加权轮询的当前权重
+}
+
+// ModelGroup 模型组，实现 ChatModel
+// 同优先级的模型按平滑加权轮询选择，调用失败（5xx、429、超时、网络错误）或被本地限流时依次切换到同优先级其它模型和低优先级模型
+// 工具调用循环在成员模型内执行，已经执行过工具的调用失败后不再切换，避免有副作用的工具被重复执行
+type ModelGroup struct {
+	Name  string
+	tiers [][]*ModelGroupMember // 按优先级分层
+	mu    sync.Mutex
+}
+
+// NewModelGroup 创建模型组，members 至少一个
+func NewModelGroup(name string, members []*ModelGroupMember) *ModelGroup {
+	sorted := append([]*ModelGroupMember(nil), members...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	group := &ModelGroup{Name: name}
+	for i, member := range sorted {
+		if member.Weight <= 0 {
+			member.Weight = 1
+		}
+		if i == 0 || member.Priority != sorted[i-1].Priority {
+			group.tiers = append(group.tiers, nil)
+		}
+		group.tiers[len(group.tiers)-1] = append(group.tiers[len(group.tiers)-1], member)
+	}
+	return group
+}
+
+// Call 同步调用，失败时按顺序切换模型
+func (g *ModelGroup) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	ctx, toolRun := withToolRunMarker(ctx)
+	var response *ChatResponse
+	err := g.invoke(ctx, toolRun, func(member *ModelGroupMember) error {
+		result, err := member.Model.Call(ctx, prompt)
+		if err != nil {
+			return err
+		}
+		member.Breaker.OnSuccess()
+		response = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Stream 流式调用，建立连接失败或第一个片段为错误时切换模型，开始输出后不再切换
+func (g *ModelGroup) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	ctx, toolRun := withToolRunMarker(ctx)
+	var stream <-chan ChatStreamChunk
+	err := g.invoke(ctx, toolRun, func(member *ModelGroupMember) error {
+		chunks, err := member.Model.Stream(ctx, prompt)
+		if err != nil {
+			return err
+		}
+		first, ok := <-chunks
+		if !ok {
+			member.Breaker.OnSuccess()
+			stream = chunks
+			return nil
+		}
+		if first.Err != nil {
+			go drainStreamChunks(chunks)
+			return first.Err
+		}
+		stream = g.forward(ctx, member, first, chunks)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// GetDefaultOptions 优先级最高的模型的默认选项
+func (g *ModelGroup) GetDefaultOptions() *OpenAiChatOptions {
+	if len(g.tiers) == 0 {
+		return nil
+	}
+	return g.tiers[0][0].Model.GetDefaultOptions()
+}
+
+// invoke 按顺序对模型执行 call，直到成功或遇到不可切换的错误
+// 熔断中的模型跳过，全部熔断时仍按顺序尝试，避免熔断导致完全不可用；已执行过工具时不再切换
+func (g *ModelGroup) invoke(ctx context.Context, toolRun *toolRunMarker, call func(member *ModelGroupMember) error) error {
+	var ordered []*ModelGroupMember
+	g.mu.Lock()
+	for _, tier := range g.tiers {
+		ordered = append(ordered, orderTier(tier)...)
+	}
+	g.mu.Unlock()
+
+	var lastErr error
+	for _, force := range []bool{false, true} {
+		if force {
+			if lastErr != nil {
+				break
+			}
+			log.Printf("模型组 %s 全部模型熔断，仍按顺序尝试", g.Name)
+		}
+		for _, member := range ordered {
+			if !force && !member.Breaker.Allow() {
+				continue
+			}
+			err := call(member)
+			if err == nil {
+				return nil
+			}
+			if !g.onError(ctx, member, err) {
+				return err
+			}
+			if toolRun.ran.Load() {
+				log.Printf("模型组 %s 调用 %s 失败时已执行过工具，不再切换模型", g.Name, member.Name)
+				return err
+			}
+			lastErr = err
+		}
+	}
+	return fmt.Errorf("模型组 %s 全部模型调用失败: %w", g.Name, lastErr)
+}
+
+// orderTier 平滑加权轮询（与 Nginx 一致）选出本层第一个模型，调用方需持有锁
+func orderTier(tier []*ModelGroupMember) []*ModelGroupMember {
+	if len(tier) == 1 {
+		return tier
+	}
+
+	total := 0
+	var selected *ModelGroupMember
+	for _, member := range tier {
+		member.currentWeight += member.Weight
+		total += member.Weight
+		if selected == nil || member.currentWeight > selected.currentWeight {
+			selected = member
+		}
+	}
+	selected.currentWeight -= total
+
+	ordered := make([]*ModelGroupMember, 0, len(tier))
+	ordered = append(ordered, selected)
+	for _, member := range tier {
+		if member != selected {
+			ordered = append(ordered, member)
+		}
+	}
+	sort.SliceStable(ordered[1:], func(i, j int) bool {
+		return ordered[1+i].Weight > ordered[1+j].Weight
+	})
+	return ordered
+}
+
+// onError 记录调用失败，返回是否可以切换到下一个模型
+func (g *ModelGroup) onError(ctx context.Context, member *ModelGroupMember, err error) bool {
+	if ctx.Err() != nil {
+		member.Breaker.OnCancel()
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) {
+		// 本地限流不代表模型不可用，不计入熔断
+		member.Breaker.OnCancel()
+		log.Printf("模型组 %s 调用 %s 被限流，切换到下一个模型: %v", g.Name, member.Name, err)
+		return true
+	}
+	if !isRetryableModelError(err) {
+		// 请求本身有误，模型服务可用
+		member.Breaker.OnSuccess()
+		return false
+	}
+	member.Breaker.OnFailure()
+	log.Printf("模型组 %s 调用 %s 失败，切换到下一个模型: %v", g.Name, member.Name, err)
+	return true
+}
+
+// forward 转发已开始输出的流，流中途的可重试错误计入熔断
+func (g *ModelGroup) forward(ctx context.Context, member *ModelGroupMember, first ChatStreamChunk, chunks <-chan ChatStreamChunk) <-chan ChatStreamChunk {
+	out := make(chan ChatStreamChunk)
+	go func() {
+		defer close(out)
+		defer drainStreamChunks(chunks)
+
+		var streamErr error
+		for chunk := first; ; {
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				member.Breaker.OnCancel()
+				return
+			}
+
+			next, ok := <-chunks
+			if !ok {
+				break
+			}
+			chunk = next
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			member.Breaker.OnCancel()
+		case streamErr != nil && isRetryableModelError(streamErr):
+			member.Breaker.OnFailure()
+		default:
+			member.Breaker.OnSuccess()
+		}
+	}()
+	return out
+}
+
+// isRetryableModelError 判断错误是否由模型服务不可用引起：5xx、429、408、超时或网络错误
+func isRetryableModelError(err error) bool {
+	var apiErr *ChatModelApiError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError ||
+			apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout
+	}
+	if errors.Is(err, errChatModelTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// toolRunKey 工具执行标记在请求上下文中的键
+type toolRunKey struct{}
+
+// toolRunMarker 记录一次模型组调用中是否已执行过工具
+type toolRunMarker struct {
+	ran atomic.Bool
+}
+
+// withToolRunMarker 将新的工具执行标记放入请求上下文
+func withToolRunMarker(ctx context.Context) (context.Context, *toolRunMarker) {
+	marker := &toolRunMarker{}
+	return context.WithValue(ctx, toolRunKey{}, marker), marker
+}
+
+// markToolRun 标记请求上下文对应的调用已执行过工具
+func markToolRun(ctx context.Context) {
+	if marker, ok := ctx.Value(toolRunKey{}).(*toolRunMarker); ok {
+		marker.ran.Store(true)
+	}
+}
+
+// drainStreamChunks 读完流，避免生产方阻塞
+func drainStreamChunks(chunks <-chan ChatStreamChunk) {
+	for range chunks {
+	}
+}
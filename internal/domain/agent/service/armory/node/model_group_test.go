@@ -0,0 +1,267 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// scriptedStep 模拟模型单次上游请求的结果
+type scriptedStep struct {
+	response *ChatResponse
+	err      error
+}
+
+// scriptedChatModel 按顺序返回预设结果的模型，工具调用循环与真实模型一致
+type scriptedChatModel struct {
+	steps []scriptedStep
+	tools []ToolCallback
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (m *scriptedChatModel) next() (*ChatResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls >= len(m.steps) {
+		m.calls++
+		return nil, errors.New("没有预设结果")
+	}
+	step := m.steps[m.calls]
+	m.calls++
+	return step.response, step.err
+}
+
+func (m *scriptedChatModel) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func (m *scriptedChatModel) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	options := &OpenAiChatOptions{ToolCallbacks: m.tools}
+	return callWithToolLoop(ctx, promptMessages(prompt), options, func(context.Context, []Message, *OpenAiChatOptions) (*ChatResponse, error) {
+		return m.next()
+	})
+}
+
+func (m *scriptedChatModel) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	options := &OpenAiChatOptions{ToolCallbacks: m.tools}
+	return streamWithToolLoop(ctx, promptMessages(prompt), options, func(context.Context, []Message, *OpenAiChatOptions) (<-chan ChatStreamChunk, error) {
+		response, err := m.next()
+		if err != nil {
+			return nil, err
+		}
+		chunks := make(chan ChatStreamChunk, 1)
+		chunks <- ChatStreamChunk{Response: response}
+		close(chunks)
+		return chunks, nil
+	})
+}
+
+func (m *scriptedChatModel) GetDefaultOptions() *OpenAiChatOptions { return nil }
+
+// textReply 纯文本回复
+func textReply(content string) scriptedStep {
+	return scriptedStep{response: &ChatResponse{Generations: []Generation{{
+		Message:      NewAssistantMessage(content),
+		FinishReason: "stop",
+	}}}}
+}
+
+// weatherToolCallReply 调用 get_weather 工具的回复
+func weatherToolCallReply() scriptedStep {
+	return scriptedStep{response: &ChatResponse{Generations: []Generation{{
+		Message: Message{
+			Role:      MessageRoleAssistant,
+			ToolCalls: []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"杭州"}`}},
+		},
+		FinishReason: "tool_calls",
+	}}}}
+}
+
+// unavailableReply 模型服务不可用
+func unavailableReply() scriptedStep {
+	return scriptedStep{err: &ChatModelApiError{StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}}
+}
+
+// newTestModelGroup 为每个模型创建独立熔断器的模型组
+func newTestModelGroup(members ...*ModelGroupMember) *ModelGroup {
+	for _, member := range members {
+		member.Breaker = NewCircuitBreaker(member.Name)
+	}
+	return NewModelGroup("test-group", members)
+}
+
+func TestModelGroupWeightedRoundRobin(t *testing.T) {
+	models := map[string]*scriptedChatModel{}
+	var members []*ModelGroupMember
+	for name, weight := range map[string]int{"a": 5, "b": 1, "c": 1} {
+		model := &scriptedChatModel{}
+		for i := 0; i < 7; i++ {
+			model.steps = append(model.steps, textReply(name))
+		}
+		models[name] = model
+		members = append(members, &ModelGroupMember{Name: name, Model: model, Weight: weight})
+	}
+	group := newTestModelGroup(members...)
+
+	// 平滑加权轮询：每 7 次中 a 选中 5 次，且 b、c 不会连续被选中
+	var sequence []string
+	for i := 0; i < 7; i++ {
+		response, err := group.Call(context.Background(), NewPrompt(NewUserMessage("hi")))
+		if err != nil {
+			t.Fatalf("第 %d 次调用失败: %v", i+1, err)
+		}
+		sequence = append(sequence, response.GetText())
+	}
+	want := map[string]int{"a": 5, "b": 1, "c": 1}
+	for name, count := range want {
+		if got := models[name].callCount(); got != count {
+			t.Errorf("模型 %s 被调用 %d 次, 期望 %d, 顺序 %v", name, got, count, sequence)
+		}
+	}
+	for i := 1; i < len(sequence); i++ {
+		if sequence[i] != "a" && sequence[i-1] != "a" {
+			t.Errorf("低权重模型连续被选中: %v", sequence)
+		}
+	}
+}
+
+func TestModelGroupPriorityFailover(t *testing.T) {
+	primary := &scriptedChatModel{steps: []scriptedStep{unavailableReply()}}
+	backup := &scriptedChatModel{steps: []scriptedStep{textReply("backup")}}
+	group := newTestModelGroup(
+		&ModelGroupMember{Name: "primary", Model: primary, Priority: 0},
+		&ModelGroupMember{Name: "backup", Model: backup, Priority: 1},
+	)
+
+	response, err := group.Call(context.Background(), NewPrompt(NewUserMessage("hi")))
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	if response.GetText() != "backup" {
+		t.Errorf("回复 = %s, 期望切换到 backup", response.GetText())
+	}
+}
+
+func TestModelGroupNoFailoverOnClientError(t *testing.T) {
+	primary := &scriptedChatModel{steps: []scriptedStep{{err: &ChatModelApiError{StatusCode: http.StatusBadRequest}}}}
+	backup := &scriptedChatModel{steps: []scriptedStep{textReply("backup")}}
+	group := newTestModelGroup(
+		&ModelGroupMember{Name: "primary", Model: primary, Priority: 0},
+		&ModelGroupMember{Name: "backup", Model: backup, Priority: 1},
+	)
+
+	if _, err := group.Call(context.Background(), NewPrompt(NewUserMessage("hi"))); err == nil {
+		t.Fatal("请求本身有误时应直接返回错误")
+	}
+	if backup.callCount() != 0 {
+		t.Errorf("请求本身有误时不应切换模型")
+	}
+}
+
+func TestModelGroupSkipsOpenBreaker(t *testing.T) {
+	primary := &scriptedChatModel{steps: []scriptedStep{unavailableReply(), unavailableReply(), unavailableReply()}}
+	backup := &scriptedChatModel{}
+	for i := 0; i < 4; i++ {
+		backup.steps = append(backup.steps, textReply("backup"))
+	}
+	group := newTestModelGroup(
+		&ModelGroupMember{Name: "primary", Model: primary, Priority: 0},
+		&ModelGroupMember{Name: "backup", Model: backup, Priority: 1},
+	)
+
+	for i := 0; i < 4; i++ {
+		if _, err := group.Call(context.Background(), NewPrompt(NewUserMessage("hi"))); err != nil {
+			t.Fatalf("第 %d 次调用失败: %v", i+1, err)
+		}
+	}
+	// 连续失败 3 次后熔断，第 4 次直接调用 backup
+	if got := primary.callCount(); got != 3 {
+		t.Errorf("primary 被调用 %d 次, 期望熔断后不再调用 3", got)
+	}
+}
+
+func TestModelGroupFailoverRunsToolsOnce(t *testing.T) {
+	tool := &fakeWeatherTool{}
+	primary := &scriptedChatModel{
+		steps: []scriptedStep{weatherToolCallReply(), unavailableReply()},
+		tools: []ToolCallback{tool},
+	}
+	backup := &scriptedChatModel{
+		steps: []scriptedStep{weatherToolCallReply(), textReply("杭州晴")},
+		tools: []ToolCallback{tool},
+	}
+	group := newTestModelGroup(
+		&ModelGroupMember{Name: "primary", Model: primary, Priority: 0},
+		&ModelGroupMember{Name: "backup", Model: backup, Priority: 1},
+	)
+
+	// 工具已执行后上游失败，不再切换模型重放整个工具调用循环
+	_, err := group.Call(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	var apiErr *ChatModelApiError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("错误 = %v, 期望返回 primary 的错误", err)
+	}
+	if len(tool.inputs) != 1 {
+		t.Errorf("工具执行 %d 次, 期望 1", len(tool.inputs))
+	}
+	if backup.callCount() != 0 {
+		t.Errorf("已执行工具后不应切换到 backup")
+	}
+}
+
+func TestModelGroupStreamFailoverRunsToolsOnce(t *testing.T) {
+	tool := &fakeWeatherTool{}
+	primary := &scriptedChatModel{
+		steps: []scriptedStep{weatherToolCallReply(), unavailableReply()},
+		tools: []ToolCallback{tool},
+	}
+	backup := &scriptedChatModel{
+		steps: []scriptedStep{weatherToolCallReply(), textReply("杭州晴")},
+		tools: []ToolCallback{tool},
+	}
+	group := newTestModelGroup(
+		&ModelGroupMember{Name: "primary", Model: primary, Priority: 0},
+		&ModelGroupMember{Name: "backup", Model: backup, Priority: 1},
+	)
+
+	chunks, err := group.Stream(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err == nil {
+		_, _, err = collectStream(t, chunks)
+	}
+	if err == nil {
+		t.Fatal("期望返回 primary 的错误")
+	}
+	if len(tool.inputs) != 1 {
+		t.Errorf("工具执行 %d 次, 期望 1", len(tool.inputs))
+	}
+	if backup.callCount() != 0 {
+		t.Errorf("已执行工具后不应切换到 backup")
+	}
+}
+
+func TestModelGroupFailoverBeforeToolsRun(t *testing.T) {
+	tool := &fakeWeatherTool{}
+	primary := &scriptedChatModel{steps: []scriptedStep{unavailableReply()}, tools: []ToolCallback{tool}}
+	backup := &scriptedChatModel{
+		steps: []scriptedStep{weatherToolCallReply(), textReply("杭州晴")},
+		tools: []ToolCallback{tool},
+	}
+	group := newTestModelGroup(
+		&ModelGroupMember{Name: "primary", Model: primary, Priority: 0},
+		&ModelGroupMember{Name: "backup", Model: backup, Priority: 1},
+	)
+
+	response, err := group.Call(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	if response.GetText() != "杭州晴" || len(tool.inputs) != 1 {
+		t.Errorf("回复 = %s, 工具执行 %d 次", response.GetText(), len(tool.inputs))
+	}
+}
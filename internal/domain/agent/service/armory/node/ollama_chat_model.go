@@ -0,0 +1,270 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultOllamaChatPath 未配置时使用的对话接口路径
+const defaultOllamaChatPath = "/api/chat"
+
+// OllamaApi Ollama API配置（模拟Java中的OllamaApi）
+type OllamaApi struct {
+	BaseURL  string
+	APIKey   string // 可选，经反向代理鉴权时通过 Bearer 传递
+	ChatPath string // 为空时使用 /api/chat
+}
+
+// OllamaChatModel Ollama 原生接口聊天模型，流式响应为逐行 JSON
+type OllamaChatModel struct {
+	OllamaApi      *OllamaApi
+	DefaultOptions *OpenAiChatOptions
+	Timeout        time.Duration // 单次请求超时，0 表示不限制
+}
+
+// ollamaChatRequest /api/chat 请求体
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"` // Ollama 默认流式，必须显式传递
+	Tools    []openAiTool    `json:"tools,omitempty"`
+	Format   json.RawMessage `json:"format,omitempty"` // "json" 或 JSON Schema
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+// ollamaOptions 模型参数
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
+// ollamaMessage 对话消息
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// ollamaToolCall 工具调用，参数为 JSON 对象
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaChatResponse /api/chat 响应体，流式时每行一个
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// Call 同步调用对话接口，配置了工具回调时自动执行工具调用循环
+func (model *OllamaChatModel) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	return callWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.callOnce)
+}
+
+// Stream 流式调用对话接口，配置了工具回调时在流内完成工具调用循环
+func (model *OllamaChatModel) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	return streamWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.streamOnce)
+}
+
+// GetDefaultOptions 默认选项
+func (model *OllamaChatModel) GetDefaultOptions() *OpenAiChatOptions {
+	return model.DefaultOptions
+}
+
+// callOnce 单次同步请求
+func (model *OllamaChatModel) callOnce(ctx context.Context, messages []Message, options *OpenAiChatOptions) (*ChatResponse, error) {
+	resp, cancel, err := model.post(ctx, buildOllamaRequest(messages, options, false))
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	var chat ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("解析模型响应失败: %w", err)
+	}
+	if chat.Error != "" {
+		return nil, &ChatModelApiError{StatusCode: resp.StatusCode, Message: chat.Error}
+	}
+	toolCallIndex := 0
+	return chat.toChatResponse(&toolCallIndex), nil
+}
+
+// streamOnce 单次流式请求，工具调用在一行内完整返回，按出现顺序分配 Index
+func (model *OllamaChatModel) streamOnce(ctx context.Context, messages []Message, options *OpenAiChatOptions) (<-chan ChatStreamChunk, error) {
+	resp, cancel, err := model.post(ctx, buildOllamaRequest(messages, options, true))
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan ChatStreamChunk)
+	go func() {
+		defer close(chunks)
+		defer cancel()
+		defer resp.Body.Close()
+
+		send := func(chunk ChatStreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		toolCallIndex := 0
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var chat ollamaChatResponse
+			if err := decoder.Decode(&chat); err != nil {
+				if ctx.Err() != nil {
+					if !errors.Is(ctx.Err(), context.Canceled) {
+						send(ChatStreamChunk{Err: ctx.Err()})
+					}
+					return
+				}
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				send(ChatStreamChunk{Err: fmt.Errorf("读取模型流式响应失败: %w", err)})
+				return
+			}
+			if chat.Error != "" {
+				send(ChatStreamChunk{Err: &ChatModelApiError{StatusCode: resp.StatusCode, Message: chat.Error}})
+				return
+			}
+			if !send(ChatStreamChunk{Response: chat.toChatResponse(&toolCallIndex)}) || chat.Done {
+				return
+			}
+		}
+	}()
+
+	return chunks, nil
+}
+
+// post 发送请求，非 2xx 响应转为 ChatModelApiError
+func (model *OllamaChatModel) post(ctx context.Context, request *ollamaChatRequest) (*http.Response, context.CancelFunc, error) {
+	api := model.OllamaApi
+	if api == nil {
+		return nil, nil, errors.New("OllamaApi未配置")
+	}
+
+	path := api.ChatPath
+	if path == "" {
+		path = defaultOllamaChatPath
+	}
+
+	header := make(http.Header)
+	if api.APIKey != "" {
+		header.Set("Authorization", "Bearer "+api.APIKey)
+	}
+	return postChatRequest(ctx, joinURL(api.BaseURL, path), header, request, model.Timeout, request.Stream, parseOllamaError)
+}
+
+// buildOllamaRequest 构建请求体
+func buildOllamaRequest(messages []Message, options *OpenAiChatOptions, stream bool) *ollamaChatRequest {
+	request := &ollamaChatRequest{
+		Model:  options.Model,
+		Stream: stream,
+	}
+	if options.Temperature != nil || options.MaxTokens > 0 {
+		request.Options = &ollamaOptions{Temperature: options.Temperature, NumPredict: options.MaxTokens}
+	}
+
+	for _, message := range messages {
+		ollama := ollamaMessage{Role: message.Role, Content: message.Content}
+		if message.Role == MessageRoleTool {
+			ollama.ToolName = message.Name
+		}
+		for _, toolCall := range message.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = toolCall.Name
+			call.Function.Arguments = toolArgumentsJSON(toolCall.Arguments)
+			ollama.ToolCalls = append(ollama.ToolCalls, call)
+		}
+		request.Messages = append(request.Messages, ollama)
+	}
+
+	for _, callback := range options.ToolCallbacks {
+		definition := callback.GetToolDefinition()
+		request.Tools = append(request.Tools, openAiTool{
+			Type: "function",
+			Function: openAiFunctionDefinition{
+				Name:        definition.Name,
+				Description: definition.Description,
+				Parameters:  definition.InputSchema,
+			},
+		})
+	}
+	if format := options.ResponseFormat; format != nil {
+		request.Format = json.RawMessage(`"json"`)
+		if format.Type == ResponseFormatTypeJSONSchema && format.Schema != nil {
+			request.Format = format.toolSchema()
+		}
+	}
+	return request
+}
+
+// toChatResponse 转换为通用响应，Ollama 不返回工具调用ID，按 toolCallIndex 生成
+func (chat *ollamaChatResponse) toChatResponse(toolCallIndex *int) *ChatResponse {
+	generation := Generation{
+		Message: Message{Role: MessageRoleAssistant, Content: chat.Message.Content},
+	}
+	for _, call := range chat.Message.ToolCalls {
+		generation.Message.ToolCalls = append(generation.Message.ToolCalls, ToolCall{
+			Index:     *toolCallIndex,
+			ID:        fmt.Sprintf("call_%d", *toolCallIndex),
+			Name:      call.Function.Name,
+			Arguments: string(toolArgumentsJSON(string(call.Function.Arguments))),
+		})
+		*toolCallIndex++
+	}
+	if chat.Done {
+		generation.FinishReason = chat.DoneReason
+		if *toolCallIndex > 0 {
+			generation.FinishReason = "tool_calls"
+		}
+	}
+
+	response := &ChatResponse{Model: chat.Model, Generations: []Generation{generation}}
+	if chat.Done {
+		response.Usage = Usage{
+			PromptTokens:     chat.PromptEvalCount,
+			CompletionTokens: chat.EvalCount,
+			TotalTokens:      chat.PromptEvalCount + chat.EvalCount,
+		}
+	}
+	return response
+}
+
+// parseOllamaError 解析错误响应 {"error":"..."}
+func parseOllamaError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	apiErr := &ChatModelApiError{StatusCode: resp.StatusCode}
+
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		apiErr.Message = errResp.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
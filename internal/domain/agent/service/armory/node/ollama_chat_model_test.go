@@ -0,0 +1,177 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// ollamaChatModel 创建指向模拟服务的模型
+func (p *fakeChatProvider) ollamaChatModel(toolCallbacks ...ToolCallback) *OllamaChatModel {
+	return &OllamaChatModel{
+		OllamaApi:      &OllamaApi{BaseURL: p.server.URL},
+		DefaultOptions: &OpenAiChatOptions{Model: "qwen-test", ToolCallbacks: toolCallbacks},
+	}
+}
+
+// ndjsonProviderReply 200 逐行 JSON 响应
+func ndjsonProviderReply(lines ...string) fakeProviderReply {
+	return fakeProviderReply{status: http.StatusOK, contentType: "application/x-ndjson", body: ndjsonBody(lines...)}
+}
+
+func TestOllamaChatModelCallToolLoop(t *testing.T) {
+	provider := newFakeChatProvider(t,
+		jsonProviderReply(`{"model": "qwen-test", "message": {"role": "assistant", "content": "", "tool_calls": [
+			{"function": {"name": "get_weather", "arguments": {"city":"杭州"}}}
+		]}, "done": true, "done_reason": "stop", "prompt_eval_count": 10, "eval_count": 5}`),
+		jsonProviderReply(`{"model": "qwen-test", "message": {"role": "assistant", "content": "杭州晴"},
+			"done": true, "done_reason": "stop", "prompt_eval_count": 30, "eval_count": 6}`),
+	)
+	tool := &fakeWeatherTool{}
+	model := provider.ollamaChatModel(tool)
+	temperature := 0.2
+	model.DefaultOptions.Temperature = &temperature
+	model.DefaultOptions.MaxTokens = 256
+
+	response, err := model.Call(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	if response.GetText() != "杭州晴" || response.GetResult().FinishReason != "stop" {
+		t.Errorf("最终回复 = %+v", response.GetResult())
+	}
+	if want := (Usage{PromptTokens: 40, CompletionTokens: 11, TotalTokens: 51}); response.Usage != want {
+		t.Errorf("用量 = %+v, 期望 %+v", response.Usage, want)
+	}
+	if len(tool.inputs) != 1 || tool.inputs[0] != `{"city":"杭州"}` {
+		t.Errorf("工具调用参数 = %v", tool.inputs)
+	}
+
+	requests := provider.recordedRequests()
+	if len(requests) != 2 {
+		t.Fatalf("请求次数 = %d, 期望 2", len(requests))
+	}
+	if requests[0].URL != defaultOllamaChatPath {
+		t.Errorf("请求路径 = %s", requests[0].URL)
+	}
+
+	var first ollamaChatRequest
+	requests[0].decode(t, &first)
+	if first.Stream || first.Options == nil || *first.Options.Temperature != 0.2 || first.Options.NumPredict != 256 {
+		t.Errorf("请求参数 stream=%v options=%+v", first.Stream, first.Options)
+	}
+	if len(first.Tools) != 1 || first.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("工具定义 = %+v", first.Tools)
+	}
+
+	// 第二轮：工具调用参数为 JSON 对象，工具结果消息带工具名称
+	var second ollamaChatRequest
+	requests[1].decode(t, &second)
+	if len(second.Messages) != 3 {
+		t.Fatalf("第二轮消息数量 = %d, 期望 3", len(second.Messages))
+	}
+	var arguments map[string]string
+	if calls := second.Messages[1].ToolCalls; len(calls) != 1 || json.Unmarshal(calls[0].Function.Arguments, &arguments) != nil || arguments["city"] != "杭州" {
+		t.Errorf("助手工具调用 = %+v", second.Messages[1])
+	}
+	if toolMessage := second.Messages[2]; toolMessage.Role != MessageRoleTool || toolMessage.ToolName != "get_weather" || toolMessage.Content != "晴 25℃" {
+		t.Errorf("工具结果消息 = %+v", toolMessage)
+	}
+}
+
+func TestOllamaChatModelResponseFormat(t *testing.T) {
+	provider := newFakeChatProvider(t, jsonProviderReply(`{"message": {"role": "assistant", "content": "{}"}, "done": true}`))
+	model := provider.ollamaChatModel()
+	model.DefaultOptions.ResponseFormat = &ResponseFormat{Type: ResponseFormatTypeJSONObject}
+
+	if _, err := model.Call(context.Background(), NewPrompt(NewUserMessage("hi"))); err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	var request ollamaChatRequest
+	provider.recordedRequests()[0].decode(t, &request)
+	if string(request.Format) != `"json"` {
+		t.Errorf("format = %s, 期望 \"json\"", request.Format)
+	}
+}
+
+func TestOllamaChatModelStream(t *testing.T) {
+	provider := newFakeChatProvider(t,
+		ndjsonProviderReply(
+			`{"message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "get_weather", "arguments": {"city":"杭州"}}}]}, "done": false}`,
+			`{"message": {"role": "assistant", "content": ""}, "done": true, "done_reason": "stop", "prompt_eval_count": 8, "eval_count": 3}`,
+		),
+		ndjsonProviderReply(
+			`{"message": {"role": "assistant", "content": "你"}, "done": false}`,
+			`{"message": {"role": "assistant", "content": "好"}, "done": false}`,
+			`{"message": {"role": "assistant", "content": ""}, "done": true, "done_reason": "stop", "prompt_eval_count": 20, "eval_count": 2}`,
+		),
+	)
+	tool := &fakeWeatherTool{}
+
+	chunks, err := provider.ollamaChatModel(tool).Stream(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, usage, err := collectStream(t, chunks)
+	if err != nil {
+		t.Fatalf("读取流失败: %v", err)
+	}
+	if content != "你好" {
+		t.Errorf("内容 = %q", content)
+	}
+	if want := (Usage{PromptTokens: 28, CompletionTokens: 5, TotalTokens: 33}); usage != want {
+		t.Errorf("用量 = %+v, 期望按轮累加 %+v", usage, want)
+	}
+	if len(tool.inputs) != 1 || tool.inputs[0] != `{"city":"杭州"}` {
+		t.Errorf("工具调用参数 = %v", tool.inputs)
+	}
+
+	var first ollamaChatRequest
+	provider.recordedRequests()[0].decode(t, &first)
+	if !first.Stream {
+		t.Error("流式请求应显式设置 stream")
+	}
+}
+
+func TestOllamaChatModelErrorMapping(t *testing.T) {
+	provider := newFakeChatProvider(t,
+		errorProviderReply(http.StatusNotFound, `{"error": "model \"qwen-test\" not found"}`),
+		ndjsonProviderReply(
+			`{"message": {"role": "assistant", "content": "你"}, "done": false}`,
+			`{"error": "model runner has unexpectedly stopped"}`,
+		),
+		ndjsonProviderReply(`{"message": {"role": "assistant", "content": "你"}, "done": false}`),
+	)
+	model := provider.ollamaChatModel()
+
+	_, err := model.Call(context.Background(), NewPrompt(NewUserMessage("hi")))
+	var apiErr *ChatModelApiError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != `model "qwen-test" not found` {
+		t.Fatalf("错误 = %v, 期望 404 ChatModelApiError", err)
+	}
+	if isRetryableModelError(err) {
+		t.Error("模型不存在不应切换模型重试")
+	}
+
+	// 流中途的错误行
+	chunks, err := model.Stream(context.Background(), NewPrompt(NewUserMessage("hi")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, _, err := collectStream(t, chunks)
+	if !errors.As(err, &apiErr) || apiErr.Message != "model runner has unexpectedly stopped" || content != "你" {
+		t.Errorf("流内错误 = %v, 已输出 %q", err, content)
+	}
+
+	// 未收到 done 即断开
+	chunks, err = model.Stream(context.Background(), NewPrompt(NewUserMessage("hi")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	if _, _, err := collectStream(t, chunks); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("流提前结束错误 = %v, 期望 io.ErrUnexpectedEOF", err)
+	}
+}
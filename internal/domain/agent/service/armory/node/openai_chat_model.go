@@ -0,0 +1,396 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCompletionsPath 未配置时使用的对话接口路径
+	defaultCompletionsPath = "/v1/chat/completions"
+	// sseDoneMarker OpenAI 流式响应结束标记
+	sseDoneMarker = "[DONE]"
+)
+
+// openAiChatCompletionRequest /chat/completions 请求体
+type openAiChatCompletionRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAiMessage       `json:"messages"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	StreamOptions  *openAiStreamOptions  `json:"stream_options,omitempty"`
+	Tools          []openAiTool          `json:"tools,omitempty"`
+	ResponseFormat *openAiResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAiResponseFormat 结构化输出格式
+type openAiResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAiJSONSchema `json:"json_schema,omitempty"`
+}
+
+// openAiJSONSchema json_schema 格式的 Schema 定义
+type openAiJSONSchema struct {
+	Name   string      `json:"name"`
+	Schema *JSONSchema `json:"schema,omitempty"`
+	Strict bool        `json:"strict,omitempty"`
+}
+
+// openAiTool 工具定义
+type openAiTool struct {
+	Type     string                   `json:"type"`
+	Function openAiFunctionDefinition `json:"function"`
+}
+
+// openAiFunctionDefinition 函数定义
+type openAiFunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// openAiStreamOptions 流式选项
+type openAiStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAiMessage 请求/响应消息
+type openAiMessage struct {
+	Role       string           `json:"role,omitempty"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAiToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
+}
+
+// openAiToolCall 工具调用
+type openAiToolCall struct {
+	Index    *int               `json:"index,omitempty"`
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function openAiFunctionCall `json:"function"`
+}
+
+// openAiFunctionCall 函数调用
+type openAiFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// openAiChatCompletion /chat/completions 响应体，流式片段复用同一结构
+type openAiChatCompletion struct {
+	ID      string                       `json:"id"`
+	Model   string                       `json:"model"`
+	Choices []openAiChatCompletionChoice `json:"choices"`
+	Usage   *openAiUsage                 `json:"usage"`
+}
+
+// openAiChatCompletionChoice 候选结果，非流式使用 Message，流式使用 Delta
+type openAiChatCompletionChoice struct {
+	Index        int           `json:"index"`
+	Message      openAiMessage `json:"message"`
+	Delta        openAiMessage `json:"delta"`
+	FinishReason string        `json:"finish_reason"`
+}
+
+// openAiUsage token 用量
+type openAiUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// openAiErrorResponse 错误响应体
+type openAiErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+// openAiCompatibleApi OpenAI 兼容接口的地址与鉴权，OpenAI 与 Azure OpenAI 共用请求和响应格式
+type openAiCompatibleApi interface {
+	completionsURL() string
+	authorize(header http.Header)
+}
+
+// openAiCompletionsClient OpenAI 兼容的 /chat/completions 接口客户端
+type openAiCompletionsClient struct {
+	api     openAiCompatibleApi
+	timeout time.Duration // 单次请求超时，0 表示不限制
+}
+
+// Call 同步调用对话接口，配置了工具回调时自动执行工具调用循环
+func (model *OpenAiChatModel) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	return callWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.client().callOnce)
+}
+
+// Stream 流式调用对话接口，配置了工具回调时在流内完成工具调用循环
+// Timeout 仅约束每轮等待响应头的时间，返回的 channel 在流结束或 ctx 取消后关闭
+func (model *OpenAiChatModel) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	return streamWithToolLoop(ctx, promptMessages(prompt), mergeChatOptions(model.DefaultOptions, prompt), model.client().streamOnce)
+}
+
+// GetDefaultOptions 默认选项
+func (model *OpenAiChatModel) GetDefaultOptions() *OpenAiChatOptions {
+	return model.DefaultOptions
+}
+
+// client 接口客户端
+func (model *OpenAiChatModel) client() *openAiCompletionsClient {
+	client := &openAiCompletionsClient{timeout: model.Timeout}
+	if model.OpenAiApi != nil {
+		client.api = model.OpenAiApi
+	}
+	return client
+}
+
+// callOnce 单次同步请求
+func (client *openAiCompletionsClient) callOnce(ctx context.Context, messages []Message, options *OpenAiChatOptions) (*ChatResponse, error) {
+	resp, cancel, err := client.post(ctx, buildOpenAiRequest(messages, options, false))
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	var completion openAiChatCompletion
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("解析模型响应失败: %w", err)
+	}
+	return completion.toChatResponse(false), nil
+}
+
+// streamOnce 单次流式请求
+func (client *openAiCompletionsClient) streamOnce(ctx context.Context, messages []Message, options *OpenAiChatOptions) (<-chan ChatStreamChunk, error) {
+	resp, cancel, err := client.post(ctx, buildOpenAiRequest(messages, options, true))
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan ChatStreamChunk)
+	go func() {
+		defer close(chunks)
+		defer cancel()
+		defer resp.Body.Close()
+
+		send := func(chunk ChatStreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		done := false
+		err := readSseEvents(resp.Body, func(event *sseEvent) bool {
+			data := strings.TrimSpace(event.Data)
+			if data == "" {
+				return true
+			}
+			if data == sseDoneMarker {
+				done = true
+				return false
+			}
+
+			var completion openAiChatCompletion
+			if err := json.Unmarshal([]byte(data), &completion); err != nil {
+				send(ChatStreamChunk{Err: fmt.Errorf("解析模型流式响应失败: %w", err)})
+				return false
+			}
+			return send(ChatStreamChunk{Response: completion.toChatResponse(true)})
+		})
+		if err != nil && ctx.Err() == nil {
+			send(ChatStreamChunk{Err: fmt.Errorf("读取模型流式响应失败: %w", err)})
+			return
+		}
+		if !done && ctx.Err() != nil && !errors.Is(ctx.Err(), context.Canceled) {
+			send(ChatStreamChunk{Err: ctx.Err()})
+		}
+	}()
+
+	return chunks, nil
+}
+
+// buildOpenAiRequest 构建请求体
+func buildOpenAiRequest(messages []Message, options *OpenAiChatOptions, stream bool) *openAiChatCompletionRequest {
+	request := &openAiChatCompletionRequest{
+		Model:       options.Model,
+		Temperature: options.Temperature,
+		MaxTokens:   options.MaxTokens,
+		Stream:      stream,
+	}
+	if stream {
+		request.StreamOptions = &openAiStreamOptions{IncludeUsage: true}
+	}
+	for _, message := range messages {
+		request.Messages = append(request.Messages, toOpenAiMessage(message))
+	}
+	for _, callback := range options.ToolCallbacks {
+		definition := callback.GetToolDefinition()
+		request.Tools = append(request.Tools, openAiTool{
+			Type: "function",
+			Function: openAiFunctionDefinition{
+				Name:        definition.Name,
+				Description: definition.Description,
+				Parameters:  definition.InputSchema,
+			},
+		})
+	}
+	if format := options.ResponseFormat; format != nil {
+		request.ResponseFormat = &openAiResponseFormat{Type: format.Type}
+		if format.Type == ResponseFormatTypeJSONSchema {
+			request.ResponseFormat.JSONSchema = &openAiJSONSchema{
+				Name:   responseFormatName(format.Name),
+				Schema: format.Schema,
+				Strict: format.Strict,
+			}
+		}
+	}
+	return request
+}
+
+// post 发送请求，非 2xx 响应转为 ChatModelApiError
+func (client *openAiCompletionsClient) post(ctx context.Context, request *openAiChatCompletionRequest) (*http.Response, context.CancelFunc, error) {
+	if client.api == nil {
+		return nil, nil, errors.New("OpenAiApi未配置")
+	}
+
+	header := make(http.Header)
+	if request.Stream {
+		header.Set("Accept", "text/event-stream")
+	}
+	client.api.authorize(header)
+	return postChatRequest(ctx, client.api.completionsURL(), header, request, client.timeout, request.Stream, parseOpenAiError)
+}
+
+// completionsURL 拼接对话接口地址
+func (api *OpenAiApi) completionsURL() string {
+	path := api.CompletionsPath
+	if path == "" {
+		path = defaultCompletionsPath
+	}
+	return joinURL(api.BaseURL, path)
+}
+
+// authorize 设置 Bearer 鉴权
+func (api *OpenAiApi) authorize(header http.Header) {
+	if api.APIKey != "" {
+		header.Set("Authorization", "Bearer "+api.APIKey)
+	}
+}
+
+// joinURL 拼接地址，避免出现重复或缺失的斜杠
+func joinURL(baseURL, path string) string {
+	if path == "" {
+		return baseURL
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// parseOpenAiError 解析错误响应
+func parseOpenAiError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	apiErr := &ChatModelApiError{StatusCode: resp.StatusCode}
+
+	var errResp openAiErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Message = errResp.Error.Message
+		apiErr.Type = errResp.Error.Type
+		if errResp.Error.Code != nil {
+			apiErr.Code = fmt.Sprint(errResp.Error.Code)
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// toOpenAiMessage 转换为接口消息格式
+func toOpenAiMessage(message Message) openAiMessage {
+	result := openAiMessage{
+		Role:       message.Role,
+		ToolCallID: message.ToolCallID,
+		Name:       message.Name,
+	}
+	// 携带工具调用的助手消息允许 content 为空
+	if message.Content != "" || len(message.ToolCalls) == 0 {
+		content := message.Content
+		result.Content = &content
+	}
+	for _, toolCall := range message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, openAiToolCall{
+			ID:   toolCall.ID,
+			Type: "function",
+			Function: openAiFunctionCall{
+				Name:      toolCall.Name,
+				Arguments: toolCall.Arguments,
+			},
+		})
+	}
+	return result
+}
+
+// toChatResponse 转换为通用响应，流式片段取 delta
+func (completion *openAiChatCompletion) toChatResponse(stream bool) *ChatResponse {
+	response := &ChatResponse{
+		ID:    completion.ID,
+		Model: completion.Model,
+	}
+	for _, choice := range completion.Choices {
+		message := choice.Message
+		if stream {
+			message = choice.Delta
+		}
+
+		generation := Generation{
+			Index:        choice.Index,
+			FinishReason: choice.FinishReason,
+			Message: Message{
+				Role: message.Role,
+			},
+		}
+		if generation.Message.Role == "" {
+			generation.Message.Role = MessageRoleAssistant
+		}
+		if message.Content != nil {
+			generation.Message.Content = *message.Content
+		}
+		for i, toolCall := range message.ToolCalls {
+			index := i
+			if toolCall.Index != nil {
+				index = *toolCall.Index
+			}
+			generation.Message.ToolCalls = append(generation.Message.ToolCalls, ToolCall{
+				Index:     index,
+				ID:        toolCall.ID,
+				Name:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			})
+		}
+		response.Generations = append(response.Generations, generation)
+	}
+	if completion.Usage != nil {
+		response.Usage = Usage{
+			PromptTokens:     completion.Usage.PromptTokens,
+			CompletionTokens: completion.Usage.CompletionTokens,
+			TotalTokens:      completion.Usage.TotalTokens,
+		}
+		if completion.Usage.PromptTokensDetails != nil {
+			response.Usage.CachedTokens = completion.Usage.PromptTokensDetails.CachedTokens
+		}
+	}
+	return response
+}
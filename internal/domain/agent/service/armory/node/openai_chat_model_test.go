@@ -0,0 +1,350 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOpenAiProvider 模拟 /v1/chat/completions 接口，按请求顺序返回预设响应并记录请求体
+type fakeOpenAiProvider struct {
+	t         *testing.T
+	server    *httptest.Server
+	responses []string // 非流式为 JSON 响应体，流式为完整的 SSE 响应体
+
+	mu       sync.Mutex
+	requests []openAiChatCompletionRequest
+}
+
+// newFakeOpenAiProvider 启动模拟服务，测试结束时关闭
+func newFakeOpenAiProvider(t *testing.T, responses ...string) *fakeOpenAiProvider {
+	t.Helper()
+	provider := &fakeOpenAiProvider{t: t, responses: responses}
+	provider.server = httptest.NewServer(http.HandlerFunc(provider.handle))
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func (p *fakeOpenAiProvider) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != defaultCompletionsPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer test-key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"message":"invalid api key","type":"invalid_request_error","code":"invalid_api_key"}}`)
+		return
+	}
+
+	var request openAiChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.mu.Lock()
+	p.requests = append(p.requests, request)
+	index := len(p.requests) - 1
+	p.mu.Unlock()
+
+	if index >= len(p.responses) {
+		p.t.Errorf("第 %d 次请求没有预设响应", index+1)
+		http.Error(w, "no response", http.StatusInternalServerError)
+		return
+	}
+	if request.Stream {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	fmt.Fprint(w, p.responses[index])
+}
+
+// recordedRequests 已收到的请求
+func (p *fakeOpenAiProvider) recordedRequests() []openAiChatCompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]openAiChatCompletionRequest(nil), p.requests...)
+}
+
+// chatModel 创建指向模拟服务的模型
+func (p *fakeOpenAiProvider) chatModel(apiKey string, toolCallbacks ...ToolCallback) *OpenAiChatModel {
+	return &OpenAiChatModel{
+		OpenAiApi:      &OpenAiApi{BaseURL: p.server.URL, APIKey: apiKey},
+		DefaultOptions: &OpenAiChatOptions{Model: "gpt-test", ToolCallbacks: toolCallbacks},
+	}
+}
+
+// sseBody 将多个 JSON 片段拼成流式响应体
+func sseBody(chunks ...string) string {
+	var body strings.Builder
+	for _, chunk := range chunks {
+		body.WriteString("data: " + chunk + "\n\n")
+	}
+	body.WriteString("data: " + sseDoneMarker + "\n\n")
+	return body.String()
+}
+
+// fakeWeatherTool 记录调用参数的工具回调
+type fakeWeatherTool struct {
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (tool *fakeWeatherTool) GetToolDefinition() ToolDefinition {
+	return ToolDefinition{
+		Name:        "get_weather",
+		Description: "查询城市天气",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+	}
+}
+
+func (tool *fakeWeatherTool) Call(_ context.Context, toolInput string) (string, error) {
+	tool.mu.Lock()
+	defer tool.mu.Unlock()
+	tool.inputs = append(tool.inputs, toolInput)
+	return "晴 25℃", nil
+}
+
+// collectStream 读取流式响应，返回拼接后的文本、累计用量和异常
+func collectStream(t *testing.T, chunks <-chan ChatStreamChunk) (string, Usage, error) {
+	t.Helper()
+	var (
+		content strings.Builder
+		usage   Usage
+	)
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return content.String(), usage, chunk.Err
+		}
+		if result := chunk.Response.GetResult(); result != nil {
+			if len(result.Message.ToolCalls) > 0 {
+				t.Errorf("工具调用片段不应转发给调用方: %+v", result.Message.ToolCalls)
+			}
+			content.WriteString(result.Message.Content)
+		}
+		usage.Add(chunk.Response.Usage)
+	}
+	return content.String(), usage, nil
+}
+
+func TestOpenAiChatModelCall(t *testing.T) {
+	provider := newFakeOpenAiProvider(t, `{
+		"id": "chatcmpl-1",
+		"model": "gpt-test",
+		"choices": [{"index": 0, "message": {"role": "assistant", "content": "你好"}, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 12, "completion_tokens": 3, "total_tokens": 15, "prompt_tokens_details": {"cached_tokens": 4}}
+	}`)
+
+	temperature := 0.2
+	prompt := NewPrompt(NewSystemMessage("你是助手"), NewUserMessage("hi"))
+	prompt.Options = &OpenAiChatOptions{Temperature: &temperature, MaxTokens: 64}
+	response, err := provider.chatModel("test-key").Call(context.Background(), prompt)
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+
+	if response.GetText() != "你好" || response.GetResult().FinishReason != "stop" {
+		t.Errorf("响应 = %+v", response.GetResult())
+	}
+	if want := (Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15, CachedTokens: 4}); response.Usage != want {
+		t.Errorf("用量 = %+v, 期望 %+v", response.Usage, want)
+	}
+
+	requests := provider.recordedRequests()
+	if len(requests) != 1 {
+		t.Fatalf("请求次数 = %d, 期望 1", len(requests))
+	}
+	request := requests[0]
+	if request.Model != "gpt-test" || request.Stream || request.MaxTokens != 64 || request.Temperature == nil || *request.Temperature != temperature {
+		t.Errorf("请求参数 = %+v", request)
+	}
+	if len(request.Messages) != 2 || request.Messages[0].Role != MessageRoleSystem || *request.Messages[1].Content != "hi" {
+		t.Errorf("请求消息 = %+v", request.Messages)
+	}
+}
+
+func TestOpenAiChatModelCallApiError(t *testing.T) {
+	provider := newFakeOpenAiProvider(t)
+
+	_, err := provider.chatModel("wrong-key").Call(context.Background(), NewPrompt(NewUserMessage("hi")))
+	var apiErr *ChatModelApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("期望 ChatModelApiError, 实际 %v", err)
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "invalid_api_key" || apiErr.Message != "invalid api key" {
+		t.Errorf("错误 = %+v", apiErr)
+	}
+}
+
+func TestOpenAiChatModelCallToolLoop(t *testing.T) {
+	provider := newFakeOpenAiProvider(t,
+		`{"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [
+			{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"杭州\"}"}},
+			{"id": "call_2", "type": "function", "function": {"name": "unknown_tool", "arguments": "{}"}}
+		]}, "finish_reason": "tool_calls"}], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`,
+		`{"choices": [{"message": {"role": "assistant", "content": "杭州晴 25℃"}, "finish_reason": "stop"}], "usage": {"prompt_tokens": 30, "completion_tokens": 6, "total_tokens": 36}}`,
+	)
+	tool := &fakeWeatherTool{}
+
+	response, err := provider.chatModel("test-key", tool).Call(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if err != nil {
+		t.Fatalf("Call 失败: %v", err)
+	}
+	if response.GetText() != "杭州晴 25℃" {
+		t.Errorf("最终回复 = %s", response.GetText())
+	}
+	if want := (Usage{PromptTokens: 40, CompletionTokens: 11, TotalTokens: 51}); response.Usage != want {
+		t.Errorf("用量 = %+v, 期望按轮累加 %+v", response.Usage, want)
+	}
+	if len(tool.inputs) != 1 || tool.inputs[0] != `{"city":"杭州"}` {
+		t.Errorf("工具调用参数 = %v", tool.inputs)
+	}
+
+	requests := provider.recordedRequests()
+	if len(requests) != 2 {
+		t.Fatalf("请求次数 = %d, 期望 2", len(requests))
+	}
+	if len(requests[0].Tools) != 1 || requests[0].Tools[0].Function.Name != "get_weather" {
+		t.Errorf("工具定义 = %+v", requests[0].Tools)
+	}
+
+	// 第二轮请求携带助手的工具调用和两条工具结果，不存在的工具以错误信息回传
+	messages := requests[1].Messages
+	if len(messages) != 4 {
+		t.Fatalf("第二轮消息数量 = %d, 期望 4", len(messages))
+	}
+	if messages[1].Role != MessageRoleAssistant || messages[1].Content != nil || len(messages[1].ToolCalls) != 2 {
+		t.Errorf("助手工具调用消息 = %+v", messages[1])
+	}
+	if messages[2].Role != MessageRoleTool || messages[2].ToolCallID != "call_1" || *messages[2].Content != "晴 25℃" {
+		t.Errorf("工具结果消息 = %+v", messages[2])
+	}
+	if messages[3].ToolCallID != "call_2" || !strings.HasPrefix(*messages[3].Content, "Error:") {
+		t.Errorf("不存在工具的结果消息 = %+v", messages[3])
+	}
+}
+
+func TestOpenAiChatModelCallToolIterationsExceeded(t *testing.T) {
+	toolCallResponse := `{"choices": [{"message": {"role": "assistant", "tool_calls": [
+		{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{}"}}
+	]}, "finish_reason": "tool_calls"}]}`
+	provider := newFakeOpenAiProvider(t, toolCallResponse, toolCallResponse, toolCallResponse)
+	model := provider.chatModel("test-key", &fakeWeatherTool{})
+	model.DefaultOptions.MaxToolIterations = 2
+
+	_, err := model.Call(context.Background(), NewPrompt(NewUserMessage("hi")))
+	var exceeded *ErrToolIterationsExceeded
+	if !errors.As(err, &exceeded) || exceeded.MaxIterations != 2 {
+		t.Fatalf("期望 ErrToolIterationsExceeded, 实际 %v", err)
+	}
+	if requests := provider.recordedRequests(); len(requests) != 3 {
+		t.Errorf("请求次数 = %d, 期望 3", len(requests))
+	}
+}
+
+func TestOpenAiChatModelStream(t *testing.T) {
+	provider := newFakeOpenAiProvider(t, sseBody(
+		`{"choices": [{"index": 0, "delta": {"role": "assistant", "content": "你"}}]}`,
+		`{"choices": [{"index": 0, "delta": {"content": "好"}, "finish_reason": "stop"}]}`,
+		`{"choices": [], "usage": {"prompt_tokens": 8, "completion_tokens": 2, "total_tokens": 10}}`,
+	))
+
+	chunks, err := provider.chatModel("test-key").Stream(context.Background(), NewPrompt(NewUserMessage("hi")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, usage, err := collectStream(t, chunks)
+	if err != nil {
+		t.Fatalf("读取流失败: %v", err)
+	}
+	if content != "你好" {
+		t.Errorf("流式文本 = %s", content)
+	}
+	if usage.TotalTokens != 10 {
+		t.Errorf("流式用量 = %+v", usage)
+	}
+
+	request := provider.recordedRequests()[0]
+	if !request.Stream || request.StreamOptions == nil || !request.StreamOptions.IncludeUsage {
+		t.Errorf("流式请求参数 = %+v", request)
+	}
+}
+
+func TestOpenAiChatModelStreamToolCallDeltas(t *testing.T) {
+	provider := newFakeOpenAiProvider(t,
+		// 两个工具调用的参数分散在多个片段中，按 index 聚合
+		sseBody(
+			`{"choices": [{"index": 0, "delta": {"role": "assistant", "content": "查询中", "tool_calls": [{"index": 0, "id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": ""}}]}}]}`,
+			`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": "{\"city\":"}}]}}]}`,
+			`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 1, "id": "call_2", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"上海\"}"}}]}}]}`,
+			`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": "\"杭州\"}"}}]}, "finish_reason": "tool_calls"}]}`,
+			`{"choices": [], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`,
+		),
+		sseBody(
+			`{"choices": [{"index": 0, "delta": {"role": "assistant", "content": "都是晴天"}, "finish_reason": "stop"}]}`,
+			`{"choices": [], "usage": {"prompt_tokens": 30, "completion_tokens": 4, "total_tokens": 34}}`,
+		),
+	)
+	tool := &fakeWeatherTool{}
+
+	chunks, err := provider.chatModel("test-key", tool).Stream(context.Background(), NewPrompt(NewUserMessage("杭州和上海天气")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, usage, err := collectStream(t, chunks)
+	if err != nil {
+		t.Fatalf("读取流失败: %v", err)
+	}
+	if content != "查询中都是晴天" {
+		t.Errorf("流式文本 = %s", content)
+	}
+	if usage.TotalTokens != 49 {
+		t.Errorf("流式用量合计 = %d, 期望 49", usage.TotalTokens)
+	}
+	if want := []string{`{"city":"杭州"}`, `{"city":"上海"}`}; len(tool.inputs) != 2 || tool.inputs[0] != want[0] || tool.inputs[1] != want[1] {
+		t.Errorf("工具调用参数 = %v, 期望 %v", tool.inputs, want)
+	}
+
+	requests := provider.recordedRequests()
+	if len(requests) != 2 {
+		t.Fatalf("请求次数 = %d, 期望 2", len(requests))
+	}
+	assistant := requests[1].Messages[1]
+	if assistant.Content == nil || *assistant.Content != "查询中" || len(assistant.ToolCalls) != 2 {
+		t.Fatalf("聚合后的助手消息 = %+v", assistant)
+	}
+	if assistant.ToolCalls[0].ID != "call_1" || assistant.ToolCalls[0].Function.Arguments != `{"city":"杭州"}` {
+		t.Errorf("聚合后的工具调用 = %+v", assistant.ToolCalls[0])
+	}
+	if toolMessages := requests[1].Messages[2:]; len(toolMessages) != 2 || toolMessages[1].ToolCallID != "call_2" {
+		t.Errorf("工具结果消息 = %+v", toolMessages)
+	}
+}
+
+func TestRateLimitedChatModelCountsEachToolLoopRequest(t *testing.T) {
+	toolCallResponse := `{"choices": [{"message": {"role": "assistant", "tool_calls": [
+		{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{}"}}
+	]}, "finish_reason": "tool_calls"}]}`
+	provider := newFakeOpenAiProvider(t, toolCallResponse,
+		`{"choices": [{"message": {"role": "assistant", "content": "晴"}, "finish_reason": "stop"}]}`)
+
+	// 每分钟 1 次请求，工具调用循环的第二轮请求应被限流
+	chatModel := &RateLimitedChatModel{
+		ChatModel: provider.chatModel("test-key", &fakeWeatherTool{}),
+		Limiter:   NewRateLimiter("test", 1, 0, 0, 50*time.Millisecond),
+	}
+	_, err := chatModel.Call(context.Background(), NewPrompt(NewUserMessage("杭州天气")))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("错误 = %v, 期望 ErrRateLimited", err)
+	}
+	if requests := provider.recordedRequests(); len(requests) != 1 {
+		t.Errorf("请求次数 = %d, 期望 1", len(requests))
+	}
+}
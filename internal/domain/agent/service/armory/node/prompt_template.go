@@ -0,0 +1,45 @@
+package node
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// promptVariablePattern 提示词变量占位符，如 {{user_name}}
+var promptVariablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.\-]+)\s*\}\}`)
+
+// RenderPromptTemplate 替换提示词中的 {{变量}}，lookup 找不到的变量保留原样，便于后续阶段继续替换
+func RenderPromptTemplate(template string, lookup func(name string) (string, bool)) string {
+	return promptVariablePattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := promptVariablePattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := lookup(name); ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// PromptVariablesLookup 从 map 取变量值，只接受字符串、数字和布尔等标量
+func PromptVariablesLookup(variables map[string]any) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := variables[name]
+		if !ok {
+			return "", false
+		}
+		return promptScalar(value)
+	}
+}
+
+// promptScalar 标量转为字符串
+func promptScalar(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case fmt.Stringer:
+		return v.String(), true
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,124 @@
+package node
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"smart-weaver/internal/config"
+)
+
+// 知识库问答顾问上下文键
+const (
+	// AdviseContextRetrievedDocuments 检索到并注入提示词的文档，类型 []config.Document
+	AdviseContextRetrievedDocuments = "qa_retrieved_documents"
+	// AdviseContextFilterExpression 本次请求的元数据过滤条件，类型 map[string]any，覆盖顾问默认配置
+	AdviseContextFilterExpression = "qa_filter_expression"
+)
+
+// questionAnswerContextPlaceholder 模板中检索内容的占位符
+const questionAnswerContextPlaceholder = "{question_answer_context}"
+
+// DefaultQuestionAnswerUserTextAdvise 默认注入模板，追加在用户消息之后
+const DefaultQuestionAnswerUserTextAdvise = `
+Context information is below, surrounded by ---------------------
+
+---------------------
+{question_answer_context}
+---------------------
+
+Given the context and provided history information and not prior knowledge,
+reply to the user comment. If the answer is not in the context, inform
+the user that you can't answer the question.
+`
+
+// SearchRequest 向量检索参数
+type SearchRequest struct {
+	TopK                int
+	SimilarityThreshold float64        // <=0 表示不过滤
+	FilterExpression    map[string]any // 元数据过滤，如 {"knowledge": "xxx"}
+}
+
+// QuestionAnswerAdvisor 知识库问答顾问，按用户消息检索向量库并将结果注入提示词（模拟Java中的QuestionAnswerAdvisor）
+type QuestionAnswerAdvisor struct {
+	name           string
+	order          int
+	vectorStore    config.VectorStore
+	searchRequest  SearchRequest
+	userTextAdvise string
+
+	// EmbeddingModel 计算查询向量的嵌入模型，为空时由向量存储计算
+	EmbeddingModel config.EmbeddingModel
+}
+
+// NewQuestionAnswerAdvisor 创建知识库问答顾问，userTextAdvise 为空时使用默认模板
+func NewQuestionAnswerAdvisor(name string, order int, vectorStore config.VectorStore, searchRequest SearchRequest, userTextAdvise string) *QuestionAnswerAdvisor {
+	if userTextAdvise == "" {
+		userTextAdvise = DefaultQuestionAnswerUserTextAdvise
+	}
+	return &QuestionAnswerAdvisor{
+		name:           name,
+		order:          order,
+		vectorStore:    vectorStore,
+		searchRequest:  searchRequest,
+		userTextAdvise: userTextAdvise,
+	}
+}
+
+// Name 顾问名称
+func (a *QuestionAnswerAdvisor) Name() string {
+	return a.name
+}
+
+// Order 执行顺序
+func (a *QuestionAnswerAdvisor) Order() int {
+	return a.order
+}
+
+// AdviseRequest 检索与用户消息相关的文档并注入用户消息，检索结果记录到上下文
+func (a *QuestionAnswerAdvisor) AdviseRequest(ctx stdcontext.Context, request *AdvisedRequest) (*AdvisedRequest, error) {
+	filter := a.searchRequest.FilterExpression
+	if override, ok := request.AdviseContext[AdviseContextFilterExpression].(map[string]any); ok {
+		filter = override
+	}
+
+	docs, err := a.similaritySearch(ctx, request.UserText, filter)
+	if err != nil {
+		return nil, fmt.Errorf("顾问 %s 检索知识库失败: %w", a.name, err)
+	}
+
+	contents := make([]string, 0, len(docs))
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		contents = append(contents, doc.Text)
+		ids = append(ids, doc.ID)
+	}
+	log.Printf("顾问 %s 检索到 %d 个文档 %v", a.name, len(docs), ids)
+
+	advised := *request
+	advised.UserText = request.UserText + "\n" + strings.ReplaceAll(a.userTextAdvise, questionAnswerContextPlaceholder, strings.Join(contents, "\n"))
+	advised.AdviseContext[AdviseContextRetrievedDocuments] = docs
+	return &advised, nil
+}
+
+// similaritySearch 配置了嵌入模型时先计算查询向量再检索
+func (a *QuestionAnswerAdvisor) similaritySearch(ctx stdcontext.Context, query string, filter map[string]any) ([]config.Document, error) {
+	if a.EmbeddingModel == nil {
+		return a.vectorStore.SimilaritySearch(ctx, query, a.searchRequest.TopK, a.searchRequest.SimilarityThreshold, filter)
+	}
+	embeddings, err := a.EmbeddingModel.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("计算查询向量失败: %w", err)
+	}
+	if len(embeddings) == 0 {
+		return nil, errors.New("嵌入模型未返回查询向量")
+	}
+	return a.vectorStore.SimilaritySearchByVector(ctx, embeddings[0], a.searchRequest.TopK, a.searchRequest.SimilarityThreshold, filter)
+}
+
+// AdviseResponse 检索结果已记录在共享上下文中，调用方可从响应上下文取出展示引用来源
+func (a *QuestionAnswerAdvisor) AdviseResponse(ctx stdcontext.Context, response *AdvisedResponse) (*AdvisedResponse, error) {
+	return response, nil
+}
@@ -0,0 +1,307 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// defaultQueueTimeout 未配置时超过限流后排队等待的最长时间
+const defaultQueueTimeout = 10 * time.Second
+
+// ErrRateLimited 请求超过模型限流，排队超时后返回
+var ErrRateLimited = errors.New("请求超过模型限流")
+
+// RateLimiter 模型限流器，每分钟请求数、每分钟 token 数按令牌桶限制，并发请求数按信号量限制
+// 超过限制的请求排队等待，预计等待时间超过排队上限时立即拒绝
+type RateLimiter struct {
+	Name           string
+	Rpm            int // 每分钟请求数，0 表示不限制
+	Tpm            int // 每分钟 token 数，0 表示不限制
+	MaxConcurrency int // 最大并发请求数，0 表示不限制
+	QueueTimeout   time.Duration
+
+	requests *tokenBucket
+	tokens   *tokenBucket
+	slots    chan struct{}
+	clock    limiterClock
+}
+
+// NewRateLimiter 创建限流器，queueTimeout 小于等于0时使用默认值
+func NewRateLimiter(name string, rpm, tpm, maxConcurrency int, queueTimeout time.Duration) *RateLimiter {
+	return newRateLimiter(name, rpm, tpm, maxConcurrency, queueTimeout, systemClock{})
+}
+
+// newRateLimiter 创建使用指定时钟的限流器
+func newRateLimiter(name string, rpm, tpm, maxConcurrency int, queueTimeout time.Duration, clock limiterClock) *RateLimiter {
+	if queueTimeout <= 0 {
+		queueTimeout = defaultQueueTimeout
+	}
+	limiter := &RateLimiter{
+		Name:           name,
+		Rpm:            rpm,
+		Tpm:            tpm,
+		MaxConcurrency: maxConcurrency,
+		QueueTimeout:   queueTimeout,
+		clock:          clock,
+	}
+	if rpm > 0 {
+		limiter.requests = newTokenBucket(rpm, clock)
+	}
+	if tpm > 0 {
+		limiter.tokens = newTokenBucket(tpm, clock)
+	}
+	if maxConcurrency > 0 {
+		limiter.slots = make(chan struct{}, maxConcurrency)
+	}
+	return limiter
+}
+
+// Acquire 获取一次请求的配额，estimatedTokens 为预估的 token 数
+// 成功时返回 release，请求结束后传入实际使用的 token 数，按与预估的差额修正 token 桶
+func (l *RateLimiter) Acquire(ctx context.Context, estimatedTokens int) (func(usedTokens int), error) {
+	now := l.clock.Now()
+	deadline := now.Add(l.QueueTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	budget := deadline.Sub(now).Round(time.Millisecond)
+
+	// 并发数
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			timeout, stop := l.clock.After(deadline.Sub(l.clock.Now()))
+			defer stop()
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-timeout:
+				return nil, l.rejected(budget, "并发请求数达到上限 %d", l.MaxConcurrency)
+			}
+		}
+	}
+	releaseSlot := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	// 每分钟请求数和 token 数，预留配额后等待两者中较长的时间
+	reservedTokens := 0.0
+	if l.tokens != nil {
+		reservedTokens = math.Min(float64(estimatedTokens), l.tokens.capacity)
+	}
+	var requestWait, tokenWait time.Duration
+	if l.requests != nil {
+		requestWait = l.requests.reserve(1)
+	}
+	if l.tokens != nil {
+		tokenWait = l.tokens.reserve(reservedTokens)
+	}
+	refund := func() {
+		if l.requests != nil {
+			l.requests.adjust(1)
+		}
+		if l.tokens != nil {
+			l.tokens.adjust(reservedTokens)
+		}
+	}
+
+	if wait := max(requestWait, tokenWait); wait > 0 {
+		if l.clock.Now().Add(wait).After(deadline) {
+			refund()
+			releaseSlot()
+			if requestWait >= tokenWait {
+				return nil, l.rejected(budget, "每分钟请求数达到上限 %d", l.Rpm)
+			}
+			return nil, l.rejected(budget, "每分钟 token 数达到上限 %d", l.Tpm)
+		}
+		ready, stop := l.clock.After(wait)
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			stop()
+			refund()
+			releaseSlot()
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func(usedTokens int) {
+		once.Do(func() {
+			if l.tokens != nil && usedTokens > 0 {
+				l.tokens.adjust(reservedTokens - float64(usedTokens))
+			}
+			releaseSlot()
+		})
+	}, nil
+}
+
+// rejected 限流拒绝错误，budget 为本次请求可排队的时间
+func (l *RateLimiter) rejected(budget time.Duration, format string, args ...any) error {
+	return fmt.Errorf("%w: 模型 %s %s，排队超过 %s", ErrRateLimited, l.Name, fmt.Sprintf(format, args...), budget)
+}
+
+// tokenBucket 令牌桶，容量为每分钟配额，按秒均匀补充
+// 预留时允许余额为负，负数部分即排在前面的请求需要等待的配额
+type tokenBucket struct {
+	capacity float64
+	rate     float64 // 每秒补充
+	tokens   float64
+	last     time.Time
+	clock    limiterClock
+	mu       sync.Mutex
+}
+
+// newTokenBucket 创建令牌桶，初始为满
+func newTokenBucket(perMinute int, clock limiterClock) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+		last:     clock.Now(),
+		clock:    clock,
+	}
+}
+
+// reserve 预留 n 个令牌，返回需要等待的时间
+func (b *tokenBucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// adjust 归还（delta 为正）或追加扣除（delta 为负）令牌
+func (b *tokenBucket) adjust(delta float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens = math.Min(b.capacity, b.tokens+delta)
+}
+
+// refill 按经过的时间补充令牌，调用方需持有锁
+func (b *tokenBucket) refill() {
+	now := b.clock.Now()
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// limiterClock 限流器使用的时钟，测试中替换为手动推进的时钟
+type limiterClock interface {
+	Now() time.Time
+	// After 返回 d 之后收到当前时间的 channel 及停止函数
+	After(d time.Duration) (<-chan time.Time, func())
+}
+
+// systemClock 系统时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+// rateLimiterKey 限流器在请求上下文中的键
+type rateLimiterKey struct{}
+
+// withRateLimiter 将限流器放入请求上下文，由工具调用循环在每次上游请求前获取配额
+func withRateLimiter(ctx context.Context, limiter *RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, limiter)
+}
+
+// takeRateLimiter 取出请求上下文中的限流器，返回的上下文不再携带限流器，避免工具内部的模型调用占用本模型配额
+func takeRateLimiter(ctx context.Context) (context.Context, *RateLimiter) {
+	limiter, _ := ctx.Value(rateLimiterKey{}).(*RateLimiter)
+	if limiter == nil {
+		return ctx, nil
+	}
+	return withRateLimiter(ctx, nil), limiter
+}
+
+// RateLimitedChatModel 带限流的对话模型，工具调用循环内的每次上游请求单独获取配额
+type RateLimitedChatModel struct {
+	ChatModel
+	Limiter *RateLimiter
+}
+
+// Call 同步调用，每次上游请求前获取配额
+func (m *RateLimitedChatModel) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	return m.ChatModel.Call(withRateLimiter(ctx, m.Limiter), prompt)
+}
+
+// Stream 流式调用，每次上游请求前获取配额，该次请求的流结束时释放
+func (m *RateLimitedChatModel) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	return m.ChatModel.Stream(withRateLimiter(ctx, m.Limiter), prompt)
+}
+
+// rateLimitedCall 获取配额后执行单次同步请求，limiter 为空时不限流
+func rateLimitedCall(limiter *RateLimiter, callOnce chatCallFunc) chatCallFunc {
+	if limiter == nil {
+		return callOnce
+	}
+	return func(ctx context.Context, messages []Message, options *OpenAiChatOptions) (*ChatResponse, error) {
+		release, err := limiter.Acquire(ctx, estimateTokens("", messages))
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := callOnce(ctx, messages, options)
+		usedTokens := 0
+		if response != nil {
+			usedTokens = response.Usage.TotalTokens
+		}
+		release(usedTokens)
+		return response, err
+	}
+}
+
+// rateLimitedStream 获取配额后执行单次流式请求，流结束时释放配额，limiter 为空时不限流
+func rateLimitedStream(limiter *RateLimiter, streamOnce chatStreamFunc) chatStreamFunc {
+	if limiter == nil {
+		return streamOnce
+	}
+	return func(ctx context.Context, messages []Message, options *OpenAiChatOptions) (<-chan ChatStreamChunk, error) {
+		release, err := limiter.Acquire(ctx, estimateTokens("", messages))
+		if err != nil {
+			return nil, err
+		}
+
+		upstream, err := streamOnce(ctx, messages, options)
+		if err != nil {
+			release(0)
+			return nil, err
+		}
+
+		chunks := make(chan ChatStreamChunk)
+		go func() {
+			var usage Usage
+			defer func() { release(usage.TotalTokens) }()
+			defer close(chunks)
+			for chunk := range upstream {
+				if chunk.Response != nil {
+					usage.Add(chunk.Response.Usage)
+				}
+				select {
+				case chunks <- chunk:
+				case <-ctx.Done():
+					// 继续读取直到上游关闭
+				}
+			}
+		}()
+		return chunks, nil
+	}
+}
@@ -0,0 +1,201 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// acquireResult 异步获取配额的结果
+type acquireResult struct {
+	release func(usedTokens int)
+	err     error
+}
+
+// acquireAsync 在新的 goroutine 中获取配额
+func acquireAsync(ctx context.Context, limiter *RateLimiter, estimatedTokens int) <-chan acquireResult {
+	result := make(chan acquireResult, 1)
+	go func() {
+		release, err := limiter.Acquire(ctx, estimatedTokens)
+		result <- acquireResult{release: release, err: err}
+	}()
+	return result
+}
+
+// receiveAcquire 等待异步获取配额的结果
+func receiveAcquire(t *testing.T, result <-chan acquireResult) acquireResult {
+	t.Helper()
+	select {
+	case r := <-result:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("获取配额未返回")
+		return acquireResult{}
+	}
+}
+
+// assertPending 断言获取配额仍在等待
+func assertPending(t *testing.T, result <-chan acquireResult) {
+	t.Helper()
+	select {
+	case r := <-result:
+		t.Fatalf("获取配额应继续等待, 实际返回 %+v", r)
+	default:
+	}
+}
+
+// drainRequests 立即用完每分钟请求数
+func drainRequests(t *testing.T, limiter *RateLimiter) {
+	t.Helper()
+	for i := 0; i < limiter.Rpm; i++ {
+		release, err := limiter.Acquire(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("第 %d 次获取配额失败: %v", i+1, err)
+		}
+		release(0)
+	}
+}
+
+func TestRateLimiterTokenBucketRefill(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter("test", 60, 0, 0, 500*time.Millisecond, clock)
+	drainRequests(t, limiter)
+
+	// 每秒补充 1 个，预计等待 1 秒超过排队上限，立即拒绝
+	if _, err := limiter.Acquire(context.Background(), 0); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("配额用完后错误 = %v, 期望 ErrRateLimited", err)
+	}
+
+	clock.Advance(time.Second)
+	release, err := limiter.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("补充 1 秒后获取配额失败: %v", err)
+	}
+	release(0)
+
+	// 补充不超过容量
+	clock.Advance(10 * time.Minute)
+	drainRequests(t, limiter)
+	if _, err := limiter.Acquire(context.Background(), 0); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("补充后超过容量的请求错误 = %v, 期望 ErrRateLimited", err)
+	}
+}
+
+func TestRateLimiterWaitsForToken(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter("test", 60, 0, 0, 10*time.Second, clock)
+	drainRequests(t, limiter)
+
+	result := acquireAsync(context.Background(), limiter, 0)
+	clock.waitForTimers(t, 1)
+	assertPending(t, result)
+
+	clock.Advance(999 * time.Millisecond)
+	assertPending(t, result)
+
+	clock.Advance(time.Millisecond)
+	if r := receiveAcquire(t, result); r.err != nil {
+		t.Fatalf("等待令牌后获取配额失败: %v", r.err)
+	}
+}
+
+func TestRateLimiterContextCanceledWhileWaiting(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter("test", 60, 0, 0, 10*time.Second, clock)
+	drainRequests(t, limiter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := acquireAsync(ctx, limiter, 0)
+	clock.waitForTimers(t, 1)
+	cancel()
+
+	if r := receiveAcquire(t, result); !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("取消后错误 = %v, 期望 context.Canceled", r.err)
+	}
+
+	// 取消的请求归还预留的令牌，1 秒后补充的令牌可立即使用
+	clock.Advance(time.Second)
+	release, err := limiter.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("取消后未归还令牌: %v", err)
+	}
+	release(0)
+}
+
+func TestRateLimiterConcurrentAcquirersServedInOrder(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter("test", 60, 0, 0, 10*time.Second, clock)
+	drainRequests(t, limiter)
+
+	// 每个等待者预留一个令牌，分别等待 1 到 5 秒，每补充 1 秒只放行一个
+	const waiters = 5
+	done := make(chan acquireResult, waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			release, err := limiter.Acquire(context.Background(), 0)
+			done <- acquireResult{release: release, err: err}
+		}()
+	}
+	clock.waitForTimers(t, waiters)
+
+	for i := 0; i < waiters; i++ {
+		assertPending(t, done)
+		clock.Advance(time.Second)
+		if r := receiveAcquire(t, done); r.err != nil {
+			t.Fatalf("第 %d 个等待者获取配额失败: %v", i+1, r.err)
+		}
+	}
+	assertPending(t, done)
+}
+
+func TestRateLimiterMaxConcurrency(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter("test", 0, 0, 1, 10*time.Second, clock)
+
+	release, err := limiter.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("获取配额失败: %v", err)
+	}
+
+	// 并发数已满，等待释放
+	waiting := acquireAsync(context.Background(), limiter, 0)
+	clock.waitForTimers(t, 1)
+	assertPending(t, waiting)
+	release(0)
+	r := receiveAcquire(t, waiting)
+	if r.err != nil {
+		t.Fatalf("释放后获取配额失败: %v", r.err)
+	}
+
+	// 排队超过上限时拒绝
+	rejected := acquireAsync(context.Background(), limiter, 0)
+	clock.waitForTimers(t, 1)
+	clock.Advance(10 * time.Second)
+	if r := receiveAcquire(t, rejected); !errors.Is(r.err, ErrRateLimited) {
+		t.Fatalf("排队超时错误 = %v, 期望 ErrRateLimited", r.err)
+	}
+	r.release(0)
+}
+
+func TestRateLimiterAdjustsTokensByUsage(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter("test", 0, 600, 0, 500*time.Millisecond, clock)
+
+	// 预估 100，实际 300，按差额追加扣除
+	release, err := limiter.Acquire(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("获取配额失败: %v", err)
+	}
+	release(300)
+	release(300) // 重复释放无效
+
+	release, err = limiter.Acquire(context.Background(), 300)
+	if err != nil {
+		t.Fatalf("剩余 300 token 时获取配额失败: %v", err)
+	}
+	release(300)
+	if _, err := limiter.Acquire(context.Background(), 20); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("token 用完后错误 = %v, 期望 ErrRateLimited", err)
+	}
+}
@@ -2,6 +2,7 @@ package node
 
 import (
 	"log"
+	"smart-weaver/internal/domain/agent/adapter/repository"
 	"smart-weaver/internal/domain/agent/model/entity"
 	"smart-weaver/internal/domain/agent/model/valobj"
 	"smart-weaver/internal/domain/agent/service/armory"
@@ -9,11 +10,8 @@ import (
 	"sync"
 )
 
-// Repository 接口定义
-type Repository interface {
-	QueryAiClientModelVOListByClientIds(clientIds []int64) ([]valobj.AiClientModelVO, error)
-	QueryAiClientToolMcpVOListByClientIds(clientIds []int64) ([]valobj.AiClientToolMcpVO, error)
-}
+// Repository 配置仓储，与领域仓储接口一致
+type Repository = repository.IAgentRepository
 
 // RootNode 根节点
 type RootNode struct {
@@ -22,6 +20,15 @@ type RootNode struct {
 	repository        Repository
 }
 
+// NewRootNode 创建RootNode实例，support 为各节点共享的生成器，next 为查询配置后执行的第一个节点（Tool MCP 节点）
+func NewRootNode(support *armory.AbstractArmorySupport, next StrategyHandler, repository Repository) *RootNode {
+	return &RootNode{
+		AbstractArmorySupport: support,
+		aiClientModelNode:     next,
+		repository:            repository,
+	}
+}
+
 // MultiThread 覆盖父类的多线程方法
 func (r *RootNode) MultiThread(req any, ctx any) error {
 	requestParameter, ok := req.(*entity.AiAgentEngineStarterEntity)
@@ -40,9 +47,12 @@ func (r *RootNode) MultiThread(req any, ctx any) error {
 	var mu sync.Mutex
 
 	// 存储结果的变量
+	var aiClientList []valobj.AiClientVO
 	var aiClientModelList []valobj.AiClientModelVO
 	var aiClientToolMcpList []valobj.AiClientToolMcpVO
-	var err1, err2 error
+	var aiClientAdvisorList []valobj.AiClientAdvisorVO
+	var aiClientSystemPromptList []valobj.AiClientSystemPromptVO
+	var err1, err2, err3, err4, err5 error
 
 	// 异步查询 ai_client_model 数据
 	wg.Add(1)
@@ -70,6 +80,45 @@ func (r *RootNode) MultiThread(req any, ctx any) error {
 		mu.Unlock()
 	})
 
+	// 异步查询 ai_client_advisor 数据
+	wg.Add(1)
+	r.SubmitTask(func() {
+		defer wg.Done()
+		log.Printf("查询配置数据(ai_client_advisor) %v", requestParameter.ClientIDList)
+		list, err := r.repository.QueryAiClientAdvisorVOListByClientIds(requestParameter.ClientIDList)
+
+		mu.Lock()
+		aiClientAdvisorList = list
+		err3 = err
+		mu.Unlock()
+	})
+
+	// 异步查询 ai_client 数据
+	wg.Add(1)
+	r.SubmitTask(func() {
+		defer wg.Done()
+		log.Printf("查询配置数据(ai_client) %v", requestParameter.ClientIDList)
+		list, err := r.repository.QueryAiClientVOListByClientIds(requestParameter.ClientIDList)
+
+		mu.Lock()
+		aiClientList = list
+		err4 = err
+		mu.Unlock()
+	})
+
+	// 异步查询 ai_client_system_prompt 数据
+	wg.Add(1)
+	r.SubmitTask(func() {
+		defer wg.Done()
+		log.Printf("查询配置数据(ai_client_system_prompt) %v", requestParameter.ClientIDList)
+		list, err := r.repository.QueryAiClientSystemPromptVOListByClientIds(requestParameter.ClientIDList)
+
+		mu.Lock()
+		aiClientSystemPromptList = list
+		err5 = err
+		mu.Unlock()
+	})
+
 	// 等待所有任务完成
 	wg.Wait()
 
@@ -82,10 +131,25 @@ func (r *RootNode) MultiThread(req any, ctx any) error {
 		log.Printf("Error querying ai_client_tool_mcp: %v", err2)
 		return err2
 	}
+	if err3 != nil {
+		log.Printf("Error querying ai_client_advisor: %v", err3)
+		return err3
+	}
+	if err4 != nil {
+		log.Printf("Error querying ai_client: %v", err4)
+		return err4
+	}
+	if err5 != nil {
+		log.Printf("Error querying ai_client_system_prompt: %v", err5)
+		return err5
+	}
 
 	// 设置结果到动态上下文
 	dynamicContext.SetValue("aiClientModelList", aiClientModelList)
 	dynamicContext.SetValue("aiClientToolMcpList", aiClientToolMcpList)
+	dynamicContext.SetValue("aiClientAdvisorList", aiClientAdvisorList)
+	dynamicContext.SetValue("aiClientList", aiClientList)
+	dynamicContext.SetValue("aiClientSystemPromptList", aiClientSystemPromptList)
 
 	return nil
 }
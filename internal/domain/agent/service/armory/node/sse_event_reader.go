@@ -0,0 +1,90 @@
+package node
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseEvent Server-Sent Events 事件
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// readSseEvents 按 text/event-stream 规范解析事件流，handle 返回 false 时停止读取
+// 流正常结束时返回 nil
+func readSseEvents(r io.Reader, handle func(event *sseEvent) bool) error {
+	reader := bufio.NewReader(r)
+
+	var (
+		event   sseEvent
+		data    strings.Builder
+		hasData bool
+	)
+
+	dispatch := func() bool {
+		defer func() {
+			event = sseEvent{}
+			data.Reset()
+			hasData = false
+		}()
+		if !hasData && event.Event == "" && event.ID == "" {
+			return true
+		}
+		event.Data = strings.TrimSuffix(data.String(), "\n")
+		return handle(&event)
+	}
+
+	processLine := func(line string) bool {
+		if line == "" {
+			return dispatch()
+		}
+		if strings.HasPrefix(line, ":") {
+			// 注释行，常用于心跳
+			return true
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				event.ID = value
+			}
+		case "retry":
+			if ms, convErr := strconv.Atoi(value); convErr == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+		return true
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if !processLine(strings.TrimRight(line, "\r\n")) {
+				return nil
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// 流结束时分发尚未以空行结尾的事件
+				dispatch()
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package node
+
+import (
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// 结构化输出格式类型
+const (
+	ResponseFormatTypeJSONObject = "json_object" // 只要求输出 JSON 对象
+	ResponseFormatTypeJSONSchema = "json_schema" // 要求输出符合 Schema 的 JSON 对象
+)
+
+const (
+	// defaultStructuredOutputRetries 未配置时结构化输出校验失败后的最大重试次数
+	defaultStructuredOutputRetries = 2
+	// defaultResponseFormatName 无法从类型推导名称时使用的 Schema 名称
+	defaultResponseFormatName = "structured_output"
+)
+
+// responseFormatNamePattern Schema 名称允许的字符
+var responseFormatNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ResponseFormat 结构化输出格式（模拟Java中的ResponseFormat）
+// 支持的模型以 response_format / format 原生约束输出，Anthropic 以强制调用同名工具的方式约束
+type ResponseFormat struct {
+	Type   string      // json_schema / json_object
+	Name   string      // Schema 名称，只能包含字母、数字、下划线和短横线
+	Schema *JSONSchema // Type 为 json_schema 时必填
+	Strict bool        // 要求模型严格遵循 Schema，Schema 需满足严格模式的限制
+}
+
+// NewResponseFormat 根据 Go 结构体生成 json_schema 格式，名称取类型名
+func NewResponseFormat[T any]() (*ResponseFormat, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	schema, strict, err := GenerateJSONSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return &ResponseFormat{
+		Type:   ResponseFormatTypeJSONSchema,
+		Name:   responseFormatName(t.Name()),
+		Schema: schema,
+		Strict: strict,
+	}, nil
+}
+
+// responseFormatName 清理 Schema 名称中不允许的字符，为空时使用默认名称
+func responseFormatName(name string) string {
+	name = responseFormatNamePattern.ReplaceAllString(name, "_")
+	if name == "" {
+		return defaultResponseFormatName
+	}
+	return name
+}
+
+// toolName 不支持原生结构化输出的模型使用的工具名称
+func (f *ResponseFormat) toolName() string {
+	return responseFormatName(f.Name)
+}
+
+// toolSchema 工具参数 Schema，json_object 格式不限制字段
+func (f *ResponseFormat) toolSchema() json.RawMessage {
+	if f.Type == ResponseFormatTypeJSONSchema && f.Schema != nil {
+		if schema, err := json.Marshal(f.Schema); err == nil {
+			return schema
+		}
+	}
+	return json.RawMessage(`{"type":"object"}`)
+}
+
+// ErrStructuredOutputInvalid 重试后模型仍未返回符合 Schema 的结果
+type ErrStructuredOutputInvalid struct {
+	Attempts int      // 调用模型的次数
+	Errors   []string // 最后一次的校验错误
+	Content  string   // 最后一次的模型输出
+}
+
+func (e *ErrStructuredOutputInvalid) Error() string {
+	return fmt.Sprintf("结构化输出校验失败，共调用模型 %d 次: %s", e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// CallStructured 同步对话并将输出解析为 T（模拟Java中的 ChatClient.call().entity()）
+// 根据 T 生成 JSON Schema 作为结构化输出格式，校验失败时把校验错误发给模型重新生成，最多重试 MaxStructuredRetries 次
+// 顾问请求阶段只执行一次，重试的中间结果不经过顾问响应阶段，返回的响应用量为各次调用之和
+func CallStructured[T any](ctx stdcontext.Context, client *ChatClient, request *ChatClientRequest) (*T, *ChatResponse, error) {
+	responseFormat, err := NewResponseFormat[T]()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	structuredRequest := *request
+	structuredRequest.Options = &OpenAiChatOptions{}
+	if request.Options != nil {
+		*structuredRequest.Options = *request.Options
+	}
+	structuredRequest.Options.ResponseFormat = responseFormat
+
+	advisedRequest, err := client.advise(ctx, &structuredRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	prompt := advisedRequest.ToPrompt()
+	retries := maxStructuredRetries(mergeChatOptions(client.ChatModel.GetDefaultOptions(), prompt))
+
+	var usage Usage
+	for attempt := 0; ; attempt++ {
+		response, err := client.ChatModel.Call(ctx, prompt)
+		if err != nil {
+			client.Advisors.AdviseError(ctx, advisedRequest.AdviseContext, err)
+			return nil, nil, err
+		}
+		usage.Add(response.Usage)
+
+		content := response.GetText()
+		result, validationErrors := parseStructuredOutput[T](responseFormat.Schema, content)
+		if len(validationErrors) == 0 {
+			response.Usage = usage
+			if _, err := client.Advisors.AdviseResponse(ctx, &AdvisedResponse{
+				Response:      response,
+				AdviseContext: advisedRequest.AdviseContext,
+			}); err != nil {
+				return nil, nil, err
+			}
+			return result, response, nil
+		}
+		if attempt >= retries {
+			err := &ErrStructuredOutputInvalid{Attempts: attempt + 1, Errors: validationErrors, Content: content}
+			client.Advisors.AdviseError(ctx, advisedRequest.AdviseContext, err)
+			return nil, nil, err
+		}
+
+		prompt = &Prompt{
+			Messages: append(append([]Message(nil), prompt.Messages...),
+				NewAssistantMessage(content),
+				NewUserMessage(structuredOutputFeedback(validationErrors))),
+			Options: prompt.Options,
+		}
+	}
+}
+
+// maxStructuredRetries 获取结构化输出重试上限
+func maxStructuredRetries(options *OpenAiChatOptions) int {
+	if options == nil || options.MaxStructuredRetries <= 0 {
+		return defaultStructuredOutputRetries
+	}
+	return options.MaxStructuredRetries
+}
+
+// parseStructuredOutput 提取模型输出中的 JSON，按 Schema 校验后解析为 T
+func parseStructuredOutput[T any](schema *JSONSchema, content string) (*T, []string) {
+	data := extractJSON(content)
+	if data == "" {
+		return nil, []string{"response does not contain a JSON object"}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, []string{"response is not valid JSON: " + err.Error()}
+	}
+	if schema != nil {
+		if validationErrors := schema.Validate(value); len(validationErrors) > 0 {
+			return nil, validationErrors
+		}
+	}
+
+	result := new(T)
+	if err := json.Unmarshal([]byte(data), result); err != nil {
+		return nil, []string{"response does not match the expected structure: " + err.Error()}
+	}
+	return result, nil
+}
+
+// extractJSON 提取输出中的 JSON 对象，兼容 ```json 代码块和前后附加说明的情况
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimPrefix(content, "json")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+		content = strings.TrimSpace(content)
+	}
+	if json.Valid([]byte(content)) {
+		return content
+	}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return content[start : end+1]
+}
+
+// structuredOutputFeedback 校验失败后发给模型的修正提示
+func structuredOutputFeedback(validationErrors []string) string {
+	var feedback strings.Builder
+	feedback.WriteString("Your previous response did not match the required JSON schema:\n")
+	for _, validationError := range validationErrors {
+		feedback.WriteString("- ")
+		feedback.WriteString(validationError)
+		feedback.WriteString("\n")
+	}
+	feedback.WriteString("Respond again with only the corrected JSON object, without any explanation or code fences.")
+	return feedback.String()
+}
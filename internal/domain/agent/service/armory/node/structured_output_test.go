@@ -0,0 +1,165 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// weatherReport 结构化输出测试使用的目标类型
+type weatherReport struct {
+	City        string `json:"city" description:"城市名称"`
+	Condition   string `json:"condition" enum:"sunny,cloudy,rainy"`
+	Temperature int    `json:"temperature"`
+}
+
+// fakeStructuredChatModel 依次返回预设文本，每次回复计 10+5 token，记录收到的提示词
+type fakeStructuredChatModel struct {
+	mu      sync.Mutex
+	replies []string
+	prompts []*Prompt
+}
+
+func (m *fakeStructuredChatModel) Call(_ context.Context, prompt *Prompt) (*ChatResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, prompt)
+	if len(m.replies) == 0 {
+		return nil, errors.New("没有预设回复")
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return &ChatResponse{
+		Generations: []Generation{{Message: NewAssistantMessage(reply)}},
+		Usage:       Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (m *fakeStructuredChatModel) Stream(context.Context, *Prompt) (<-chan ChatStreamChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *fakeStructuredChatModel) GetDefaultOptions() *OpenAiChatOptions { return nil }
+
+func TestCallStructuredRetriesWithFeedback(t *testing.T) {
+	chatModel := &fakeStructuredChatModel{replies: []string{
+		`{"city": "杭州", "condition": "windy"}`,
+		"```json\n{\"city\": \"杭州\", \"condition\": \"sunny\", \"temperature\": 25}\n```",
+	}}
+	client := NewChatClientBuilder(chatModel).Build()
+
+	report, response, err := CallStructured[weatherReport](context.Background(), client, &ChatClientRequest{UserText: "杭州天气"})
+	if err != nil {
+		t.Fatalf("CallStructured 失败: %v", err)
+	}
+	if *report != (weatherReport{City: "杭州", Condition: "sunny", Temperature: 25}) {
+		t.Errorf("解析结果 = %+v", report)
+	}
+	if want := (Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30}); response.Usage != want {
+		t.Errorf("用量 = %+v, 期望两次调用之和 %+v", response.Usage, want)
+	}
+
+	if len(chatModel.prompts) != 2 {
+		t.Fatalf("调用次数 = %d, 期望 2", len(chatModel.prompts))
+	}
+	first := chatModel.prompts[0]
+	if first.Options == nil || first.Options.ResponseFormat == nil || first.Options.ResponseFormat.Name != "weatherReport" {
+		t.Errorf("请求应携带结构化输出格式: %+v", first.Options)
+	}
+
+	// 重试时追加上一次的输出和校验错误
+	retry := chatModel.prompts[1].Messages
+	if len(retry) != len(first.Messages)+2 {
+		t.Fatalf("重试消息数量 = %d, 期望 %d", len(retry), len(first.Messages)+2)
+	}
+	previous, feedback := retry[len(retry)-2], retry[len(retry)-1]
+	if previous.Role != MessageRoleAssistant || previous.Content != `{"city": "杭州", "condition": "windy"}` {
+		t.Errorf("上一次输出 = %+v", previous)
+	}
+	if feedback.Role != MessageRoleUser ||
+		!strings.Contains(feedback.Content, `- $.condition: value must be one of ["sunny","cloudy","rainy"]`) ||
+		!strings.Contains(feedback.Content, `- $: missing required property "temperature"`) {
+		t.Errorf("修正提示 = %q", feedback.Content)
+	}
+}
+
+func TestCallStructuredExhaustsRetries(t *testing.T) {
+	chatModel := &fakeStructuredChatModel{replies: []string{"晴", "晴", "晴"}}
+	client := NewChatClientBuilder(chatModel).Build()
+	request := &ChatClientRequest{
+		UserText: "杭州天气",
+		Options:  &OpenAiChatOptions{MaxStructuredRetries: 1},
+	}
+
+	_, _, err := CallStructured[weatherReport](context.Background(), client, request)
+	var invalid *ErrStructuredOutputInvalid
+	if !errors.As(err, &invalid) {
+		t.Fatalf("期望 ErrStructuredOutputInvalid, 实际 %v", err)
+	}
+	if invalid.Attempts != 2 || invalid.Content != "晴" || len(invalid.Errors) != 1 {
+		t.Errorf("错误 = %+v", invalid)
+	}
+	if len(chatModel.prompts) != 2 {
+		t.Errorf("调用次数 = %d, 期望 2", len(chatModel.prompts))
+	}
+	if request.Options.ResponseFormat != nil {
+		t.Error("不应修改调用方传入的选项")
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "纯 JSON", content: ` {"a": 1} `, want: `{"a": 1}`},
+		{name: "json 代码块", content: "```json\n{\"a\": 1}\n```", want: `{"a": 1}`},
+		{name: "无语言代码块", content: "```\n{\"a\": 1}\n```", want: `{"a": 1}`},
+		{name: "前后附加说明", content: "结果如下：\n{\"a\": {\"b\": 2}}\n以上。", want: `{"a": {"b": 2}}`},
+		{name: "代码块前有说明", content: "结果如下：\n```json\n{\"a\": 1}\n```", want: `{"a": 1}`},
+		{name: "不含对象", content: "晴 25℃", want: ""},
+		{name: "括号顺序错误", content: "} 晴 {", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractJSON(tt.content); got != tt.want {
+				t.Errorf("extractJSON(%q) = %q, 期望 %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStructuredOutput(t *testing.T) {
+	schema, _, err := GenerateJSONSchema(reflectTypeOf[weatherReport]())
+	if err != nil {
+		t.Fatalf("生成 Schema 失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "合法", content: `{"city": "杭州", "condition": "rainy", "temperature": 18}`},
+		{name: "无 JSON", content: "晴", wantErr: "response does not contain a JSON object"},
+		{name: "JSON 语法错误", content: `{"city": }`, wantErr: "response is not valid JSON"},
+		{name: "类型不符", content: `{"city": "杭州", "condition": "rainy", "temperature": 18.5}`, wantErr: "$.temperature: expected integer, got number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, errs := parseStructuredOutput[weatherReport](schema, tt.content)
+			if tt.wantErr == "" {
+				if len(errs) > 0 || result == nil {
+					t.Fatalf("解析失败: %v", errs)
+				}
+				return
+			}
+			if result != nil || len(errs) != 1 || !strings.HasPrefix(errs[0], tt.wantErr) {
+				t.Errorf("错误 = %v, 期望以 %q 开头", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// fakemcpserver 测试用的 stdio MCP 服务端，按行收发 JSON-RPC 消息
+//
+// 环境变量：
+//
+//	FAKE_MCP_PROTOCOL_VERSION 响应 initialize 时返回的协议版本，为空时返回客户端请求的版本
+//
+// 工具：
+//
+//	echo  返回参数 text
+//	sleep 等待参数 ms 毫秒后返回
+//	exit  不响应直接退出进程
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	writeMu     sync.Mutex
+	stateMu     sync.Mutex
+	initialized bool
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var request message
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			continue
+		}
+		handle(&request)
+	}
+}
+
+func handle(request *message) {
+	switch request.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(request.Params, &params)
+		version := os.Getenv("FAKE_MCP_PROTOCOL_VERSION")
+		if version == "" {
+			version = params.ProtocolVersion
+		}
+		reply(request.ID, map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "fake-mcp-server", "version": "0.0.1"},
+		}, nil)
+	case "notifications/initialized":
+		stateMu.Lock()
+		initialized = true
+		stateMu.Unlock()
+	case "ping":
+		reply(request.ID, map[string]any{}, nil)
+	case "tools/list":
+		if !isInitialized() {
+			reply(request.ID, nil, &rpcError{Code: -32600, Message: "not initialized"})
+			return
+		}
+		tools := []map[string]any{}
+		for _, name := range []string{"echo", "sleep", "exit"} {
+			tools = append(tools, map[string]any{"name": name, "inputSchema": map[string]any{"type": "object"}})
+		}
+		reply(request.ID, map[string]any{"tools": tools}, nil)
+	case "tools/call":
+		if !isInitialized() {
+			reply(request.ID, nil, &rpcError{Code: -32600, Message: "not initialized"})
+			return
+		}
+		var params struct {
+			Name      string `json:"name"`
+			Arguments struct {
+				Text string `json:"text"`
+				Ms   int    `json:"ms"`
+			} `json:"arguments"`
+		}
+		_ = json.Unmarshal(request.Params, &params)
+		switch params.Name {
+		case "echo":
+			reply(request.ID, textResult(params.Arguments.Text), nil)
+		case "sleep":
+			go func() {
+				time.Sleep(time.Duration(params.Arguments.Ms) * time.Millisecond)
+				reply(request.ID, textResult("slept"), nil)
+			}()
+		case "exit":
+			os.Exit(1)
+		default:
+			reply(request.ID, nil, &rpcError{Code: -32602, Message: "unknown tool " + params.Name})
+		}
+	default:
+		if len(request.ID) > 0 {
+			reply(request.ID, nil, &rpcError{Code: -32601, Message: "method not found"})
+		}
+	}
+}
+
+func isInitialized() bool {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	return initialized
+}
+
+func textResult(text string) map[string]any {
+	return map[string]any{"content": []map[string]any{{"type": "text", "text": text}}}
+}
+
+func reply(id json.RawMessage, result any, rpcErr *rpcError) {
+	data, _ := json.Marshal(message{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	os.Stdout.Write(append(data, '\n'))
+}
@@ -0,0 +1,254 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// defaultMaxToolIterations 未配置时工具调用循环的最大轮数
+const defaultMaxToolIterations = 10
+
+// ErrToolIterationsExceeded 工具调用轮数超过上限
+type ErrToolIterationsExceeded struct {
+	MaxIterations int
+}
+
+func (e *ErrToolIterationsExceeded) Error() string {
+	return fmt.Sprintf("工具调用轮数超过上限 %d，模型仍在请求调用工具", e.MaxIterations)
+}
+
+// maxToolIterations 获取工具调用循环上限
+func maxToolIterations(options *OpenAiChatOptions) int {
+	if options == nil || options.MaxToolIterations <= 0 {
+		return defaultMaxToolIterations
+	}
+	return options.MaxToolIterations
+}
+
+// toolCallbackMap 按工具名称索引工具回调
+func toolCallbackMap(toolCallbacks []ToolCallback) map[string]ToolCallback {
+	callbacks := make(map[string]ToolCallback, len(toolCallbacks))
+	for _, callback := range toolCallbacks {
+		callbacks[callback.GetToolDefinition().Name] = callback
+	}
+	return callbacks
+}
+
+// executeToolCalls 依次执行模型发起的工具调用，返回对应的工具结果消息
+// 工具不存在或执行失败时将错误信息作为结果回传给模型，由模型决定后续动作
+func executeToolCalls(ctx context.Context, callbacks map[string]ToolCallback, toolCalls []ToolCall) []Message {
+	markToolRun(ctx)
+	messages := make([]Message, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		var content string
+		callback, ok := callbacks[toolCall.Name]
+		if !ok {
+			log.Printf("模型请求了不存在的工具: %s", toolCall.Name)
+			content = fmt.Sprintf("Error: tool %s not found", toolCall.Name)
+		} else {
+			result, err := callback.Call(ctx, toolCall.Arguments)
+			if err != nil {
+				log.Printf("工具 %s 执行失败: %v", toolCall.Name, err)
+				content = "Error: " + err.Error()
+			} else {
+				content = result
+			}
+		}
+		messages = append(messages, NewToolResponseMessage(toolCall.ID, toolCall.Name, content))
+	}
+	return messages
+}
+
+// chatCallFunc 单次同步请求
+type chatCallFunc func(ctx context.Context, messages []Message, options *OpenAiChatOptions) (*ChatResponse, error)
+
+// chatStreamFunc 单次流式请求，工具调用以 ToolCall.Index 区分的增量片段返回
+type chatStreamFunc func(ctx context.Context, messages []Message, options *OpenAiChatOptions) (<-chan ChatStreamChunk, error)
+
+// callWithToolLoop 同步调用，配置了工具回调时执行工具调用循环，用量按轮累加
+// 上下文携带限流器时每轮请求单独获取配额
+func callWithToolLoop(ctx context.Context, messages []Message, options *OpenAiChatOptions, callOnce chatCallFunc) (*ChatResponse, error) {
+	ctx, limiter := takeRateLimiter(ctx)
+	callOnce = rateLimitedCall(limiter, callOnce)
+	callbacks := toolCallbackMap(options.ToolCallbacks)
+
+	var usage Usage
+	for iteration := 0; ; iteration++ {
+		response, err := callOnce(ctx, messages, options)
+		if err != nil {
+			return nil, err
+		}
+		usage.Add(response.Usage)
+
+		result := response.GetResult()
+		if result == nil || len(result.Message.ToolCalls) == 0 || len(callbacks) == 0 {
+			response.Usage = usage
+			return response, nil
+		}
+		if iteration >= maxToolIterations(options) {
+			return nil, &ErrToolIterationsExceeded{MaxIterations: maxToolIterations(options)}
+		}
+
+		messages = append(messages, result.Message)
+		messages = append(messages, executeToolCalls(ctx, callbacks, result.Message.ToolCalls)...)
+	}
+}
+
+// streamWithToolLoop 流式调用，配置了工具回调时在流内完成工具调用循环
+// 工具调用片段只在内部聚合，不转发给调用方，上下文携带限流器时每轮请求单独获取配额
+func streamWithToolLoop(ctx context.Context, messages []Message, options *OpenAiChatOptions, streamOnce chatStreamFunc) (<-chan ChatStreamChunk, error) {
+	ctx, limiter := takeRateLimiter(ctx)
+	streamOnce = rateLimitedStream(limiter, streamOnce)
+	first, err := streamOnce(ctx, messages, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.ToolCallbacks) == 0 {
+		return first, nil
+	}
+
+	callbacks := toolCallbackMap(options.ToolCallbacks)
+	chunks := make(chan ChatStreamChunk)
+	go func() {
+		defer close(chunks)
+
+		send := func(chunk ChatStreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		current := first
+		for iteration := 0; ; iteration++ {
+			assistant := Message{Role: MessageRoleAssistant}
+			var toolCalls []*ToolCall
+			toolCallIndex := make(map[int]*ToolCall)
+
+			for chunk := range current {
+				if chunk.Err != nil {
+					send(chunk)
+					return
+				}
+
+				forward := chunk.Response
+				if result := chunk.Response.GetResult(); result != nil {
+					assistant.Content += result.Message.Content
+					for _, delta := range result.Message.ToolCalls {
+						toolCall, ok := toolCallIndex[delta.Index]
+						if !ok {
+							toolCall = &ToolCall{Index: delta.Index}
+							toolCallIndex[delta.Index] = toolCall
+							toolCalls = append(toolCalls, toolCall)
+						}
+						if delta.ID != "" {
+							toolCall.ID = delta.ID
+						}
+						if delta.Name != "" {
+							toolCall.Name = delta.Name
+						}
+						toolCall.Arguments += delta.Arguments
+					}
+
+					if len(result.Message.ToolCalls) > 0 {
+						stripped := *chunk.Response
+						stripped.Generations = append([]Generation(nil), chunk.Response.Generations...)
+						stripped.Generations[0].Message.ToolCalls = nil
+						forward = &stripped
+					}
+				}
+				if isEmptyStreamChunk(forward) {
+					continue
+				}
+				if !send(ChatStreamChunk{Response: forward}) {
+					return
+				}
+			}
+
+			if len(toolCalls) == 0 || ctx.Err() != nil {
+				return
+			}
+			if iteration >= maxToolIterations(options) {
+				send(ChatStreamChunk{Err: &ErrToolIterationsExceeded{MaxIterations: maxToolIterations(options)}})
+				return
+			}
+
+			for _, toolCall := range toolCalls {
+				assistant.ToolCalls = append(assistant.ToolCalls, *toolCall)
+			}
+			messages = append(messages, assistant)
+			messages = append(messages, executeToolCalls(ctx, callbacks, assistant.ToolCalls)...)
+
+			next, err := streamOnce(ctx, messages, options)
+			if err != nil {
+				send(ChatStreamChunk{Err: err})
+				return
+			}
+			current = next
+		}
+	}()
+
+	return chunks, nil
+}
+
+// mergeChatOptions 合并默认选项与请求选项，请求选项优先
+func mergeChatOptions(defaultOptions *OpenAiChatOptions, prompt *Prompt) *OpenAiChatOptions {
+	merged := &OpenAiChatOptions{}
+	if defaultOptions != nil {
+		*merged = *defaultOptions
+	}
+	if prompt == nil || prompt.Options == nil {
+		return merged
+	}
+
+	options := prompt.Options
+	if options.Model != "" {
+		merged.Model = options.Model
+	}
+	if options.Temperature != nil {
+		merged.Temperature = options.Temperature
+	}
+	if options.MaxTokens > 0 {
+		merged.MaxTokens = options.MaxTokens
+	}
+	if options.ToolCallbacks != nil {
+		merged.ToolCallbacks = options.ToolCallbacks
+	}
+	if options.MaxToolIterations > 0 {
+		merged.MaxToolIterations = options.MaxToolIterations
+	}
+	if options.ResponseFormat != nil {
+		merged.ResponseFormat = options.ResponseFormat
+	}
+	if options.MaxStructuredRetries > 0 {
+		merged.MaxStructuredRetries = options.MaxStructuredRetries
+	}
+	return merged
+}
+
+// promptMessages 复制提示词消息，避免工具调用循环修改调用方的切片
+func promptMessages(prompt *Prompt) []Message {
+	if prompt == nil {
+		return nil
+	}
+	return append([]Message(nil), prompt.Messages...)
+}
+
+// isEmptyStreamChunk 判断流式片段是否没有需要转发的内容
+func isEmptyStreamChunk(response *ChatResponse) bool {
+	if response.Usage.TotalTokens > 0 || response.Usage.PromptTokens > 0 {
+		return false
+	}
+	for _, generation := range response.Generations {
+		if generation.Message.Content != "" || len(generation.Message.ToolCalls) > 0 {
+			return false
+		}
+		if generation.FinishReason != "" && generation.FinishReason != "tool_calls" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// usageScopeKey 用量归属在请求上下文中的键
+type usageScopeKey struct{}
+
+// UsageScope 用量归属，由对话服务放入请求上下文
+type UsageScope struct {
+	ClientID       int64
+	ConversationID string
+}
+
+// WithUsageScope 将用量归属放入请求上下文
+func WithUsageScope(ctx context.Context, scope UsageScope) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+// UsageScopeFrom 获取请求上下文中的用量归属，没有时返回零值
+func UsageScopeFrom(ctx context.Context) UsageScope {
+	scope, _ := ctx.Value(usageScopeKey{}).(UsageScope)
+	return scope
+}
+
+// UsageRecord 一次模型调用的用量
+type UsageRecord struct {
+	ModelID        int64
+	ModelName      string
+	ClientID       int64
+	ConversationID string
+	Usage          Usage
+	CreateTime     time.Time
+}
+
+// UsageRecorder 用量记录器，RecordUsage 在请求路径上调用，实现不能阻塞
+type UsageRecorder interface {
+	RecordUsage(record UsageRecord)
+}
+
+// MeteredChatModel 记录用量的对话模型，调用结束后按请求上下文中的用量归属记录一次用量
+type MeteredChatModel struct {
+	ChatModel
+	ModelID   int64
+	ModelName string // 响应未返回模型名称时使用
+	Recorder  UsageRecorder
+}
+
+// Call 同步调用并记录用量，失败时记录已产生的用量
+func (m *MeteredChatModel) Call(ctx context.Context, prompt *Prompt) (*ChatResponse, error) {
+	response, err := m.ChatModel.Call(ctx, prompt)
+	if response != nil {
+		m.record(ctx, response.Model, response.Usage)
+	}
+	return response, err
+}
+
+// Stream 流式调用，流结束时记录累计用量
+func (m *MeteredChatModel) Stream(ctx context.Context, prompt *Prompt) (<-chan ChatStreamChunk, error) {
+	upstream, err := m.ChatModel.Stream(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan ChatStreamChunk)
+	go func() {
+		var usage Usage
+		var modelName string
+		defer func() { m.record(ctx, modelName, usage) }()
+		defer close(chunks)
+		for chunk := range upstream {
+			if chunk.Response != nil {
+				usage.Add(chunk.Response.Usage)
+				if chunk.Response.Model != "" {
+					modelName = chunk.Response.Model
+				}
+			}
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				// 继续读取直到上游关闭
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+// record 记录用量，没有产生 token 时不记录
+func (m *MeteredChatModel) record(ctx context.Context, modelName string, usage Usage) {
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	if usage.TotalTokens == 0 {
+		return
+	}
+	if modelName == "" {
+		modelName = m.ModelName
+	}
+
+	scope := UsageScopeFrom(ctx)
+	m.Recorder.RecordUsage(UsageRecord{
+		ModelID:        m.ModelID,
+		ModelName:      modelName,
+		ClientID:       scope.ClientID,
+		ConversationID: scope.ConversationID,
+		Usage:          usage,
+		CreateTime:     time.Now(),
+	})
+}
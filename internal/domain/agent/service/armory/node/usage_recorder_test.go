@@ -0,0 +1,203 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeUsageRecorder 把记录的用量写入 channel
+type fakeUsageRecorder struct {
+	records chan UsageRecord
+}
+
+func newFakeUsageRecorder() *fakeUsageRecorder {
+	return &fakeUsageRecorder{records: make(chan UsageRecord, 8)}
+}
+
+func (r *fakeUsageRecorder) RecordUsage(record UsageRecord) {
+	r.records <- record
+}
+
+// receive 等待一条用量记录
+func (r *fakeUsageRecorder) receive(t *testing.T) UsageRecord {
+	t.Helper()
+	select {
+	case record := <-r.records:
+		return record
+	case <-time.After(5 * time.Second):
+		t.Fatal("未记录用量")
+		return UsageRecord{}
+	}
+}
+
+// assertNoRecord 断言没有记录用量
+func (r *fakeUsageRecorder) assertNoRecord(t *testing.T) {
+	t.Helper()
+	select {
+	case record := <-r.records:
+		t.Errorf("不应记录用量, 实际 %+v", record)
+	default:
+	}
+}
+
+// fakeUsageChatModel Call 返回预设响应和错误，Stream 依次推送预设分片
+type fakeUsageChatModel struct {
+	response *ChatResponse
+	err      error
+	chunks   []ChatStreamChunk
+}
+
+func (m *fakeUsageChatModel) Call(context.Context, *Prompt) (*ChatResponse, error) {
+	return m.response, m.err
+}
+
+func (m *fakeUsageChatModel) Stream(context.Context, *Prompt) (<-chan ChatStreamChunk, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	chunks := make(chan ChatStreamChunk, len(m.chunks))
+	for _, chunk := range m.chunks {
+		chunks <- chunk
+	}
+	close(chunks)
+	return chunks, nil
+}
+
+func (m *fakeUsageChatModel) GetDefaultOptions() *OpenAiChatOptions { return nil }
+
+func TestMeteredChatModelCall(t *testing.T) {
+	scope := UsageScope{ClientID: 3001, ConversationID: "conv-1"}
+	tests := []struct {
+		name     string
+		response *ChatResponse
+		err      error
+		want     *UsageRecord // nil 表示不记录
+	}{
+		{
+			name:     "记录响应用量和模型名称",
+			response: &ChatResponse{Model: "gpt-4o-2024-08-06", Usage: Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CachedTokens: 2}},
+			want:     &UsageRecord{ModelName: "gpt-4o-2024-08-06", Usage: Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CachedTokens: 2}},
+		},
+		{
+			name:     "响应无模型名称时使用配置名称并补全总数",
+			response: &ChatResponse{Usage: Usage{PromptTokens: 10, CompletionTokens: 5}},
+			want:     &UsageRecord{ModelName: "gpt-4o", Usage: Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+		},
+		{
+			name:     "失败时记录已产生的用量",
+			response: &ChatResponse{Usage: Usage{PromptTokens: 40, CompletionTokens: 8, TotalTokens: 48}},
+			err:      errors.New("工具调用失败"),
+			want:     &UsageRecord{ModelName: "gpt-4o", Usage: Usage{PromptTokens: 40, CompletionTokens: 8, TotalTokens: 48}},
+		},
+		{name: "没有响应", err: errors.New("upstream failed")},
+		{name: "没有产生 token", response: &ChatResponse{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newFakeUsageRecorder()
+			model := &MeteredChatModel{
+				ChatModel: &fakeUsageChatModel{response: tt.response, err: tt.err},
+				ModelID:   2001,
+				ModelName: "gpt-4o",
+				Recorder:  recorder,
+			}
+
+			response, err := model.Call(WithUsageScope(context.Background(), scope), NewPrompt(NewUserMessage("hi")))
+			if response != tt.response || !errors.Is(err, tt.err) {
+				t.Errorf("应原样返回上游结果, 实际 %v, %v", response, err)
+			}
+			if tt.want == nil {
+				recorder.assertNoRecord(t)
+				return
+			}
+			record := recorder.receive(t)
+			if record.ModelID != 2001 || record.ClientID != scope.ClientID || record.ConversationID != scope.ConversationID {
+				t.Errorf("用量归属 = %+v", record)
+			}
+			if record.ModelName != tt.want.ModelName || record.Usage != tt.want.Usage {
+				t.Errorf("用量 = %s %+v, 期望 %s %+v", record.ModelName, record.Usage, tt.want.ModelName, tt.want.Usage)
+			}
+			if record.CreateTime.IsZero() {
+				t.Error("用量应带记录时间")
+			}
+		})
+	}
+}
+
+func TestMeteredChatModelStream(t *testing.T) {
+	recorder := newFakeUsageRecorder()
+	model := &MeteredChatModel{
+		ChatModel: &fakeUsageChatModel{chunks: []ChatStreamChunk{
+			{Response: &ChatResponse{Model: "gpt-4o-2024-08-06", Generations: []Generation{{Message: NewAssistantMessage("你")}}}},
+			{Response: &ChatResponse{Usage: Usage{PromptTokens: 30, CompletionTokens: 6, TotalTokens: 36}}},
+			{Response: &ChatResponse{Generations: []Generation{{Message: NewAssistantMessage("好")}}, Usage: Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}}},
+		}},
+		ModelID:   2001,
+		ModelName: "gpt-4o",
+		Recorder:  recorder,
+	}
+
+	chunks, err := model.Stream(WithUsageScope(context.Background(), UsageScope{ClientID: 3001}), NewPrompt(NewUserMessage("hi")))
+	if err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	content, _, err := collectStream(t, chunks)
+	if err != nil || content != "你好" {
+		t.Fatalf("内容 = %q, 错误 %v", content, err)
+	}
+
+	// 流结束后按累计用量记录一次
+	record := recorder.receive(t)
+	if want := (Usage{PromptTokens: 40, CompletionTokens: 8, TotalTokens: 48}); record.Usage != want {
+		t.Errorf("用量 = %+v, 期望 %+v", record.Usage, want)
+	}
+	if record.ModelName != "gpt-4o-2024-08-06" || record.ClientID != 3001 {
+		t.Errorf("用量记录 = %+v", record)
+	}
+	recorder.assertNoRecord(t)
+}
+
+func TestMeteredChatModelStreamCanceled(t *testing.T) {
+	recorder := newFakeUsageRecorder()
+	model := &MeteredChatModel{
+		ChatModel: &fakeUsageChatModel{chunks: []ChatStreamChunk{
+			{Response: &ChatResponse{Usage: Usage{PromptTokens: 30, CompletionTokens: 6}}},
+			{Response: &ChatResponse{Usage: Usage{PromptTokens: 10, CompletionTokens: 2}}},
+		}},
+		ModelName: "gpt-4o",
+		Recorder:  recorder,
+	}
+
+	// 调用方取消后不再读取，上游的用量仍然全部记录
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := model.Stream(ctx, NewPrompt(NewUserMessage("hi"))); err != nil {
+		t.Fatalf("Stream 失败: %v", err)
+	}
+	if record := recorder.receive(t); record.Usage != (Usage{PromptTokens: 40, CompletionTokens: 8, TotalTokens: 48}) {
+		t.Errorf("取消后用量 = %+v", record.Usage)
+	}
+}
+
+func TestMeteredChatModelStreamError(t *testing.T) {
+	recorder := newFakeUsageRecorder()
+	upstreamErr := errors.New("upstream failed")
+	model := &MeteredChatModel{ChatModel: &fakeUsageChatModel{err: upstreamErr}, Recorder: recorder}
+
+	if _, err := model.Stream(context.Background(), NewPrompt(NewUserMessage("hi"))); !errors.Is(err, upstreamErr) {
+		t.Fatalf("错误 = %v, 期望上游错误", err)
+	}
+	recorder.assertNoRecord(t)
+}
+
+func TestUsageScopeFrom(t *testing.T) {
+	if scope := UsageScopeFrom(context.Background()); scope != (UsageScope{}) {
+		t.Errorf("未设置时用量归属 = %+v, 期望零值", scope)
+	}
+	want := UsageScope{ClientID: 3001, ConversationID: "conv-1"}
+	if scope := UsageScopeFrom(WithUsageScope(context.Background(), want)); scope != want {
+		t.Errorf("用量归属 = %+v, 期望 %+v", scope, want)
+	}
+}
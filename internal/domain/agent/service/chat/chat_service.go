@@ -0,0 +1,128 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"smart-weaver/internal/domain/agent/service/armory/node"
+)
+
+// ErrChatClientNotFound 客户端未构建
+var ErrChatClientNotFound = errors.New("客户端未构建")
+
+// ChatClientProvider 按客户端ID租用已构建的对话客户端，使用完后调用 release
+type ChatClientProvider interface {
+	AcquireChatClient(clientID int64) (chatClient *node.ChatClient, release func(), ok bool)
+}
+
+// ChatRequest 对话请求
+type ChatRequest struct {
+	ClientID       int64          `json:"clientId" form:"clientId" binding:"required"`
+	ConversationID string         `json:"conversationId" form:"conversationId"` // 为空时使用默认会话
+	Message        string         `json:"message" form:"message" binding:"required"`
+	Variables      map[string]any `json:"variables" form:"-"` // 替换系统提示词中的 {{变量}}，可为空
+}
+
+// ChatResult 对话结果
+type ChatResult struct {
+	ClientID       int64      `json:"clientId"`
+	ConversationID string     `json:"conversationId"`
+	Content        string     `json:"content"`
+	Usage          node.Usage `json:"usage"`
+}
+
+// IChatService 对话服务
+type IChatService interface {
+	// Chat 同步对话
+	Chat(ctx context.Context, req *ChatRequest) (*ChatResult, error)
+	// ChatStream 流式对话，返回的 channel 在流结束后关闭
+	ChatStream(ctx context.Context, req *ChatRequest) (<-chan node.ChatStreamChunk, error)
+}
+
+// ChatService 对话服务实现
+type ChatService struct {
+	clientProvider ChatClientProvider
+}
+
+// NewChatService 创建对话服务
+func NewChatService(clientProvider ChatClientProvider) *ChatService {
+	return &ChatService{clientProvider: clientProvider}
+}
+
+// Chat 同步对话
+func (s *ChatService) Chat(ctx context.Context, req *ChatRequest) (*ChatResult, error) {
+	chatClient, chatRequest, release, err := s.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	response, err := chatClient.Call(usageContext(ctx, req), chatRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatResult{
+		ClientID:       req.ClientID,
+		ConversationID: req.ConversationID,
+		Content:        response.GetText(),
+		Usage:          response.Usage,
+	}, nil
+}
+
+// ChatStream 流式对话，客户端租约在流结束后释放
+func (s *ChatService) ChatStream(ctx context.Context, req *ChatRequest) (<-chan node.ChatStreamChunk, error) {
+	chatClient, chatRequest, release, err := s.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	upstream, err := chatClient.Stream(usageContext(ctx, req), chatRequest)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	chunks := make(chan node.ChatStreamChunk)
+	go func() {
+		defer release()
+		defer close(chunks)
+		for chunk := range upstream {
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				// 继续读取直到上游关闭
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+// usageContext 将客户端和会话放入请求上下文，模型用量按此归属
+func usageContext(ctx context.Context, req *ChatRequest) context.Context {
+	return node.WithUsageScope(ctx, node.UsageScope{ClientID: req.ClientID, ConversationID: req.ConversationID})
+}
+
+// prepare 校验请求并租用客户端
+func (s *ChatService) prepare(req *ChatRequest) (*node.ChatClient, *node.ChatClientRequest, func(), error) {
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		return nil, nil, nil, errors.New("message不能为空")
+	}
+
+	chatClient, release, ok := s.clientProvider.AcquireChatClient(req.ClientID)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: clientId=%d", ErrChatClientNotFound, req.ClientID)
+	}
+
+	adviseContext := make(map[string]any)
+	if req.ConversationID != "" {
+		adviseContext[node.AdviseContextConversationID] = req.ConversationID
+	}
+	return chatClient, &node.ChatClientRequest{
+		UserText:      req.Message,
+		SystemParams:  req.Variables,
+		AdviseContext: adviseContext,
+	}, release, nil
+}
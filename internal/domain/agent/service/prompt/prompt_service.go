@@ -0,0 +1,108 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"smart-weaver/internal/domain/agent/adapter/repository"
+	"smart-weaver/internal/domain/agent/model/valobj"
+)
+
+// maxPromptNameLength 提示词名称最大长度，与表字段长度一致
+const maxPromptNameLength = 64
+
+var (
+	// ErrInvalidPromptRequest 提示词请求参数不合法
+	ErrInvalidPromptRequest = errors.New("提示词请求参数不合法")
+	// ErrPromptVersionNotFound 提示词版本不存在
+	ErrPromptVersionNotFound = errors.New("提示词版本不存在")
+)
+
+// IPromptService 系统提示词版本管理服务
+// 版本变更会更新提示词的 update_time，由配置变更监听重新构建对应客户端
+type IPromptService interface {
+	// QueryPromptVersions 查询客户端同名提示词的全部版本，新版本在前
+	QueryPromptVersions(clientId int64, promptName string) ([]valobj.AiClientSystemPromptVersionVO, error)
+	// PublishPromptVersion 发布新版本并设为生效
+	PublishPromptVersion(prompt *valobj.AiClientSystemPromptVersionVO) (*valobj.AiClientSystemPromptVersionVO, error)
+	// ActivatePromptVersion 将指定版本设为生效，用于回滚
+	ActivatePromptVersion(clientId int64, promptName string, version int) error
+}
+
+// PromptService 系统提示词版本管理服务实现
+type PromptService struct {
+	repository repository.IAgentRepository
+}
+
+// NewPromptService 创建系统提示词版本管理服务
+func NewPromptService(repository repository.IAgentRepository) *PromptService {
+	return &PromptService{repository: repository}
+}
+
+// QueryPromptVersions 查询客户端同名提示词的全部版本，新版本在前
+func (s *PromptService) QueryPromptVersions(clientId int64, promptName string) ([]valobj.AiClientSystemPromptVersionVO, error) {
+	if err := validatePromptKey(clientId, promptName); err != nil {
+		return nil, err
+	}
+	return s.repository.QueryAiClientSystemPromptVersions(clientId, promptName)
+}
+
+// PublishPromptVersion 发布新版本并设为生效，原生效版本转为历史版本
+func (s *PromptService) PublishPromptVersion(prompt *valobj.AiClientSystemPromptVersionVO) (*valobj.AiClientSystemPromptVersionVO, error) {
+	if prompt == nil {
+		return nil, fmt.Errorf("%w: 提示词不能为空", ErrInvalidPromptRequest)
+	}
+	if err := validatePromptKey(prompt.ClientID, prompt.PromptName); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(prompt.PromptContent) == "" {
+		return nil, fmt.Errorf("%w: 提示词内容不能为空", ErrInvalidPromptRequest)
+	}
+
+	published := *prompt
+	if err := s.repository.SaveAiClientSystemPromptVersion(&published); err != nil {
+		return nil, err
+	}
+	return &published, nil
+}
+
+// ActivatePromptVersion 将指定版本设为生效，用于回滚
+func (s *PromptService) ActivatePromptVersion(clientId int64, promptName string, version int) error {
+	if err := validatePromptKey(clientId, promptName); err != nil {
+		return err
+	}
+	if version <= 0 {
+		return fmt.Errorf("%w: 版本号必须大于0", ErrInvalidPromptRequest)
+	}
+
+	versions, err := s.repository.QueryAiClientSystemPromptVersions(clientId, promptName)
+	if err != nil {
+		return err
+	}
+	for _, item := range versions {
+		if item.Version != version {
+			continue
+		}
+		if item.Active {
+			return nil
+		}
+		return s.repository.ActivateAiClientSystemPromptVersion(clientId, promptName, version)
+	}
+	return fmt.Errorf("%w: clientId=%d promptName=%s version=%d", ErrPromptVersionNotFound, clientId, promptName, version)
+}
+
+// validatePromptKey 校验客户端ID和提示词名称
+func validatePromptKey(clientId int64, promptName string) error {
+	if clientId <= 0 {
+		return fmt.Errorf("%w: clientId必须大于0", ErrInvalidPromptRequest)
+	}
+	if strings.TrimSpace(promptName) == "" {
+		return fmt.Errorf("%w: promptName不能为空", ErrInvalidPromptRequest)
+	}
+	if utf8.RuneCountInString(promptName) > maxPromptNameLength {
+		return fmt.Errorf("%w: promptName不能超过%d个字符", ErrInvalidPromptRequest, maxPromptNameLength)
+	}
+	return nil
+}
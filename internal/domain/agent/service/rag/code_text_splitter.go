@@ -0,0 +1,207 @@
+package rag
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"smart-weaver/internal/config"
+)
+
+// 代码分块元数据键
+const (
+	MetadataLanguage  = "language"
+	MetadataStartLine = "start_line" // 从 1 开始
+	MetadataEndLine   = "end_line"
+)
+
+// languageExtensions 扩展名对应的语言
+var languageExtensions = map[string]string{
+	".go": "go", ".py": "python", ".java": "java", ".kt": "kotlin", ".kts": "kotlin",
+	".scala": "scala", ".cs": "csharp", ".js": "javascript", ".jsx": "javascript",
+	".mjs": "javascript", ".cjs": "javascript", ".ts": "typescript", ".tsx": "typescript",
+	".rs": "rust", ".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp",
+	".hpp": "cpp", ".rb": "ruby", ".php": "php", ".swift": "swift", ".sh": "shell",
+	".bash": "shell", ".sql": "sql", ".md": "markdown", ".markdown": "markdown",
+	".proto": "protobuf", ".yaml": "yaml", ".yml": "yaml", ".json": "json",
+	".toml": "toml", ".xml": "xml", ".html": "html", ".vue": "vue",
+}
+
+// languageBoundaries 各语言顶层声明的起始行，作为优先切分点
+var languageBoundaries = map[string]*regexp.Regexp{
+	"go":         regexp.MustCompile(`^(?:func|type|var|const)\b`),
+	"python":     regexp.MustCompile(`^(?:async\s+def|def|class)\s`),
+	"java":       regexp.MustCompile(`^\s{0,4}(?:public|protected|private|static|final|abstract|class|interface|enum|record|@interface)\b`),
+	"kotlin":     regexp.MustCompile(`^\s{0,4}(?:(?:public|private|internal|protected|override|suspend|data|sealed|open|abstract|inline)\s+)*(?:fun|class|object|interface|enum\s+class)\b`),
+	"scala":      regexp.MustCompile(`^\s{0,2}(?:(?:private|protected|override|final|sealed|implicit|case)\s+)*(?:def|class|object|trait)\b`),
+	"csharp":     regexp.MustCompile(`^\s{0,8}(?:public|protected|private|internal|static|abstract|sealed|partial|class|interface|enum|record|struct|namespace)\b`),
+	"javascript": regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?(?:function\*?|class|const|let|var)\b`),
+	"typescript": regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\*?|class|const|let|var|interface|type|enum|namespace)\b`),
+	"rust":       regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:async\s+|unsafe\s+|const\s+)*(?:fn|struct|enum|trait|impl|mod|static|type|macro_rules!)\b`),
+	"c":          regexp.MustCompile(`^(?:struct|enum|union|typedef|static|extern)\b|^[A-Za-z_][\w\s\*]*\b[A-Za-z_]\w*\s*\([^;]*$`),
+	"cpp":        regexp.MustCompile(`^(?:class|struct|enum|union|namespace|template|typedef|static|extern)\b|^[A-Za-z_][\w\s\*&:<>,]*\b[A-Za-z_~][\w:]*\s*\([^;]*$`),
+	"ruby":       regexp.MustCompile(`^\s{0,2}(?:def|class|module)\s`),
+	"php":        regexp.MustCompile(`^\s{0,4}(?:(?:public|protected|private|static|abstract|final)\s+)*(?:function|class|interface|trait)\b`),
+	"swift":      regexp.MustCompile(`^\s{0,4}(?:(?:public|private|internal|fileprivate|open|static|final|override)\s+)*(?:func|class|struct|enum|protocol|extension)\b`),
+	"shell":      regexp.MustCompile(`^(?:function\s+[\w-]+|[\w-]+\s*\(\))`),
+	"sql":        regexp.MustCompile(`(?i)^(?:create|alter|drop|insert|update|delete|select|with)\b`),
+	"markdown":   regexp.MustCompile(`^#{1,6}\s`),
+	"protobuf":   regexp.MustCompile(`^(?:message|service|enum|extend)\b`),
+}
+
+// codeLeadingLine 注释、注解等附着在声明前的行，与其后的声明划分到同一块
+var codeLeadingLine = regexp.MustCompile(`^\s*(?://|#|/\*|\*|@|--|///|\[)`)
+
+// DetectLanguage 按文件扩展名识别语言，无法识别时返回空
+func DetectLanguage(filePath string) string {
+	return languageExtensions[strings.ToLower(path.Ext(filePath))]
+}
+
+// CodeTextSplitter 代码分割器，优先在顶层声明处切分，相邻声明合并到 token 上限，超长声明再按 token 切分
+type CodeTextSplitter struct {
+	Splitter *config.TokenTextSplitter
+}
+
+// NewCodeTextSplitter 创建代码分割器
+func NewCodeTextSplitter(splitter *config.TokenTextSplitter) *CodeTextSplitter {
+	return &CodeTextSplitter{Splitter: splitter}
+}
+
+// codeSegment 按声明切出的行区间 [start, end)
+type codeSegment struct {
+	start, end int
+	tokens     int
+}
+
+// Split 切分代码文档，分块元数据与 TokenTextSplitter 一致，另带语言和起止行号
+func (s *CodeTextSplitter) Split(doc config.Document, language string) ([]config.Document, error) {
+	boundary, ok := languageBoundaries[language]
+	if !ok {
+		chunks, err := s.Splitter.Split(doc)
+		if err != nil {
+			return nil, err
+		}
+		for i := range chunks {
+			setChunkLines(doc.Text, &chunks[i], language)
+		}
+		return chunks, nil
+	}
+
+	lines := strings.SplitAfter(doc.Text, "\n")
+	lineOffsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		lineOffsets[i+1] = lineOffsets[i] + len(line)
+	}
+
+	segments, err := s.segments(lines, lineOffsets, doc.Text, boundary)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []config.Document
+	for _, segment := range segments {
+		startOffset, endOffset := lineOffsets[segment.start], lineOffsets[segment.end]
+		if segment.tokens <= s.Splitter.ChunkSize {
+			chunk := config.Document{Text: doc.Text[startOffset:endOffset], Metadata: map[string]any{
+				config.ChunkMetadataStartOffset: startOffset,
+				config.ChunkMetadataEndOffset:   endOffset,
+				config.ChunkMetadataTokenCount:  segment.tokens,
+			}}
+			chunks = append(chunks, chunk)
+			continue
+		}
+
+		// 单个声明超过上限，按 token 继续切分，偏移换算回整个文件
+		parts, err := s.Splitter.Split(config.Document{ID: doc.ID, Text: doc.Text[startOffset:endOffset]})
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			part.Metadata[config.ChunkMetadataStartOffset] = part.Metadata[config.ChunkMetadataStartOffset].(int) + startOffset
+			part.Metadata[config.ChunkMetadataEndOffset] = part.Metadata[config.ChunkMetadataEndOffset].(int) + startOffset
+			chunks = append(chunks, part)
+		}
+	}
+
+	sourceID := doc.ID
+	if sourceID == "" {
+		sourceID = config.NewDocumentID()
+	}
+	var result []config.Document
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk.Text) == "" {
+			continue
+		}
+		index := len(result)
+		metadata := make(map[string]any, len(doc.Metadata)+8)
+		for key, value := range doc.Metadata {
+			metadata[key] = value
+		}
+		for _, key := range []string{config.ChunkMetadataStartOffset, config.ChunkMetadataEndOffset, config.ChunkMetadataTokenCount} {
+			metadata[key] = chunk.Metadata[key]
+		}
+		metadata[config.ChunkMetadataSourceID] = sourceID
+		metadata[config.ChunkMetadataChunkIndex] = index
+		chunk.ID = config.NewNameBasedDocumentID(fmt.Sprintf("%s#%d", sourceID, index))
+		chunk.Metadata = metadata
+		setChunkLines(doc.Text, &chunk, language)
+		result = append(result, chunk)
+	}
+	return result, nil
+}
+
+// segments 在声明边界处切分行，再把相邻片段合并到 token 上限以内
+func (s *CodeTextSplitter) segments(lines []string, lineOffsets []int, text string, boundary *regexp.Regexp) ([]codeSegment, error) {
+	starts := []int{0}
+	for i := 1; i < len(lines); i++ {
+		if !boundary.MatchString(lines[i]) {
+			continue
+		}
+		// 声明前紧邻的注释、注解归入该声明
+		start := i
+		for start > starts[len(starts)-1]+1 && codeLeadingLine.MatchString(lines[start-1]) {
+			start--
+		}
+		if start > starts[len(starts)-1] {
+			starts = append(starts, start)
+		}
+	}
+	starts = append(starts, len(lines))
+
+	var merged []codeSegment
+	for i := 0; i+1 < len(starts); i++ {
+		start, end := starts[i], starts[i+1]
+		tokens, err := s.Splitter.CountTokens(text[lineOffsets[start]:lineOffsets[end]])
+		if err != nil {
+			return nil, err
+		}
+		if n := len(merged); n > 0 && merged[n-1].tokens+tokens <= s.Splitter.ChunkSize {
+			merged[n-1].end = end
+			merged[n-1].tokens += tokens
+			continue
+		}
+		merged = append(merged, codeSegment{start: start, end: end, tokens: tokens})
+	}
+	return merged, nil
+}
+
+// setChunkLines 根据偏移计算起止行号，并去除分块首尾空行
+func setChunkLines(text string, chunk *config.Document, language string) {
+	startOffset := chunk.Metadata[config.ChunkMetadataStartOffset].(int)
+	endOffset := chunk.Metadata[config.ChunkMetadataEndOffset].(int)
+
+	trimmed := strings.TrimLeft(chunk.Text, "\r\n")
+	startOffset += len(chunk.Text) - len(trimmed)
+	trimmed = strings.TrimRight(trimmed, " \t\r\n")
+	endOffset = startOffset + len(trimmed)
+
+	chunk.Text = trimmed
+	chunk.Metadata[config.ChunkMetadataStartOffset] = startOffset
+	chunk.Metadata[config.ChunkMetadataEndOffset] = endOffset
+	chunk.Metadata[MetadataStartLine] = strings.Count(text[:startOffset], "\n") + 1
+	chunk.Metadata[MetadataEndLine] = strings.Count(text[:endOffset], "\n") + 1
+	if language != "" {
+		chunk.Metadata[MetadataLanguage] = language
+	}
+}
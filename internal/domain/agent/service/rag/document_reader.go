@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"smart-weaver/internal/config"
+)
+
+// 文档元数据键
+const (
+	MetadataSource      = "source"       // 来源文件名
+	MetadataKnowledge   = "knowledge"    // 知识库标签
+	MetadataOrigin      = "origin"       // 入库方式，上传文件为 upload，用于与同名的代码仓库文件区分
+	MetadataContentType = "content_type" // 文档类型
+	MetadataTitle       = "title"        // 标题
+	MetadataHeadingPath = "heading_path" // Markdown 标题层级路径
+	MetadataPageNumber  = "page_number"  // PDF 页码，从 1 开始
+	MetadataTotalPages  = "total_pages"  // PDF 总页数
+)
+
+// 入库方式
+const (
+	OriginUpload = "upload"
+)
+
+// 文档类型
+const (
+	ContentTypeText     = "text"
+	ContentTypeMarkdown = "markdown"
+	ContentTypeHtml     = "html"
+	ContentTypePdf      = "pdf"
+)
+
+// DocumentReader 文档读取器（模拟Java中的DocumentReader）
+type DocumentReader interface {
+	// Read 读取并解析为文档列表
+	Read() ([]config.Document, error)
+}
+
+// NewDocumentReader 按文件扩展名选择读取器，扩展名无法识别时按内容嗅探
+func NewDocumentReader(fileName string, content []byte) (DocumentReader, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".md", ".markdown":
+		return NewMarkdownDocumentReader(fileName, content), nil
+	case ".html", ".htm", ".xhtml":
+		return NewHtmlDocumentReader(fileName, content), nil
+	case ".pdf":
+		return NewPdfDocumentReader(fileName, content), nil
+	case ".txt", ".text", ".log", ".csv":
+		return NewTextDocumentReader(fileName, content), nil
+	}
+
+	contentType := http.DetectContentType(content)
+	switch {
+	case strings.HasPrefix(contentType, "text/html"):
+		return NewHtmlDocumentReader(fileName, content), nil
+	case strings.HasPrefix(contentType, "application/pdf"):
+		return NewPdfDocumentReader(fileName, content), nil
+	case strings.HasPrefix(contentType, "text/plain"):
+		return NewTextDocumentReader(fileName, content), nil
+	}
+	return nil, fmt.Errorf("不支持的文件类型: %s (%s)", fileName, contentType)
+}
+
+// TextDocumentReader 纯文本读取器
+type TextDocumentReader struct {
+	Source  string
+	Content []byte
+}
+
+// NewTextDocumentReader 创建纯文本读取器
+func NewTextDocumentReader(source string, content []byte) *TextDocumentReader {
+	return &TextDocumentReader{Source: source, Content: content}
+}
+
+// Read 整个文件作为一个文档
+func (r *TextDocumentReader) Read() ([]config.Document, error) {
+	text, err := decodeText(r.Source, r.Content)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return []config.Document{newSourceDocument(text, r.Source, ContentTypeText)}, nil
+}
+
+// decodeText 去除 UTF-8 BOM 并统一换行符，非 UTF-8 内容返回错误
+func decodeText(source string, content []byte) (string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(content) {
+		return "", fmt.Errorf("文件 %s 不是 UTF-8 编码", source)
+	}
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n"), nil
+}
+
+// newSourceDocument 创建带来源信息的文档
+func newSourceDocument(text, source, contentType string) config.Document {
+	return config.NewDocument(text, map[string]any{
+		MetadataSource:      source,
+		MetadataContentType: contentType,
+	})
+}
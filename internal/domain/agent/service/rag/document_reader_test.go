@@ -0,0 +1,173 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"smart-weaver/internal/config"
+)
+
+// readFixture 按文件名选择读取器解析 testdata 下的样例文件
+func readFixture(t *testing.T, fileName string) []config.Document {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", fileName))
+	if err != nil {
+		t.Fatalf("读取样例文件失败: %v", err)
+	}
+	reader, err := NewDocumentReader(fileName, content)
+	if err != nil {
+		t.Fatalf("NewDocumentReader 失败: %v", err)
+	}
+	docs, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read 失败: %v", err)
+	}
+	return docs
+}
+
+func TestNewDocumentReaderSelectsReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Markdown 扩展名", fileName: "README.MD", content: "# a", want: "*rag.MarkdownDocumentReader"},
+		{name: "HTML 扩展名", fileName: "index.htm", content: "<p>a</p>", want: "*rag.HtmlDocumentReader"},
+		{name: "PDF 扩展名", fileName: "a.pdf", content: "%PDF-1.4", want: "*rag.PdfDocumentReader"},
+		{name: "文本扩展名", fileName: "a.log", content: "a", want: "*rag.TextDocumentReader"},
+		{name: "嗅探 HTML", fileName: "page", content: "<!DOCTYPE html><html></html>", want: "*rag.HtmlDocumentReader"},
+		{name: "嗅探 PDF", fileName: "report", content: "%PDF-1.4\n", want: "*rag.PdfDocumentReader"},
+		{name: "嗅探文本", fileName: "notes", content: "plain text", want: "*rag.TextDocumentReader"},
+		{name: "不支持的类型", fileName: "image", content: "\x89PNG\r\n\x1a\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewDocumentReader(tt.fileName, []byte(tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("期望返回错误, 实际读取器 %T", reader)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDocumentReader 失败: %v", err)
+			}
+			if got := fmt.Sprintf("%T", reader); got != tt.want {
+				t.Errorf("读取器 = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextDocumentReader(t *testing.T) {
+	docs, err := NewTextDocumentReader("a.txt", []byte("\xef\xbb\xbf第一行\r\n第二行\r")).Read()
+	if err != nil {
+		t.Fatalf("Read 失败: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Text != "第一行\n第二行\n" {
+		t.Fatalf("文档 = %+v", docs)
+	}
+	if docs[0].Metadata[MetadataSource] != "a.txt" || docs[0].Metadata[MetadataContentType] != ContentTypeText {
+		t.Errorf("元数据 = %v", docs[0].Metadata)
+	}
+
+	if docs, err := NewTextDocumentReader("blank.txt", []byte(" \n\t")).Read(); err != nil || len(docs) != 0 {
+		t.Errorf("空白文件应不产生文档, 实际 %+v, %v", docs, err)
+	}
+	if _, err := NewTextDocumentReader("gbk.txt", []byte{0xc4, 0xe3, 0xba, 0xc3}).Read(); err == nil {
+		t.Error("非 UTF-8 内容应返回错误")
+	}
+}
+
+func TestMarkdownDocumentReader(t *testing.T) {
+	docs := readFixture(t, "sample.md")
+
+	want := []struct {
+		title       string
+		headingPath string
+		prefix      string
+	}{
+		{prefix: "前言段落。"},
+		{title: "安装", headingPath: "安装", prefix: "# 安装"},
+		{title: "使用 Docker", headingPath: "安装 > 使用 Docker", prefix: "## 使用 Docker"},
+		{title: "配置", headingPath: "安装 > 配置", prefix: "配置\n----"},
+	}
+	if len(docs) != len(want) {
+		t.Fatalf("章节数量 = %d, 期望 %d: %+v", len(docs), len(want), docs)
+	}
+	for i, w := range want {
+		doc := docs[i]
+		if !strings.HasPrefix(doc.Text, w.prefix) {
+			t.Errorf("第 %d 个章节 = %q, 期望以 %q 开头", i, doc.Text, w.prefix)
+		}
+		if title, _ := doc.Metadata[MetadataTitle].(string); title != w.title {
+			t.Errorf("第 %d 个章节标题 = %q, 期望 %q", i, title, w.title)
+		}
+		if headingPath, _ := doc.Metadata[MetadataHeadingPath].(string); headingPath != w.headingPath {
+			t.Errorf("第 %d 个章节标题路径 = %q, 期望 %q", i, headingPath, w.headingPath)
+		}
+	}
+
+	// 代码块内的 # 和超过最大级别的标题不切分章节
+	if !strings.Contains(docs[2].Text, "# 这不是标题") || !strings.Contains(docs[2].Text, "#### 深层标题") {
+		t.Errorf("代码块或深层标题被切分: %q", docs[2].Text)
+	}
+}
+
+func TestHtmlDocumentReader(t *testing.T) {
+	docs := readFixture(t, "sample.html")
+	if len(docs) != 1 {
+		t.Fatalf("文档数量 = %d, 期望 1", len(docs))
+	}
+	doc := docs[0]
+
+	if doc.Metadata[MetadataTitle] != "智能体 文档" {
+		t.Errorf("标题 = %v", doc.Metadata[MetadataTitle])
+	}
+	if !strings.HasPrefix(doc.Text, "快速开始\n\n第一段 正文，") {
+		t.Errorf("正文 = %q", doc.Text)
+	}
+	if !strings.Contains(doc.Text, "名称 说明") {
+		t.Errorf("表格单元格未以空格分隔: %q", doc.Text)
+	}
+	for _, boilerplate := range []string{"首页", "站点页眉", "隐藏内容", "脚本", "版权所有", "color"} {
+		if strings.Contains(doc.Text, boilerplate) {
+			t.Errorf("正文包含样板内容 %q: %q", boilerplate, doc.Text)
+		}
+	}
+}
+
+func TestPdfDocumentReader(t *testing.T) {
+	docs := readFixture(t, "sample.pdf")
+
+	// 第 2 页为空白页，跳过
+	want := []struct {
+		pageNumber int
+		text       string
+	}{
+		{pageNumber: 1, text: "Hello page one"},
+		{pageNumber: 3, text: "Third page text"},
+	}
+	if len(docs) != len(want) {
+		t.Fatalf("文档数量 = %d, 期望 %d: %+v", len(docs), len(want), docs)
+	}
+	for i, w := range want {
+		if docs[i].Text != w.text {
+			t.Errorf("第 %d 页文本 = %q, 期望 %q", w.pageNumber, docs[i].Text, w.text)
+		}
+		if docs[i].Metadata[MetadataPageNumber] != w.pageNumber || docs[i].Metadata[MetadataTotalPages] != 3 {
+			t.Errorf("第 %d 页元数据 = %v", w.pageNumber, docs[i].Metadata)
+		}
+	}
+}
+
+func TestPdfDocumentReaderCorruptFile(t *testing.T) {
+	if _, err := NewPdfDocumentReader("broken.pdf", []byte("%PDF-1.4\nnot a pdf")).Read(); err == nil {
+		t.Error("损坏的 PDF 应返回错误")
+	}
+}
@@ -0,0 +1,475 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"smart-weaver/internal/config"
+)
+
+// gitScpLikeURLPattern scp 形式的 ssh 地址，如 git@github.com:org/repo.git
+var gitScpLikeURLPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9][A-Za-z0-9.-]*:.+$`)
+
+// 代码文档元数据键
+const (
+	MetadataRepository = "repository"
+	MetadataPath       = "path"
+	MetadataCommit     = "commit"
+	MetadataBlob       = "blob"
+)
+
+const (
+	// defaultMaxGitFileSize 默认跳过超过 1MB 的文件
+	defaultMaxGitFileSize = 1 << 20
+	// defaultGitWorkDir 默认远程仓库缓存目录
+	defaultGitWorkDir = "data/git/repos"
+	// defaultGitStateDir 默认增量入库状态目录
+	defaultGitStateDir = "data/git/state"
+)
+
+// defaultGitExcludes 默认排除依赖目录、构建产物和二进制文件
+var defaultGitExcludes = []string{
+	".git/**", "vendor/**", "node_modules/**", "dist/**", "build/**", "target/**",
+	"*.min.js", "*.min.css", "*.map", "*.lock", "go.sum", "package-lock.json",
+	"*.png", "*.jpg", "*.jpeg", "*.gif", "*.ico", "*.svg", "*.webp", "*.pdf",
+	"*.zip", "*.gz", "*.tar", "*.jar", "*.class", "*.exe", "*.dll", "*.so",
+	"*.dylib", "*.bin", "*.woff", "*.woff2", "*.ttf", "*.eot", "*.mp3", "*.mp4",
+}
+
+// GitIngestRequest 代码仓库入库请求，RepoURL 与 LocalPath 二选一
+type GitIngestRequest struct {
+	Knowledge string   `json:"ragTag"`
+	RepoURL   string   `json:"repoUrl"`
+	LocalPath string   `json:"localPath"`
+	Branch    string   `json:"branch"`
+	Username  string   `json:"username"`
+	Token     string   `json:"token"`
+	Include   []string `json:"include"` // 为空时包含全部文件
+	Exclude   []string `json:"exclude"` // 追加在默认排除规则之后
+}
+
+// GitIngestResult 代码仓库入库结果
+type GitIngestResult struct {
+	Repository string   `json:"repository"`
+	Commit     string   `json:"commit"`
+	Added      int      `json:"added"`
+	Updated    int      `json:"updated"`
+	Removed    int      `json:"removed"`
+	Unchanged  int      `json:"unchanged"`
+	Skipped    int      `json:"skipped"` // 被过滤、过大或二进制文件
+	Chunks     int      `json:"chunks"`  // 本次写入的分块数
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// gitManifest 上次入库状态，按文件记录 blob 哈希和分块ID，用于增量入库
+type gitManifest struct {
+	Repository string                      `json:"repository"`
+	Knowledge  string                      `json:"knowledge"`
+	Commit     string                      `json:"commit"`
+	UpdatedAt  time.Time                   `json:"updatedAt"`
+	Files      map[string]gitManifestEntry `json:"files"`
+}
+
+// gitManifestEntry 单个文件的入库状态
+type gitManifestEntry struct {
+	Blob     string   `json:"blob"`
+	ChunkIDs []string `json:"chunkIds"`
+}
+
+// GitRepositoryIngester 代码仓库入库，比较 blob 哈希只对变更文件重新向量化
+type GitRepositoryIngester struct {
+	Splitter    *CodeTextSplitter
+	VectorStore config.VectorStore
+	WorkDir     string // 远程仓库缓存目录
+	StateDir    string // 增量入库状态目录
+	MaxFileSize int64  // 超过该大小的文件跳过
+	// AllowLocalPath 是否允许读取服务器本地仓库，接口对外开放时应关闭
+	AllowLocalPath bool
+
+	mu sync.Mutex
+}
+
+// NewGitRepositoryIngester 创建代码仓库入库器
+func NewGitRepositoryIngester(splitter *config.TokenTextSplitter, vectorStore config.VectorStore, workDir, stateDir string) *GitRepositoryIngester {
+	if workDir == "" {
+		workDir = defaultGitWorkDir
+	}
+	if stateDir == "" {
+		stateDir = defaultGitStateDir
+	}
+	return &GitRepositoryIngester{
+		Splitter:    NewCodeTextSplitter(splitter),
+		VectorStore: vectorStore,
+		WorkDir:     workDir,
+		StateDir:    stateDir,
+		MaxFileSize: defaultMaxGitFileSize,
+	}
+}
+
+// Ingest 读取仓库指定分支的最新提交并增量入库，单个文件失败不影响其他文件
+func (g *GitRepositoryIngester) Ingest(ctx context.Context, req *GitIngestRequest) (*GitIngestResult, error) {
+	if err := validateGitIngestRequest(req, g.AllowLocalPath); err != nil {
+		return nil, err
+	}
+
+	// 同一时间只处理一个入库任务，避免并发操作同一个缓存仓库和状态文件
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	repository := req.RepoURL
+	var (
+		repo *gitRepository
+		rev  string
+		err  error
+	)
+	if req.LocalPath != "" {
+		if repository, err = filepath.Abs(req.LocalPath); err != nil {
+			return nil, err
+		}
+		if repo, err = openGitRepository(ctx, repository); err != nil {
+			return nil, err
+		}
+		rev = req.Branch
+		if rev == "" {
+			rev = "HEAD"
+		}
+	} else if repo, rev, err = syncGitRepository(ctx, g.WorkDir, req.RepoURL, req.Branch, req.Username, req.Token, g.AllowLocalPath); err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := repo.listFiles(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestPath := g.manifestPath(req.Knowledge, repository)
+	manifest, err := loadGitManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	blobReader, err := repo.newBlobReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer blobReader.Close()
+
+	result := &GitIngestResult{Repository: repository, Commit: commit}
+	excludes := append(append([]string{}, defaultGitExcludes...), req.Exclude...)
+	files := make(map[string]gitManifestEntry, len(entries))
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !matchPathFilters(entry.Path, req.Include, excludes) || entry.Size > g.maxFileSize() {
+			result.Skipped++
+			continue
+		}
+
+		previous, existed := manifest.Files[entry.Path]
+		if existed && previous.Blob == entry.Blob {
+			files[entry.Path] = previous
+			result.Unchanged++
+			continue
+		}
+
+		chunkIDs, skipped, err := g.ingestFile(ctx, blobReader, req.Knowledge, repository, commit, entry)
+		if err != nil {
+			log.Printf("仓库 %s 文件 %s 入库失败: %v", repository, entry.Path, err)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", entry.Path, err))
+			// 保留旧状态，下次重试
+			if existed {
+				files[entry.Path] = previous
+			}
+			continue
+		}
+		if skipped {
+			result.Skipped++
+			continue
+		}
+
+		files[entry.Path] = gitManifestEntry{Blob: entry.Blob, ChunkIDs: chunkIDs}
+		result.Chunks += len(chunkIDs)
+		if !existed {
+			result.Added++
+			continue
+		}
+		result.Updated++
+		if err := g.VectorStore.Delete(ctx, staleChunkIDs(previous.ChunkIDs, chunkIDs)); err != nil {
+			return nil, fmt.Errorf("删除文件 %s 旧分块失败: %w", entry.Path, err)
+		}
+	}
+
+	// 已删除、不再匹配过滤规则或变为二进制的文件
+	for filePath, previous := range manifest.Files {
+		if _, ok := files[filePath]; ok {
+			continue
+		}
+		if err := g.VectorStore.Delete(ctx, previous.ChunkIDs); err != nil {
+			return nil, fmt.Errorf("删除文件 %s 分块失败: %w", filePath, err)
+		}
+		result.Removed++
+	}
+
+	manifest.Repository = repository
+	manifest.Knowledge = req.Knowledge
+	manifest.Commit = commit
+	manifest.UpdatedAt = time.Now()
+	manifest.Files = files
+	if err := saveGitManifest(manifestPath, manifest); err != nil {
+		return nil, err
+	}
+
+	log.Printf("仓库 %s@%s 已写入知识库 %s，新增 %d 更新 %d 删除 %d 未变 %d 跳过 %d，分块 %d 个",
+		repository, commit, req.Knowledge, result.Added, result.Updated, result.Removed, result.Unchanged, result.Skipped, result.Chunks)
+	return result, nil
+}
+
+// ingestFile 切分并写入单个文件，二进制或非 UTF-8 文件跳过
+func (g *GitRepositoryIngester) ingestFile(ctx context.Context, blobReader *gitBlobReader, knowledge, repository, commit string, entry gitTreeEntry) ([]string, bool, error) {
+	content, err := blobReader.Read(entry.Blob)
+	if err != nil {
+		return nil, false, err
+	}
+	if isBinaryContent(content) {
+		return nil, true, nil
+	}
+
+	language := DetectLanguage(entry.Path)
+	doc := config.Document{
+		ID:   config.NewNameBasedDocumentID(knowledge + "|" + repository + "|" + entry.Path),
+		Text: strings.ReplaceAll(string(content), "\r\n", "\n"),
+		Metadata: map[string]any{
+			MetadataKnowledge:   knowledge,
+			MetadataSource:      entry.Path,
+			MetadataRepository:  repository,
+			MetadataPath:        entry.Path,
+			MetadataCommit:      commit,
+			MetadataBlob:        entry.Blob,
+			MetadataContentType: "code",
+		},
+	}
+
+	chunks, err := g.Splitter.Split(doc, language)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := g.VectorStore.Add(ctx, chunks); err != nil {
+		return nil, false, err
+	}
+
+	chunkIDs := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunkIDs = append(chunkIDs, chunk.ID)
+	}
+	return chunkIDs, false, nil
+}
+
+// maxFileSize 文件大小上限
+func (g *GitRepositoryIngester) maxFileSize() int64 {
+	if g.MaxFileSize <= 0 {
+		return defaultMaxGitFileSize
+	}
+	return g.MaxFileSize
+}
+
+// manifestPath 状态文件路径，按知识库标签和仓库区分
+func (g *GitRepositoryIngester) manifestPath(knowledge, repository string) string {
+	sum := sha1.Sum([]byte(knowledge + "|" + repository))
+	return filepath.Join(g.StateDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// validateGitIngestRequest 校验请求，未开启本地仓库入库时 localPath 与本地地址形式的 repoUrl 均拒绝
+func validateGitIngestRequest(req *GitIngestRequest, allowLocalPath bool) error {
+	req.Knowledge = strings.TrimSpace(req.Knowledge)
+	if req.Knowledge == "" {
+		return errors.New("知识库标签不能为空")
+	}
+	if (req.RepoURL == "") == (req.LocalPath == "") {
+		return errors.New("repoUrl 与 localPath 必须且只能指定一个")
+	}
+	if req.LocalPath != "" && !allowLocalPath {
+		return errors.New("未开启本地仓库入库")
+	}
+	if req.RepoURL != "" {
+		if err := validateGitRepoURL(req.RepoURL, allowLocalPath); err != nil {
+			return err
+		}
+	}
+	if strings.HasPrefix(req.Branch, "-") {
+		return fmt.Errorf("分支名不合法: %s", req.Branch)
+	}
+	for _, pattern := range append(append([]string{}, req.Include...), req.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("过滤规则不合法 %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// validateGitRepoURL 校验仓库地址，只允许 https://、ssh:// 和 git@host:path 形式
+// 开启本地仓库入库时额外允许 file:// 和本地路径，ext:: 等执行命令的传输方式始终拒绝
+func validateGitRepoURL(repoURL string, allowLocalPath bool) error {
+	if strings.HasPrefix(repoURL, "-") || strings.ContainsAny(repoURL, "\x00\n\r") {
+		return fmt.Errorf("仓库地址不合法: %s", repoURL)
+	}
+	if gitScpLikeURLPattern.MatchString(repoURL) {
+		return nil
+	}
+
+	if u, err := url.Parse(repoURL); err == nil && u.Scheme != "" {
+		switch strings.ToLower(u.Scheme) {
+		case "https", "ssh":
+			if u.Host == "" || strings.HasPrefix(u.Hostname(), "-") {
+				return fmt.Errorf("仓库地址不合法: %s", repoURL)
+			}
+			return nil
+		case "file":
+			if allowLocalPath {
+				return nil
+			}
+			return errors.New("未开启本地仓库入库")
+		}
+	}
+	if strings.Contains(repoURL, "::") || strings.Contains(repoURL, "://") {
+		return fmt.Errorf("不支持的仓库地址协议: %s", repoURL)
+	}
+	// 其余形式 git 按本地路径处理
+	if !allowLocalPath {
+		return errors.New("未开启本地仓库入库")
+	}
+	return nil
+}
+
+// loadGitManifest 读取状态文件，不存在时返回空状态
+func loadGitManifest(manifestPath string) (*gitManifest, error) {
+	manifest := &gitManifest{Files: make(map[string]gitManifestEntry)}
+	data, err := os.ReadFile(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取入库状态失败: %w", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("解析入库状态 %s 失败: %w", manifestPath, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]gitManifestEntry)
+	}
+	return manifest, nil
+}
+
+// saveGitManifest 写入状态文件，先写临时文件再替换
+func saveGitManifest(manifestPath string, manifest *gitManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0o755); err != nil {
+		return fmt.Errorf("创建入库状态目录失败: %w", err)
+	}
+	tmpPath := manifestPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("写入入库状态失败: %w", err)
+	}
+	return os.Rename(tmpPath, manifestPath)
+}
+
+// staleChunkIDs 旧分块中不在新分块里的ID
+func staleChunkIDs(previous, current []string) []string {
+	keep := make(map[string]bool, len(current))
+	for _, id := range current {
+		keep[id] = true
+	}
+	var stale []string
+	for _, id := range previous {
+		if !keep[id] {
+			stale = append(stale, id)
+		}
+	}
+	return stale
+}
+
+// isBinaryContent 前 8000 字节含 NUL 或不是合法 UTF-8 时视为二进制
+func isBinaryContent(content []byte) bool {
+	head := content
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	return bytes.IndexByte(head, 0) >= 0 || !utf8.Valid(content)
+}
+
+// matchPathFilters include 为空时包含全部，exclude 优先
+func matchPathFilters(filePath string, include, exclude []string) bool {
+	for _, pattern := range exclude {
+		if matchGlob(pattern, filePath) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if matchGlob(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob 支持 ** 的路径匹配，不含 / 的规则匹配任意层级的文件名或目录名
+func matchGlob(pattern, filePath string) bool {
+	pattern = strings.Trim(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+		if !strings.HasSuffix(pattern, "**") {
+			// 同名目录下的所有文件
+			if matchGlobSegments(strings.Split(pattern+"/**", "/"), strings.Split(filePath, "/")) {
+				return true
+			}
+		}
+	}
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(filePath, "/"))
+}
+
+// matchGlobSegments 逐段匹配，** 匹配零个或多个目录
+func matchGlobSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchGlobSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
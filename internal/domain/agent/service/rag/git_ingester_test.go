@@ -0,0 +1,152 @@
+package rag
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateGitRepoURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		repoURL        string
+		allowLocalPath bool
+		wantErr        bool
+	}{
+		{name: "https", repoURL: "https://github.com/org/repo.git"},
+		{name: "ssh", repoURL: "ssh://git@github.com/org/repo.git"},
+		{name: "scp 形式", repoURL: "git@github.com:org/repo.git"},
+		{name: "http 不允许", repoURL: "http://github.com/org/repo.git", wantErr: true},
+		{name: "ext 传输始终拒绝", repoURL: "ext::sh -c touch% /tmp/pwned", allowLocalPath: true, wantErr: true},
+		{name: "选项注入", repoURL: "--upload-pack=touch /tmp/pwned", allowLocalPath: true, wantErr: true},
+		{name: "主机名以 - 开头", repoURL: "ssh://-oProxyCommand=calc/repo", wantErr: true},
+		{name: "缺少主机", repoURL: "https:///repo.git", wantErr: true},
+		{name: "换行符", repoURL: "https://github.com/org/repo.git\n--foo", wantErr: true},
+		{name: "未知协议", repoURL: "git+foo://host/repo", allowLocalPath: true, wantErr: true},
+		{name: "file 未开启本地入库", repoURL: "file:///srv/repo", wantErr: true},
+		{name: "file 开启本地入库", repoURL: "file:///srv/repo", allowLocalPath: true},
+		{name: "本地路径未开启本地入库", repoURL: "/srv/repo", wantErr: true},
+		{name: "本地路径开启本地入库", repoURL: "/srv/repo", allowLocalPath: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGitRepoURL(tt.repoURL, tt.allowLocalPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateGitRepoURL(%q, %v) = %v, 期望出错 %v", tt.repoURL, tt.allowLocalPath, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		filePath string
+		want     bool
+	}{
+		{pattern: "*.go", filePath: "main.go", want: true},
+		{pattern: "*.go", filePath: "internal/config/config.go", want: true},
+		{pattern: "*.go", filePath: "README.md", want: false},
+		{pattern: "vendor", filePath: "vendor/github.com/a/a.go", want: true},
+		{pattern: "vendor", filePath: "internal/vendor/a.go", want: true},
+		{pattern: "vendor", filePath: "vendored/a.go", want: false},
+		{pattern: "/docs/", filePath: "docs/guide.md", want: true},
+		{pattern: "internal/**/*.go", filePath: "internal/a.go", want: true},
+		{pattern: "internal/**/*.go", filePath: "internal/x/y/a.go", want: true},
+		{pattern: "internal/**/*.go", filePath: "cmd/a.go", want: false},
+		{pattern: "internal/*.go", filePath: "internal/x/a.go", want: false},
+		{pattern: "**/testdata/**", filePath: "pkg/testdata/fixture.json", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.filePath, func(t *testing.T) {
+			if got := matchGlob(tt.pattern, tt.filePath); got != tt.want {
+				t.Errorf("matchGlob(%q, %q) = %v, 期望 %v", tt.pattern, tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPathFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		include  []string
+		exclude  []string
+		want     bool
+	}{
+		{name: "无规则包含全部", filePath: "a/b.txt", want: true},
+		{name: "命中 include", filePath: "a/b.go", include: []string{"*.go"}, want: true},
+		{name: "未命中 include", filePath: "a/b.txt", include: []string{"*.go"}, want: false},
+		{name: "exclude 优先", filePath: "vendor/b.go", include: []string{"*.go"}, exclude: []string{"vendor"}, want: false},
+		{name: "只有 exclude", filePath: "a/b_test.go", exclude: []string{"*_test.go"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPathFilters(tt.filePath, tt.include, tt.exclude); got != tt.want {
+				t.Errorf("matchPathFilters(%q) = %v, 期望 %v", tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaleChunkIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		current  []string
+		want     []string
+	}{
+		{name: "首次入库", current: []string{"a"}, want: nil},
+		{name: "内容未变", previous: []string{"a", "b"}, current: []string{"a", "b"}, want: nil},
+		{name: "分块减少", previous: []string{"a", "b", "c"}, current: []string{"a"}, want: []string{"b", "c"}},
+		{name: "文件删除", previous: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "分块替换", previous: []string{"a", "b"}, current: []string{"b", "d"}, want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := staleChunkIDs(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staleChunkIDs = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitManifestRoundTrip(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "state", "manifest.json")
+
+	// 状态文件不存在时返回空状态
+	empty, err := loadGitManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("读取不存在的状态文件失败: %v", err)
+	}
+	if empty.Files == nil || len(empty.Files) != 0 {
+		t.Fatalf("空状态 = %+v", empty)
+	}
+
+	manifest := &gitManifest{
+		Repository: "https://github.com/org/repo.git",
+		Knowledge:  "repo",
+		Commit:     "0123456789abcdef",
+		UpdatedAt:  time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC),
+		Files: map[string]gitManifestEntry{
+			"main.go":   {Blob: "b1", ChunkIDs: []string{"c1", "c2"}},
+			"README.md": {Blob: "b2", ChunkIDs: []string{"c3"}},
+		},
+	}
+	if err := saveGitManifest(manifestPath, manifest); err != nil {
+		t.Fatalf("写入状态文件失败: %v", err)
+	}
+
+	loaded, err := loadGitManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("读取状态文件失败: %v", err)
+	}
+	if !loaded.UpdatedAt.Equal(manifest.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, 期望 %v", loaded.UpdatedAt, manifest.UpdatedAt)
+	}
+	loaded.UpdatedAt = manifest.UpdatedAt
+	if !reflect.DeepEqual(loaded, manifest) {
+		t.Errorf("读取的状态 = %+v, 期望 %+v", loaded, manifest)
+	}
+}
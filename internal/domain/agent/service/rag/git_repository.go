@@ -0,0 +1,211 @@
+package rag
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// gitTreeEntry 提交树中的文件
+type gitTreeEntry struct {
+	Path string
+	Blob string
+	Size int64
+}
+
+// gitRepository 通过 git 命令行访问的仓库，Dir 为 git 目录（本地仓库或缓存的裸仓库）
+type gitRepository struct {
+	Dir string
+	// authHeader 克隆、拉取私有仓库时附加的认证头，不写入仓库配置
+	authHeader string
+	// allowFileProtocol 是否允许 file 传输方式，未开启本地仓库入库时禁止
+	allowFileProtocol bool
+}
+
+// openGitRepository 打开本地仓库
+func openGitRepository(ctx context.Context, dir string) (*gitRepository, error) {
+	repo := &gitRepository{Dir: dir}
+	if _, err := repo.run(ctx, "rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("%s 不是 git 仓库: %w", dir, err)
+	}
+	return repo, nil
+}
+
+// syncGitRepository 将远程仓库浅克隆为 workDir 下的裸仓库，已存在时拉取最新提交，返回仓库及提交版本
+// allowFileProtocol 为 false 时禁止 git 使用 file 传输方式，防止读取服务器本地仓库
+func syncGitRepository(ctx context.Context, workDir, repoURL, branch, username, token string, allowFileProtocol bool) (*gitRepository, string, error) {
+	sum := sha1.Sum([]byte(repoURL))
+	repo := &gitRepository{
+		Dir:               filepath.Join(workDir, hex.EncodeToString(sum[:])+".git"),
+		allowFileProtocol: allowFileProtocol,
+	}
+	if token != "" {
+		if username == "" {
+			username = "oauth2"
+		}
+		repo.authHeader = "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+token))
+	}
+
+	if _, err := os.Stat(repo.Dir); err == nil {
+		ref := branch
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if _, err := repo.run(ctx, "fetch", "--depth", "1", "--force", "origin", ref); err != nil {
+			return nil, "", fmt.Errorf("拉取仓库 %s 失败: %w", repoURL, err)
+		}
+		return repo, "FETCH_HEAD", nil
+	}
+
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return nil, "", fmt.Errorf("创建仓库缓存目录失败: %w", err)
+	}
+	args := []string{"clone", "--bare", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", repoURL, repo.Dir)
+	if _, err := repo.runIn(ctx, "", args...); err != nil {
+		_ = os.RemoveAll(repo.Dir)
+		return nil, "", fmt.Errorf("克隆仓库 %s 失败: %w", repoURL, err)
+	}
+	return repo, "HEAD", nil
+}
+
+// resolveCommit 解析提交哈希
+func (r *gitRepository) resolveCommit(ctx context.Context, rev string) (string, error) {
+	out, err := r.run(ctx, "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("解析版本 %s 失败: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// listFiles 列出提交中的普通文件，跳过子模块和符号链接
+func (r *gitRepository) listFiles(ctx context.Context, commit string) ([]gitTreeEntry, error) {
+	out, err := r.run(ctx, "ls-tree", "-r", "-l", "-z", commit)
+	if err != nil {
+		return nil, fmt.Errorf("读取提交 %s 文件列表失败: %w", commit, err)
+	}
+
+	var entries []gitTreeEntry
+	for _, record := range bytes.Split(out, []byte{0}) {
+		// <mode> SP <type> SP <object> SP <size> TAB <path>
+		meta, path, ok := strings.Cut(string(record), "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		entries = append(entries, gitTreeEntry{Path: path, Blob: fields[2], Size: size})
+	}
+	return entries, nil
+}
+
+// gitBlobReader 基于 git cat-file --batch 的批量读取器，避免每个文件启动一个进程
+type gitBlobReader struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// newBlobReader 启动批量读取进程，使用完毕需 Close
+func (r *gitRepository) newBlobReader(ctx context.Context) (*gitBlobReader, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", r.Dir, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动 git cat-file 失败: %w", err)
+	}
+	return &gitBlobReader{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// Read 读取对象内容
+func (b *gitBlobReader) Read(blob string) ([]byte, error) {
+	if _, err := io.WriteString(b.stdin, blob+"\n"); err != nil {
+		return nil, err
+	}
+
+	// <object> SP <type> SP <size> LF <contents> LF，对象不存在时为 <object> SP missing LF
+	header, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("读取对象 %s 失败: %s", blob, strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("读取对象 %s 失败: %s", blob, strings.TrimSpace(header))
+	}
+
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(b.stdout, content); err != nil {
+		return nil, err
+	}
+	return content[:size], nil
+}
+
+// Close 结束批量读取进程
+func (b *gitBlobReader) Close() error {
+	_ = b.stdin.Close()
+	return b.cmd.Wait()
+}
+
+// run 在仓库目录执行 git 命令
+func (r *gitRepository) run(ctx context.Context, args ...string) ([]byte, error) {
+	return r.runIn(ctx, r.Dir, args...)
+}
+
+// runIn 执行 git 命令，dir 为空时在当前目录执行，失败时错误信息带上 stderr
+func (r *gitRepository) runIn(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var fullArgs []string
+	if dir != "" {
+		fullArgs = append(fullArgs, "-C", dir)
+	}
+	if r.authHeader != "" {
+		fullArgs = append(fullArgs, "-c", "http.extraHeader="+r.authHeader)
+	}
+	// ext 传输方式可执行任意命令，始终禁止
+	fullArgs = append(fullArgs, "-c", "protocol.ext.allow=never")
+	if !r.allowFileProtocol {
+		fullArgs = append(fullArgs, "-c", "protocol.file.allow=never")
+	}
+	fullArgs = append(fullArgs, args...)
+
+	cmd := exec.CommandContext(ctx, "git", fullArgs...)
+	// 禁止交互式输入凭据，避免请求挂起
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package rag
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"smart-weaver/internal/config"
+)
+
+// htmlBoilerplateTags 导航、页眉页脚、脚本等与正文无关的元素
+var htmlBoilerplateTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Head:     true,
+}
+
+// htmlBlockTags 块级元素，前后换行
+var htmlBlockTags = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Br: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Figure: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Hr: true, atom.Li: true, atom.Main: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Tr: true, atom.Ul: true,
+}
+
+// HtmlDocumentReader HTML 读取器，去除导航、脚本等样板内容后提取正文
+type HtmlDocumentReader struct {
+	Source  string
+	Content []byte
+}
+
+// NewHtmlDocumentReader 创建 HTML 读取器
+func NewHtmlDocumentReader(source string, content []byte) *HtmlDocumentReader {
+	return &HtmlDocumentReader{Source: source, Content: content}
+}
+
+// Read 整个页面作为一个文档，存在 main/article 时只取其中内容
+func (r *HtmlDocumentReader) Read() ([]config.Document, error) {
+	root, err := html.Parse(bytes.NewReader(r.Content))
+	if err != nil {
+		return nil, fmt.Errorf("解析 HTML 文件 %s 失败: %w", r.Source, err)
+	}
+
+	body := findHtmlElement(root, atom.Main)
+	if body == nil {
+		body = findHtmlElement(root, atom.Article)
+	}
+	if body == nil {
+		body = root
+	}
+
+	var sb strings.Builder
+	writeHtmlText(&sb, body)
+	text := normalizeHtmlText(sb.String())
+	if text == "" {
+		return nil, nil
+	}
+
+	doc := newSourceDocument(text, r.Source, ContentTypeHtml)
+	if title := findHtmlElement(root, atom.Title); title != nil {
+		var titleText strings.Builder
+		writeHtmlText(&titleText, title)
+		if t := strings.Join(strings.Fields(titleText.String()), " "); t != "" {
+			doc.Metadata[MetadataTitle] = t
+		}
+	}
+	return []config.Document{doc}, nil
+}
+
+// findHtmlElement 深度优先查找第一个指定元素
+func findHtmlElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findHtmlElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// writeHtmlText 输出节点文本，跳过样板元素，块级元素换行
+func writeHtmlText(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if htmlBoilerplateTags[n.DataAtom] || isHiddenHtmlElement(n) {
+			return
+		}
+		if n.DataAtom == atom.Td || n.DataAtom == atom.Th {
+			sb.WriteString(" ")
+		}
+	case html.CommentNode, html.DoctypeNode:
+		return
+	}
+
+	block := n.Type == html.ElementNode && htmlBlockTags[n.DataAtom]
+	if block {
+		sb.WriteString("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeHtmlText(sb, c)
+	}
+	if block {
+		sb.WriteString("\n")
+	}
+}
+
+// isHiddenHtmlElement hidden 属性或 aria-hidden 的元素不可见
+func isHiddenHtmlElement(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "hidden" || (attr.Key == "aria-hidden" && attr.Val == "true") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHtmlText 合并行内空白，连续空行压缩为一个空行
+func normalizeHtmlText(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
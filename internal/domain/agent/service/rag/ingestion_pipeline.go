@@ -0,0 +1,78 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"smart-weaver/internal/config"
+)
+
+// IngestResult 入库结果
+type IngestResult struct {
+	Source    string `json:"source"`
+	Documents int    `json:"documents"` // 读取到的文档数（章节、页等）
+	Chunks    int    `json:"chunks"`    // 切分后写入向量库的分块数
+}
+
+// IngestionPipeline 文档入库流水线：读取 → 切分 → 向量化 → 写入向量库
+type IngestionPipeline struct {
+	Splitter    *config.TokenTextSplitter
+	VectorStore config.VectorStore
+}
+
+// NewIngestionPipeline 创建入库流水线
+func NewIngestionPipeline(splitter *config.TokenTextSplitter, vectorStore config.VectorStore) *IngestionPipeline {
+	return &IngestionPipeline{Splitter: splitter, VectorStore: vectorStore}
+}
+
+// Ingest 读取文档并入库，metadata 合并到每个文档的元数据中
+// 文档ID由来源、知识库标签和序号生成，重复上传同一文件会覆盖已有分块，
+// 写入后删除同一来源且元数据相同的旧分块中未被覆盖的部分（文件变短后多出的高序号分块）
+func (p *IngestionPipeline) Ingest(ctx context.Context, source string, reader DocumentReader, metadata map[string]any) (*IngestResult, error) {
+	docs, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IngestResult{Source: source, Documents: len(docs)}
+	if len(docs) == 0 {
+		return result, nil
+	}
+
+	for i := range docs {
+		for key, value := range metadata {
+			docs[i].Metadata[key] = value
+		}
+		docs[i].ID = config.NewNameBasedDocumentID(fmt.Sprintf("%v|%s|%d", metadata[MetadataKnowledge], source, i))
+	}
+
+	chunks, err := p.Splitter.Apply(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先查出旧分块，新分块写入成功后再删除，写入失败时保留原有内容
+	filter := map[string]any{MetadataSource: source}
+	for key, value := range metadata {
+		filter[key] = value
+	}
+	previousIDs, err := p.VectorStore.ListIDs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("查询文件 %s 旧分块失败: %w", source, err)
+	}
+
+	if err := p.VectorStore.Add(ctx, chunks); err != nil {
+		return nil, err
+	}
+
+	chunkIDs := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunkIDs = append(chunkIDs, chunk.ID)
+	}
+	if err := p.VectorStore.Delete(ctx, staleChunkIDs(previousIDs, chunkIDs)); err != nil {
+		return nil, fmt.Errorf("删除文件 %s 旧分块失败: %w", source, err)
+	}
+
+	result.Chunks = len(chunks)
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+
+	"smart-weaver/internal/config"
+)
+
+var (
+	// markdownAtxHeading ATX 标题，如 "## 标题 ##"
+	markdownAtxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	// markdownSetextUnderline Setext 标题下划线，"===" 为一级，"---" 为二级
+	markdownSetextUnderline = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	// markdownFence 代码块围栏
+	markdownFence = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+)
+
+// MarkdownDocumentReader Markdown 读取器，按标题切分为多个文档并记录标题层级
+type MarkdownDocumentReader struct {
+	Source  string
+	Content []byte
+	// MaxHeadingLevel 参与切分的最大标题级别，更深的标题并入上级章节，默认 3
+	MaxHeadingLevel int
+}
+
+// markdownSection 按标题切出的章节
+type markdownSection struct {
+	headings []string
+	lines    []string
+}
+
+// NewMarkdownDocumentReader 创建 Markdown 读取器
+func NewMarkdownDocumentReader(source string, content []byte) *MarkdownDocumentReader {
+	return &MarkdownDocumentReader{Source: source, Content: content, MaxHeadingLevel: 3}
+}
+
+// Read 每个章节一个文档，章节文本保留标题行，元数据带标题及层级路径
+func (r *MarkdownDocumentReader) Read() ([]config.Document, error) {
+	text, err := decodeText(r.Source, r.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLevel := r.MaxHeadingLevel
+	if maxLevel <= 0 || maxLevel > 6 {
+		maxLevel = 6
+	}
+
+	var (
+		sections []*markdownSection
+		current  = &markdownSection{}
+		headings = make([]string, 6)
+		fence    string
+	)
+	startSection := func(level int, title string, headingLines ...string) {
+		sections = append(sections, current)
+		headings[level-1] = title
+		for i := level; i < len(headings); i++ {
+			headings[i] = ""
+		}
+		current = &markdownSection{headings: compactHeadings(headings), lines: headingLines}
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// 代码块内的 # 不是标题
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case strings.HasPrefix(m[1], fence[:1]) && len(m[1]) >= len(fence):
+				fence = ""
+			}
+			current.lines = append(current.lines, line)
+			continue
+		}
+		if fence != "" {
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		if m := markdownAtxHeading.FindStringSubmatch(line); m != nil && len(m[1]) <= maxLevel {
+			startSection(len(m[1]), strings.TrimSpace(m[2]), line)
+			continue
+		}
+
+		// Setext 标题：非空段落行后紧跟下划线行
+		if i+1 < len(lines) && strings.TrimSpace(line) != "" && !markdownSetextUnderline.MatchString(line) {
+			if m := markdownSetextUnderline.FindStringSubmatch(lines[i+1]); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				if level <= maxLevel && !isParagraphContinuation(current.lines) {
+					startSection(level, strings.TrimSpace(line), line, lines[i+1])
+					i++
+					continue
+				}
+			}
+		}
+
+		current.lines = append(current.lines, line)
+	}
+	sections = append(sections, current)
+
+	var docs []config.Document
+	for _, section := range sections {
+		content := strings.TrimSpace(strings.Join(section.lines, "\n"))
+		if content == "" {
+			continue
+		}
+		doc := newSourceDocument(content, r.Source, ContentTypeMarkdown)
+		if len(section.headings) > 0 {
+			doc.Metadata[MetadataTitle] = section.headings[len(section.headings)-1]
+			doc.Metadata[MetadataHeadingPath] = strings.Join(section.headings, " > ")
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// isParagraphContinuation 上一行是非空文本时，"---" 下划线属于多行段落，不按 Setext 标题处理
+func isParagraphContinuation(lines []string) bool {
+	return len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != ""
+}
+
+// compactHeadings 去掉空缺的层级，得到标题路径
+func compactHeadings(headings []string) []string {
+	var path []string
+	for _, heading := range headings {
+		if heading != "" {
+			path = append(path, heading)
+		}
+	}
+	return path
+}
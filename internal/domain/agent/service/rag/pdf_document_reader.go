@@ -0,0 +1,67 @@
+package rag
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"smart-weaver/internal/config"
+)
+
+// PdfDocumentReader PDF 读取器，按页提取文本
+type PdfDocumentReader struct {
+	Source  string
+	Content []byte
+}
+
+// NewPdfDocumentReader 创建 PDF 读取器
+func NewPdfDocumentReader(source string, content []byte) *PdfDocumentReader {
+	return &PdfDocumentReader{Source: source, Content: content}
+}
+
+// Read 每页一个文档，空白页跳过，元数据带页码和总页数
+func (r *PdfDocumentReader) Read() (docs []config.Document, err error) {
+	// 解析库遇到损坏文件会 panic
+	defer func() {
+		if p := recover(); p != nil {
+			docs, err = nil, fmt.Errorf("解析 PDF 文件 %s 失败: %v", r.Source, p)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(r.Content), int64(len(r.Content)))
+	if err != nil {
+		return nil, fmt.Errorf("解析 PDF 文件 %s 失败: %w", r.Source, err)
+	}
+
+	totalPages := reader.NumPage()
+	fonts := make(map[string]*pdf.Font)
+	for pageNumber := 1; pageNumber <= totalPages; pageNumber++ {
+		page := reader.Page(pageNumber)
+		if page.V.IsNull() {
+			continue
+		}
+		// 缓存字体，避免每页重复解析字符映射
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+
+		text, err := page.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("提取 PDF 文件 %s 第 %d 页文本失败: %w", r.Source, pageNumber, err)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		doc := newSourceDocument(text, r.Source, ContentTypePdf)
+		doc.Metadata[MetadataPageNumber] = pageNumber
+		doc.Metadata[MetadataTotalPages] = totalPages
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
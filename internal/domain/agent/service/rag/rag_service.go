@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+)
+
+// IRagService 知识库服务
+type IRagService interface {
+	// UploadFile 解析文件并写入指定知识库
+	UploadFile(ctx context.Context, knowledge, fileName string, content []byte) (*IngestResult, error)
+	// IngestGitRepository 增量导入代码仓库
+	IngestGitRepository(ctx context.Context, req *GitIngestRequest) (*GitIngestResult, error)
+}
+
+// RagService 知识库服务实现
+type RagService struct {
+	pipeline    *IngestionPipeline
+	gitIngester *GitRepositoryIngester
+}
+
+// NewRagService 创建知识库服务
+func NewRagService(pipeline *IngestionPipeline, gitIngester *GitRepositoryIngester) *RagService {
+	return &RagService{pipeline: pipeline, gitIngester: gitIngester}
+}
+
+// UploadFile 解析文件并写入指定知识库，检索时可按 knowledge 元数据过滤
+func (s *RagService) UploadFile(ctx context.Context, knowledge, fileName string, content []byte) (*IngestResult, error) {
+	knowledge = strings.TrimSpace(knowledge)
+	if knowledge == "" {
+		return nil, errors.New("知识库标签不能为空")
+	}
+
+	reader, err := NewDocumentReader(fileName, content)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.pipeline.Ingest(ctx, fileName, reader, map[string]any{
+		MetadataKnowledge: knowledge,
+		MetadataOrigin:    OriginUpload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("文件 %s 已写入知识库 %s，文档 %d 个，分块 %d 个", fileName, knowledge, result.Documents, result.Chunks)
+	return result, nil
+}
+
+// IngestGitRepository 增量导入代码仓库，只对 blob 哈希变化的文件重新向量化
+func (s *RagService) IngestGitRepository(ctx context.Context, req *GitIngestRequest) (*GitIngestResult, error) {
+	return s.gitIngester.Ingest(ctx, req)
+}
@@ -0,0 +1,152 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"smart-weaver/internal/domain/agent/adapter/repository"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+)
+
+// defaultQueueSize 待写入用量的缓冲数量，写满时丢弃新的用量
+const defaultQueueSize = 1024
+
+// ErrInvalidUsageQuery 用量查询条件不合法
+var ErrInvalidUsageQuery = errors.New("用量查询条件不合法")
+
+// IUsageService 模型用量服务
+type IUsageService interface {
+	// QueryUsage 按维度聚合用量
+	QueryUsage(query *entity.UsageQueryEntity) ([]valobj.UsageSummaryVO, error)
+}
+
+// UsageService 模型用量服务实现，同时作为构建链的用量记录器，用量按模型价格计算费用后异步写入流水
+type UsageService struct {
+	repository repository.IUsageRepository
+	records    chan node.UsageRecord
+	stopped    chan struct{}
+	closed     bool
+	mu         sync.RWMutex
+	dropped    atomic.Int64 // 队列已满或服务关闭后丢弃的用量数
+}
+
+var _ node.UsageRecorder = (*UsageService)(nil)
+
+// NewUsageService 创建模型用量服务并启动写入协程
+func NewUsageService(repository repository.IUsageRepository) *UsageService {
+	return newUsageService(repository, defaultQueueSize)
+}
+
+// newUsageService 创建指定缓冲数量的模型用量服务
+func newUsageService(repository repository.IUsageRepository, queueSize int) *UsageService {
+	s := &UsageService{
+		repository: repository,
+		records:    make(chan node.UsageRecord, queueSize),
+		stopped:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// RecordUsage 记录一次模型调用的用量，不阻塞调用方
+func (s *UsageService) RecordUsage(record node.UsageRecord) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		dropped := s.dropped.Add(1)
+		log.Printf("用量服务已关闭，丢弃用量 modelId=%d clientId=%d 累计丢弃=%d", record.ModelID, record.ClientID, dropped)
+		return
+	}
+	select {
+	case s.records <- record:
+	default:
+		dropped := s.dropped.Add(1)
+		log.Printf("用量写入队列已满，丢弃用量 modelId=%d clientId=%d tokens=%d 累计丢弃=%d", record.ModelID, record.ClientID, record.Usage.TotalTokens, dropped)
+	}
+}
+
+// Dropped 累计丢弃的用量数，不为0时说明流水不完整
+func (s *UsageService) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close 停止接收用量，写完队列中的用量后返回
+func (s *UsageService) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.records)
+	}
+	s.mu.Unlock()
+	<-s.stopped
+	if dropped := s.dropped.Load(); dropped > 0 {
+		log.Printf("用量服务已关闭，累计丢弃用量 %d 条", dropped)
+	}
+}
+
+// QueryUsage 按维度聚合用量，时间范围为 [StartTime, EndTime)
+func (s *UsageService) QueryUsage(query *entity.UsageQueryEntity) ([]valobj.UsageSummaryVO, error) {
+	if query == nil {
+		return nil, fmt.Errorf("%w: 查询条件不能为空", ErrInvalidUsageQuery)
+	}
+	if !query.StartTime.Before(query.EndTime) {
+		return nil, fmt.Errorf("%w: 开始时间必须早于结束时间", ErrInvalidUsageQuery)
+	}
+	seen := make(map[string]bool, len(query.GroupBy))
+	for _, groupBy := range query.GroupBy {
+		switch groupBy {
+		case valobj.UsageGroupByDay, valobj.UsageGroupByClient, valobj.UsageGroupByModel, valobj.UsageGroupByConversation:
+		default:
+			return nil, fmt.Errorf("%w: 不支持的聚合维度 %s", ErrInvalidUsageQuery, groupBy)
+		}
+		if seen[groupBy] {
+			return nil, fmt.Errorf("%w: 聚合维度 %s 重复", ErrInvalidUsageQuery, groupBy)
+		}
+		seen[groupBy] = true
+	}
+	return s.repository.QueryUsageSummary(query)
+}
+
+// run 逐条计算费用并写入流水
+func (s *UsageService) run() {
+	defer close(s.stopped)
+	for record := range s.records {
+		s.save(record)
+	}
+}
+
+// save 计算费用并写入一条流水，失败时只记录日志
+func (s *UsageService) save(record node.UsageRecord) {
+	ledger := &entity.AiUsageLedgerEntity{
+		ModelID:          record.ModelID,
+		ModelName:        record.ModelName,
+		ClientID:         record.ClientID,
+		ConversationID:   record.ConversationID,
+		PromptTokens:     record.Usage.PromptTokens,
+		CompletionTokens: record.Usage.CompletionTokens,
+		CachedTokens:     record.Usage.CachedTokens,
+		TotalTokens:      record.Usage.TotalTokens,
+		CreateTime:       record.CreateTime,
+	}
+	if ledger.CreateTime.IsZero() {
+		ledger.CreateTime = time.Now()
+	}
+
+	price, err := s.repository.QueryModelPrice(record.ModelID)
+	if err != nil {
+		log.Printf("查询模型价格失败，费用按0记录 modelId=%d: %v", record.ModelID, err)
+	} else if price != nil {
+		ledger.Cost = price.Cost(ledger.PromptTokens, ledger.CompletionTokens, ledger.CachedTokens)
+		ledger.Currency = price.Currency
+	}
+
+	if err := s.repository.SaveUsageLedger(ledger); err != nil {
+		log.Printf("写入用量流水失败 modelId=%d clientId=%d: %v", record.ModelID, record.ClientID, err)
+	}
+}
@@ -0,0 +1,178 @@
+package usage
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+)
+
+// fakeUsageRepository 内存中的用量仓储，gate 不为空时写入流水前等待
+type fakeUsageRepository struct {
+	mu       sync.Mutex
+	prices   map[int64]*valobj.AiModelPriceVO
+	priceErr error
+	ledgers  []*entity.AiUsageLedgerEntity
+	queries  []*entity.UsageQueryEntity
+	gate     chan struct{}
+}
+
+func (r *fakeUsageRepository) QueryModelPrice(modelId int64) (*valobj.AiModelPriceVO, error) {
+	if r.priceErr != nil {
+		return nil, r.priceErr
+	}
+	return r.prices[modelId], nil
+}
+
+func (r *fakeUsageRepository) SaveUsageLedger(ledger *entity.AiUsageLedgerEntity) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ledgers = append(r.ledgers, ledger)
+	return nil
+}
+
+func (r *fakeUsageRepository) QueryUsageSummary(query *entity.UsageQueryEntity) ([]valobj.UsageSummaryVO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, query)
+	return []valobj.UsageSummaryVO{}, nil
+}
+
+func (r *fakeUsageRepository) savedLedgers() []*entity.AiUsageLedgerEntity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*entity.AiUsageLedgerEntity(nil), r.ledgers...)
+}
+
+func TestUsageServiceRecordsLedgerWithCost(t *testing.T) {
+	repository := &fakeUsageRepository{prices: map[int64]*valobj.AiModelPriceVO{
+		2001: {ModelID: 2001, InputPrice: 2, OutputPrice: 8, CachedInputPrice: 0.5, Currency: "USD"},
+	}}
+	service := NewUsageService(repository)
+
+	createTime := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	service.RecordUsage(node.UsageRecord{
+		ModelID:        2001,
+		ModelName:      "gpt-4o",
+		ClientID:       3001,
+		ConversationID: "conv-1",
+		Usage:          node.Usage{PromptTokens: 1_000_000, CompletionTokens: 500_000, TotalTokens: 1_500_000, CachedTokens: 200_000},
+		CreateTime:     createTime,
+	})
+	service.RecordUsage(node.UsageRecord{ModelID: 2002, Usage: node.Usage{PromptTokens: 10, TotalTokens: 10}})
+	service.Close()
+
+	ledgers := repository.savedLedgers()
+	if len(ledgers) != 2 {
+		t.Fatalf("流水数量 = %d, 期望 2", len(ledgers))
+	}
+	priced := ledgers[0]
+	if priced.ModelName != "gpt-4o" || priced.ClientID != 3001 || priced.ConversationID != "conv-1" || !priced.CreateTime.Equal(createTime) {
+		t.Errorf("流水 = %+v", priced)
+	}
+	// 未命中缓存 80 万 × 2 + 缓存 20 万 × 0.5 + 输出 50 万 × 8，单价按每百万 token 计
+	if math.Abs(priced.Cost-5.7) > 1e-9 || priced.Currency != "USD" {
+		t.Errorf("费用 = %v %s, 期望 5.7 USD", priced.Cost, priced.Currency)
+	}
+	if unpriced := ledgers[1]; unpriced.Cost != 0 || unpriced.Currency != "" || unpriced.CreateTime.IsZero() {
+		t.Errorf("未配置价格的流水 = %+v", unpriced)
+	}
+}
+
+func TestUsageServicePriceErrorStillSaves(t *testing.T) {
+	repository := &fakeUsageRepository{priceErr: errors.New("db down")}
+	service := NewUsageService(repository)
+	service.RecordUsage(node.UsageRecord{ModelID: 2001, Usage: node.Usage{TotalTokens: 10}})
+	service.Close()
+
+	if ledgers := repository.savedLedgers(); len(ledgers) != 1 || ledgers[0].Cost != 0 {
+		t.Errorf("查询价格失败时应按0写入流水, 实际 %+v", ledgers)
+	}
+}
+
+func TestUsageServiceCountsDroppedRecords(t *testing.T) {
+	repository := &fakeUsageRepository{gate: make(chan struct{})}
+	service := newUsageService(repository, 1)
+
+	// 写入协程阻塞在第一条，队列容纳第二条，之后的用量被丢弃
+	service.RecordUsage(node.UsageRecord{ModelID: 1})
+	waitForQueueDrained(t, service)
+	service.RecordUsage(node.UsageRecord{ModelID: 2})
+	service.RecordUsage(node.UsageRecord{ModelID: 3})
+	service.RecordUsage(node.UsageRecord{ModelID: 4})
+	if dropped := service.Dropped(); dropped != 2 {
+		t.Errorf("队列已满时丢弃数 = %d, 期望 2", dropped)
+	}
+
+	// 关闭时写完队列中的用量，关闭后的用量同样计入丢弃数
+	close(repository.gate)
+	service.Close()
+	service.RecordUsage(node.UsageRecord{ModelID: 5})
+	if dropped := service.Dropped(); dropped != 3 {
+		t.Errorf("关闭后丢弃数 = %d, 期望 3", dropped)
+	}
+	ledgers := repository.savedLedgers()
+	if len(ledgers) != 2 || ledgers[0].ModelID != 1 || ledgers[1].ModelID != 2 {
+		t.Errorf("写入的流水 = %+v, 期望 modelId 1 和 2", ledgers)
+	}
+	service.Close() // 重复关闭无效
+}
+
+// waitForQueueDrained 等待写入协程取走队列中的用量
+func waitForQueueDrained(t *testing.T, service *UsageService) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(service.records) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("写入协程未取走用量")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUsageServiceQueryUsageValidation(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	tests := []struct {
+		name    string
+		query   *entity.UsageQueryEntity
+		wantErr bool
+	}{
+		{name: "合法", query: &entity.UsageQueryEntity{StartTime: start, EndTime: end, GroupBy: []string{valobj.UsageGroupByDay, valobj.UsageGroupByModel}}},
+		{name: "不分组", query: &entity.UsageQueryEntity{StartTime: start, EndTime: end}},
+		{name: "查询条件为空", wantErr: true},
+		{name: "时间范围为空", query: &entity.UsageQueryEntity{StartTime: start, EndTime: start}, wantErr: true},
+		{name: "开始晚于结束", query: &entity.UsageQueryEntity{StartTime: end, EndTime: start}, wantErr: true},
+		{name: "不支持的维度", query: &entity.UsageQueryEntity{StartTime: start, EndTime: end, GroupBy: []string{"week"}}, wantErr: true},
+		{name: "重复维度", query: &entity.UsageQueryEntity{StartTime: start, EndTime: end, GroupBy: []string{valobj.UsageGroupByClient, valobj.UsageGroupByClient}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &fakeUsageRepository{}
+			service := NewUsageService(repository)
+			defer service.Close()
+
+			_, err := service.QueryUsage(tt.query)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidUsageQuery) {
+					t.Errorf("错误 = %v, 期望 ErrInvalidUsageQuery", err)
+				}
+				if len(repository.queries) != 0 {
+					t.Error("查询条件不合法时不应查询仓储")
+				}
+				return
+			}
+			if err != nil || len(repository.queries) != 1 {
+				t.Errorf("错误 = %v, 仓储查询次数 %d", err, len(repository.queries))
+			}
+		})
+	}
+}
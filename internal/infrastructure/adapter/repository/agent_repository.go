@@ -2,29 +2,188 @@ package repository
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"gorm.io/gorm"
+	"smart-weaver/internal/domain/agent/adapter/repository"
 	"smart-weaver/internal/domain/agent/model/valobj"
 	"smart-weaver/internal/infrastructure/dao"
+	"smart-weaver/internal/infrastructure/dao/po"
 )
 
+// AgentRepository 智能体配置仓储实现
 type AgentRepository struct {
-	clientModelDao   *dao.AiClientModelDao
-	clientToolMcpDao *dao.AiClientToolMcpDao
+	clientDao         *dao.AiClientDao
+	clientModelDao    *dao.AiClientModelDao
+	clientModelRelDao *dao.AiClientModelRelDao
+	clientToolMcpDao  *dao.AiClientToolMcpDao
+	clientAdvisorDao  *dao.AiClientAdvisorDao
+	systemPromptDao   *dao.AiClientSystemPromptDao
 }
 
-// QueryAiClientModelVOListByClientIds 查询 AI Client Model VO 列表
-func (r *AgentRepository) QueryAiClientModelVOListByClientIds(clientIdList []int64) []*valobj.AiClientModelVO {
+var _ repository.IAgentRepository = (*AgentRepository)(nil)
+
+// NewAgentRepository 创建智能体配置仓储
+func NewAgentRepository(db *gorm.DB) *AgentRepository {
+	return &AgentRepository{
+		clientDao:         &dao.AiClientDao{DB: db},
+		clientModelDao:    &dao.AiClientModelDao{DB: db},
+		clientModelRelDao: &dao.AiClientModelRelDao{DB: db},
+		clientToolMcpDao:  &dao.AiClientToolMcpDao{DB: db},
+		clientAdvisorDao:  &dao.AiClientAdvisorDao{DB: db},
+		systemPromptDao:   &dao.AiClientSystemPromptDao{DB: db},
+	}
+}
+
+// QueryEnabledClientIds 查询所有启用的客户端ID
+func (r *AgentRepository) QueryEnabledClientIds() ([]int64, error) {
+	ids, err := r.clientDao.QueryEnabledClientIds()
+	if err != nil {
+		log.Printf("查询启用客户端失败: %v", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// QueryClientConfigVersions 查询启用客户端的配置版本，由客户端、模型组、模型、MCP、顾问、顾问引用的嵌入模型和生效提示词的 update_time 拼接而成
+// 顾问和提示词只取启用和生效的记录，新增、停用、删除或切换版本都会改变记录集合
+func (r *AgentRepository) QueryClientConfigVersions() (map[int64]string, error) {
+	clientIdList, err := r.clientDao.QueryEnabledClientIds()
+	if err != nil {
+		log.Printf("查询启用客户端失败: %v", err)
+		return nil, err
+	}
+	aiClients, err := r.clientDao.QueryClientConfigByIds(clientIdList)
+	if err != nil {
+		log.Printf("查询客户端配置失败: %v", err)
+		return nil, err
+	}
+	modelRels, err := r.clientModelRelDao.QueryRelByClientIds(clientIdList)
+	if err != nil {
+		log.Printf("查询模型组配置失败: %v", err)
+		return nil, err
+	}
+	modelRelMap := make(map[int64][]po.AiClientModelRel)
+	for _, rel := range modelRels {
+		modelRelMap[rel.ClientID] = append(modelRelMap[rel.ClientID], rel)
+	}
+
+	var modelIdList, mcpIdList []int64
+	mcpIdMap := make(map[int64][]int64, len(aiClients))
+	for _, m := range aiClients {
+		modelIdList = append(modelIdList, m.ModelID)
+		for _, rel := range modelRelMap[m.ID] {
+			modelIdList = append(modelIdList, rel.ModelID)
+		}
+		mcpIdMap[m.ID] = parseIdList(m.McpIdList)
+		mcpIdList = append(mcpIdList, mcpIdMap[m.ID]...)
+	}
+
+	advisors, err := r.clientAdvisorDao.QueryAdvisorConfigByClientIds(clientIdList)
+	if err != nil {
+		log.Printf("查询顾问配置失败: %v", err)
+		return nil, err
+	}
+	advisorMap := make(map[int64][]po.AiClientAdvisor)
+	for _, advisor := range advisors {
+		advisorMap[advisor.ClientID] = append(advisorMap[advisor.ClientID], advisor)
+	}
+	embeddingModelIdMap := ragEmbeddingModelIds(advisors)
+	for _, embeddingModelIds := range embeddingModelIdMap {
+		modelIdList = append(modelIdList, embeddingModelIds...)
+	}
+
+	modelUpdateTimes, err := r.clientModelDao.QueryUpdateTimeByIds(modelIdList)
+	if err != nil {
+		log.Printf("查询模型更新时间失败: %v", err)
+		return nil, err
+	}
+	mcpUpdateTimes, err := r.clientToolMcpDao.QueryUpdateTimeByIds(mcpIdList)
+	if err != nil {
+		log.Printf("查询 MCP 更新时间失败: %v", err)
+		return nil, err
+	}
+	prompts, err := r.systemPromptDao.QueryActivePromptByClientIds(clientIdList)
+	if err != nil {
+		log.Printf("查询系统提示词配置失败: %v", err)
+		return nil, err
+	}
+	promptMap := make(map[int64][]po.AiClientSystemPrompt)
+	for _, prompt := range prompts {
+		promptMap[prompt.ClientID] = append(promptMap[prompt.ClientID], prompt)
+	}
+
+	versions := make(map[int64]string, len(aiClients))
+	for _, m := range aiClients {
+		var version strings.Builder
+		fmt.Fprintf(&version, "client:%d@%s", m.ID, formatVersionTime(m.UpdateTime, true))
+		modelUpdateTime, ok := modelUpdateTimes[m.ModelID]
+		fmt.Fprintf(&version, ";model:%d@%s", m.ModelID, formatVersionTime(modelUpdateTime, ok))
+		for _, rel := range modelRelMap[m.ID] {
+			modelUpdateTime, ok := modelUpdateTimes[rel.ModelID]
+			fmt.Fprintf(&version, ";rel:%d@%s;model:%d@%s", rel.ID, formatVersionTime(rel.UpdateTime, true),
+				rel.ModelID, formatVersionTime(modelUpdateTime, ok))
+		}
+		for _, mcpId := range mcpIdMap[m.ID] {
+			mcpUpdateTime, ok := mcpUpdateTimes[mcpId]
+			fmt.Fprintf(&version, ";mcp:%d@%s", mcpId, formatVersionTime(mcpUpdateTime, ok))
+		}
+		for _, advisor := range advisorMap[m.ID] {
+			fmt.Fprintf(&version, ";advisor:%d@%s", advisor.ID, formatVersionTime(advisor.UpdateTime, true))
+		}
+		for _, embeddingModelId := range embeddingModelIdMap[m.ID] {
+			modelUpdateTime, ok := modelUpdateTimes[embeddingModelId]
+			fmt.Fprintf(&version, ";embedding:%d@%s", embeddingModelId, formatVersionTime(modelUpdateTime, ok))
+		}
+		for _, prompt := range promptMap[m.ID] {
+			fmt.Fprintf(&version, ";prompt:%d@%s", prompt.ID, formatVersionTime(prompt.UpdateTime, true))
+		}
+		versions[m.ID] = version.String()
+	}
+	return versions, nil
+}
+
+// QueryAiClientModelVOListByClientIds 查询 AI Client Model VO 列表，包含知识库问答顾问引用的嵌入模型
+func (r *AgentRepository) QueryAiClientModelVOListByClientIds(clientIdList []int64) ([]valobj.AiClientModelVO, error) {
 	aiClientModels, err := r.clientModelDao.QueryModelConfigByClientIds(clientIdList)
 	if err != nil {
 		log.Printf("查询模型配置失败: %v", err)
-		return nil
+		return nil, err
+	}
+
+	advisors, err := r.clientAdvisorDao.QueryAdvisorConfigByClientIds(clientIdList)
+	if err != nil {
+		log.Printf("查询顾问配置失败: %v", err)
+		return nil, err
+	}
+	loaded := make(map[int64]bool, len(aiClientModels))
+	for _, m := range aiClientModels {
+		loaded[m.ID] = true
 	}
+	var embeddingModelIdList []int64
+	for _, embeddingModelIds := range ragEmbeddingModelIds(advisors) {
+		for _, id := range embeddingModelIds {
+			if !loaded[id] {
+				loaded[id] = true
+				embeddingModelIdList = append(embeddingModelIdList, id)
+			}
+		}
+	}
+	embeddingModels, err := r.clientModelDao.QueryModelConfigByIds(embeddingModelIdList)
+	if err != nil {
+		log.Printf("查询嵌入模型配置失败: %v", err)
+		return nil, err
+	}
+	aiClientModels = append(aiClientModels, embeddingModels...)
 
-	voList := make([]*valobj.AiClientModelVO, 0, len(aiClientModels))
+	voList := make([]valobj.AiClientModelVO, 0, len(aiClientModels))
 
 	for _, m := range aiClientModels {
-		vo := &valobj.AiClientModelVO{
+		vo := valobj.AiClientModelVO{
 			ID:              m.ID,
 			ModelName:       m.ModelName,
 			BaseURL:         m.BaseURL,
@@ -34,23 +193,45 @@ func (r *AgentRepository) QueryAiClientModelVOListByClientIds(clientIdList []int
 			ModelType:       m.ModelType,
 			ModelVersion:    m.ModelVersion,
 			Timeout:         m.Timeout,
+			RpmLimit:        m.RpmLimit,
+			TpmLimit:        m.TpmLimit,
+			MaxConcurrency:  m.MaxConcurrency,
+			QueueTimeout:    m.QueueTimeout,
+			ToolNamePrefix:  m.ToolNamePrefix,
 		}
 		voList = append(voList, vo)
 	}
-	return voList
+	return voList, nil
 }
 
 // QueryAiClientToolMcpVOListByClientIds 查询 AI Client Tool MCP VO 列表
-func (r *AgentRepository) QueryAiClientToolMcpVOListByClientIds(clientIdList []int64) []*valobj.AiClientToolMcpVO {
-	aiClientToolMcps, err := r.clientToolMcpDao.QueryMcpConfigByClientIds(clientIdList)
+func (r *AgentRepository) QueryAiClientToolMcpVOListByClientIds(clientIdList []int64) ([]valobj.AiClientToolMcpVO, error) {
+	// 客户端级 MCP 工具通过 ai_client.mcp_id_list 关联
+	aiClients, err := r.clientDao.QueryClientConfigByIds(clientIdList)
+	if err != nil {
+		log.Printf("查询客户端配置失败: %v", err)
+		return nil, err
+	}
+	var mcpIdList []int64
+	seen := make(map[int64]bool)
+	for _, m := range aiClients {
+		for _, mcpId := range parseIdList(m.McpIdList) {
+			if !seen[mcpId] {
+				seen[mcpId] = true
+				mcpIdList = append(mcpIdList, mcpId)
+			}
+		}
+	}
+
+	aiClientToolMcps, err := r.clientToolMcpDao.QueryMcpConfigByIds(mcpIdList)
 	if err != nil {
 		log.Printf("查询 MCP 配置失败: %v", err)
-		return nil
+		return nil, err
 	}
-	voList := make([]*valobj.AiClientToolMcpVO, 0, len(aiClientToolMcps))
+	voList := make([]valobj.AiClientToolMcpVO, 0, len(aiClientToolMcps))
 
 	for _, m := range aiClientToolMcps {
-		vo := &valobj.AiClientToolMcpVO{
+		vo := valobj.AiClientToolMcpVO{
 			ID:             m.ID,
 			McpName:        m.McpName,
 			TransportType:  m.TransportType,
@@ -74,10 +255,215 @@ func (r *AgentRepository) QueryAiClientToolMcpVOListByClientIds(clientIdList []i
 				} else {
 					vo.TransportConfigStdio = &stdio
 				}
+			// 解析 streamable_http 设置
+			case "streamable_http":
+				var streamableHttp valobj.TransportConfigStreamableHttp
+				if err := json.Unmarshal([]byte(m.TransportConfig), &streamableHttp); err != nil {
+					log.Printf("解析 Streamable HTTP 配置失败: %v", err)
+				} else {
+					vo.TransportConfigStreamableHttp = &streamableHttp
+				}
+			}
+		}
+
+		voList = append(voList, vo)
+	}
+	return voList, nil
+}
+
+// QueryAiClientAdvisorVOListByClientIds 查询 AI Client Advisor VO 列表
+func (r *AgentRepository) QueryAiClientAdvisorVOListByClientIds(clientIdList []int64) ([]valobj.AiClientAdvisorVO, error) {
+	aiClientAdvisors, err := r.clientAdvisorDao.QueryAdvisorConfigByClientIds(clientIdList)
+	if err != nil {
+		log.Printf("查询顾问配置失败: %v", err)
+		return nil, err
+	}
+	voList := make([]valobj.AiClientAdvisorVO, 0, len(aiClientAdvisors))
+
+	for _, m := range aiClientAdvisors {
+		vo := valobj.AiClientAdvisorVO{
+			ID:          m.ID,
+			ClientID:    m.ClientID,
+			AdvisorName: m.AdvisorName,
+			AdvisorType: m.AdvisorType,
+			OrderNum:    m.OrderNum,
+		}
+
+		if m.ExtParam != "" {
+			switch m.AdvisorType {
+			case valobj.AdvisorTypeRagAnswer:
+				var ragAnswer valobj.RagAnswerVO
+				if err := json.Unmarshal([]byte(m.ExtParam), &ragAnswer); err != nil {
+					log.Printf("解析 RagAnswer 配置失败: %v", err)
+				} else {
+					vo.RagAnswer = &ragAnswer
+				}
+			case valobj.AdvisorTypeChatMemory:
+				var chatMemory valobj.ChatMemoryVO
+				if err := json.Unmarshal([]byte(m.ExtParam), &chatMemory); err != nil {
+					log.Printf("解析 ChatMemory 配置失败: %v", err)
+				} else {
+					vo.ChatMemory = &chatMemory
+				}
 			}
 		}
 
 		voList = append(voList, vo)
 	}
-	return voList
+	return voList, nil
+}
+
+// QueryAiClientVOListByClientIds 查询 AI Client VO 列表
+func (r *AgentRepository) QueryAiClientVOListByClientIds(clientIdList []int64) ([]valobj.AiClientVO, error) {
+	aiClients, err := r.clientDao.QueryClientConfigByIds(clientIdList)
+	if err != nil {
+		log.Printf("查询客户端配置失败: %v", err)
+		return nil, err
+	}
+	modelRels, err := r.clientModelRelDao.QueryRelByClientIds(clientIdList)
+	if err != nil {
+		log.Printf("查询模型组配置失败: %v", err)
+		return nil, err
+	}
+	modelRelMap := make(map[int64][]valobj.AiClientModelRelVO)
+	for _, rel := range modelRels {
+		modelRelMap[rel.ClientID] = append(modelRelMap[rel.ClientID], valobj.AiClientModelRelVO{
+			ID:       rel.ID,
+			ModelID:  rel.ModelID,
+			Priority: rel.Priority,
+			Weight:   rel.Weight,
+		})
+	}
+	voList := make([]valobj.AiClientVO, 0, len(aiClients))
+
+	for _, m := range aiClients {
+		vo := valobj.AiClientVO{
+			ClientID:    m.ID,
+			ClientName:  m.ClientName,
+			ModelID:     m.ModelID,
+			ModelRels:   modelRelMap[m.ID],
+			McpIDList:   parseIdList(m.McpIdList),
+			Description: m.Description,
+
+			ToolNamePrefix: m.ToolNamePrefix,
+		}
+		voList = append(voList, vo)
+	}
+	return voList, nil
+}
+
+// QueryAiClientSystemPromptVOListByClientIds 查询生效的 AI Client System Prompt VO 列表
+func (r *AgentRepository) QueryAiClientSystemPromptVOListByClientIds(clientIdList []int64) ([]valobj.AiClientSystemPromptVO, error) {
+	prompts, err := r.systemPromptDao.QueryActivePromptByClientIds(clientIdList)
+	if err != nil {
+		log.Printf("查询系统提示词配置失败: %v", err)
+		return nil, err
+	}
+	voList := make([]valobj.AiClientSystemPromptVO, 0, len(prompts))
+
+	for _, m := range prompts {
+		voList = append(voList, valobj.AiClientSystemPromptVO{
+			ID:            m.ID,
+			ClientID:      m.ClientID,
+			PromptName:    m.PromptName,
+			PromptContent: m.PromptContent,
+			Version:       m.Version,
+		})
+	}
+	return voList, nil
+}
+
+// QueryAiClientSystemPromptVersions 查询客户端同名提示词的全部版本，新版本在前
+func (r *AgentRepository) QueryAiClientSystemPromptVersions(clientId int64, promptName string) ([]valobj.AiClientSystemPromptVersionVO, error) {
+	prompts, err := r.systemPromptDao.QueryPromptVersions(clientId, promptName)
+	if err != nil {
+		log.Printf("查询系统提示词版本失败 clientId=%d promptName=%s: %v", clientId, promptName, err)
+		return nil, err
+	}
+	voList := make([]valobj.AiClientSystemPromptVersionVO, 0, len(prompts))
+	for _, m := range prompts {
+		voList = append(voList, toSystemPromptVersionVO(&m))
+	}
+	return voList, nil
+}
+
+// SaveAiClientSystemPromptVersion 新增提示词版本并设为生效，回填 ID、版本号和创建时间
+func (r *AgentRepository) SaveAiClientSystemPromptVersion(prompt *valobj.AiClientSystemPromptVersionVO) error {
+	m := &po.AiClientSystemPrompt{
+		ClientID:      prompt.ClientID,
+		PromptName:    prompt.PromptName,
+		PromptContent: prompt.PromptContent,
+		Description:   prompt.Description,
+	}
+	if err := r.systemPromptDao.InsertNewVersion(m); err != nil {
+		log.Printf("新增系统提示词版本失败 clientId=%d promptName=%s: %v", prompt.ClientID, prompt.PromptName, err)
+		return err
+	}
+	*prompt = toSystemPromptVersionVO(m)
+	return nil
+}
+
+// ActivateAiClientSystemPromptVersion 将指定版本设为生效，用于回滚
+func (r *AgentRepository) ActivateAiClientSystemPromptVersion(clientId int64, promptName string, version int) error {
+	if err := r.systemPromptDao.ActivateVersion(clientId, promptName, version); err != nil {
+		log.Printf("切换系统提示词版本失败 clientId=%d promptName=%s version=%d: %v", clientId, promptName, version, err)
+		return err
+	}
+	return nil
+}
+
+// toSystemPromptVersionVO 转换为提示词版本 VO
+func toSystemPromptVersionVO(m *po.AiClientSystemPrompt) valobj.AiClientSystemPromptVersionVO {
+	return valobj.AiClientSystemPromptVersionVO{
+		ID:            m.ID,
+		ClientID:      m.ClientID,
+		PromptName:    m.PromptName,
+		PromptContent: m.PromptContent,
+		Version:       m.Version,
+		Description:   m.Description,
+		Active:        m.Status == 1,
+		CreateTime:    m.CreateTime,
+	}
+}
+
+// ragEmbeddingModelIds 按客户端收集知识库问答顾问 ext_param 中引用的嵌入模型ID，解析失败的配置忽略
+func ragEmbeddingModelIds(advisors []po.AiClientAdvisor) map[int64][]int64 {
+	result := make(map[int64][]int64)
+	for _, advisor := range advisors {
+		if advisor.AdvisorType != valobj.AdvisorTypeRagAnswer || advisor.ExtParam == "" {
+			continue
+		}
+		var ragAnswer valobj.RagAnswerVO
+		if err := json.Unmarshal([]byte(advisor.ExtParam), &ragAnswer); err != nil || ragAnswer.EmbeddingModelID == 0 {
+			continue
+		}
+		result[advisor.ClientID] = append(result[advisor.ClientID], ragAnswer.EmbeddingModelID)
+	}
+	return result
+}
+
+// formatVersionTime 格式化版本中的更新时间，配置不存在时为 -
+func formatVersionTime(t time.Time, exists bool) string {
+	if !exists {
+		return "-"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// parseIdList 解析逗号分隔的ID列表，忽略非法项
+func parseIdList(value string) []int64 {
+	var ids []int64
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			log.Printf("解析ID %s 失败: %v", item, err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
 }
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+	"smart-weaver/internal/infrastructure/dao"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// MysqlChatMemory 基于 ai_chat_memory 表的对话记忆
+type MysqlChatMemory struct {
+	node.ConversationLocks
+	chatMemoryDao *dao.AiChatMemoryDao
+}
+
+var _ node.ChatMemory = (*MysqlChatMemory)(nil)
+
+// NewMysqlChatMemory 创建 MySQL 对话记忆
+func NewMysqlChatMemory(db *gorm.DB) *MysqlChatMemory {
+	return &MysqlChatMemory{chatMemoryDao: &dao.AiChatMemoryDao{DB: db}}
+}
+
+// Get 获取会话消息
+func (m *MysqlChatMemory) Get(ctx context.Context, conversationID string) ([]node.Message, error) {
+	list, err := m.withContext(ctx).QueryByConversationId(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("查询会话 %s 消息失败: %w", conversationID, err)
+	}
+
+	messages := make([]node.Message, 0, len(list))
+	for _, item := range list {
+		message := node.Message{
+			Role:       item.Role,
+			Content:    item.Content,
+			ToolCallID: item.ToolCallID,
+			Name:       item.Name,
+		}
+		if item.ToolCalls != "" {
+			if err := json.Unmarshal([]byte(item.ToolCalls), &message.ToolCalls); err != nil {
+				return nil, fmt.Errorf("解析会话 %s 工具调用失败: %w", conversationID, err)
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// Add 追加消息
+func (m *MysqlChatMemory) Add(ctx context.Context, conversationID string, messages ...node.Message) error {
+	list, err := toChatMemoryPOList(conversationID, messages)
+	if err != nil {
+		return err
+	}
+	return m.withContext(ctx).BatchInsert(list)
+}
+
+// Replace 替换会话消息
+func (m *MysqlChatMemory) Replace(ctx context.Context, conversationID string, messages []node.Message) error {
+	list, err := toChatMemoryPOList(conversationID, messages)
+	if err != nil {
+		return err
+	}
+	return m.withContext(ctx).ReplaceByConversationId(conversationID, list)
+}
+
+// Clear 清空会话
+func (m *MysqlChatMemory) Clear(ctx context.Context, conversationID string) error {
+	return m.withContext(ctx).DeleteByConversationId(conversationID)
+}
+
+// withContext 绑定请求上下文
+func (m *MysqlChatMemory) withContext(ctx context.Context) *dao.AiChatMemoryDao {
+	return &dao.AiChatMemoryDao{DB: m.chatMemoryDao.DB.WithContext(ctx)}
+}
+
+// toChatMemoryPOList 转换为持久化对象
+func toChatMemoryPOList(conversationID string, messages []node.Message) ([]po.AiChatMemory, error) {
+	list := make([]po.AiChatMemory, 0, len(messages))
+	for _, message := range messages {
+		item := po.AiChatMemory{
+			ConversationID: conversationID,
+			Role:           message.Role,
+			Content:        message.Content,
+			ToolCallID:     message.ToolCallID,
+			Name:           message.Name,
+		}
+		if len(message.ToolCalls) > 0 {
+			toolCalls, err := json.Marshal(message.ToolCalls)
+			if err != nil {
+				return nil, fmt.Errorf("序列化会话 %s 工具调用失败: %w", conversationID, err)
+			}
+			item.ToolCalls = string(toolCalls)
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+)
+
+// defaultChatMemoryKeyPrefix Redis 键前缀
+const defaultChatMemoryKeyPrefix = "ai:chat_memory:"
+
+// RedisChatMemory 基于 Redis 列表的对话记忆，每个会话一个列表，兼容 Redis 协议的存储均可使用
+type RedisChatMemory struct {
+	node.ConversationLocks
+	client    redis.UniversalClient
+	KeyPrefix string        // 键前缀
+	TTL       time.Duration // 会话过期时间，每次写入后刷新，0 表示不过期
+}
+
+var _ node.ChatMemory = (*RedisChatMemory)(nil)
+
+// NewRedisChatMemory 创建 Redis 对话记忆
+func NewRedisChatMemory(client redis.UniversalClient, ttl time.Duration) *RedisChatMemory {
+	return &RedisChatMemory{
+		client:    client,
+		KeyPrefix: defaultChatMemoryKeyPrefix,
+		TTL:       ttl,
+	}
+}
+
+// Get 获取会话消息
+func (m *RedisChatMemory) Get(ctx context.Context, conversationID string) ([]node.Message, error) {
+	values, err := m.client.LRange(ctx, m.key(conversationID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询会话 %s 消息失败: %w", conversationID, err)
+	}
+
+	messages := make([]node.Message, 0, len(values))
+	for _, value := range values {
+		var message node.Message
+		if err := json.Unmarshal([]byte(value), &message); err != nil {
+			return nil, fmt.Errorf("解析会话 %s 消息失败: %w", conversationID, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// Add 追加消息
+func (m *RedisChatMemory) Add(ctx context.Context, conversationID string, messages ...node.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	values, err := encodeMessages(messages)
+	if err != nil {
+		return err
+	}
+
+	key := m.key(conversationID)
+	_, err = m.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, values...)
+		m.expire(ctx, pipe, key)
+		return nil
+	})
+	return err
+}
+
+// Replace 替换会话消息
+func (m *RedisChatMemory) Replace(ctx context.Context, conversationID string, messages []node.Message) error {
+	values, err := encodeMessages(messages)
+	if err != nil {
+		return err
+	}
+
+	key := m.key(conversationID)
+	_, err = m.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(values) > 0 {
+			pipe.RPush(ctx, key, values...)
+			m.expire(ctx, pipe, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// Clear 清空会话
+func (m *RedisChatMemory) Clear(ctx context.Context, conversationID string) error {
+	return m.client.Del(ctx, m.key(conversationID)).Err()
+}
+
+// key 会话键
+func (m *RedisChatMemory) key(conversationID string) string {
+	return m.KeyPrefix + conversationID
+}
+
+// expire 刷新过期时间
+func (m *RedisChatMemory) expire(ctx context.Context, pipe redis.Pipeliner, key string) {
+	if m.TTL > 0 {
+		pipe.Expire(ctx, key, m.TTL)
+	}
+}
+
+// encodeMessages 序列化消息
+func encodeMessages(messages []node.Message) ([]any, error) {
+	values := make([]any, 0, len(messages))
+	for _, message := range messages {
+		value, err := json.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("序列化消息失败: %w", err)
+		}
+		values = append(values, string(value))
+	}
+	return values, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/domain/agent/adapter/repository"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/infrastructure/dao"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// UsageRepository 模型用量仓储实现
+type UsageRepository struct {
+	modelPriceDao  *dao.AiModelPriceDao
+	usageLedgerDao *dao.AiUsageLedgerDao
+}
+
+var _ repository.IUsageRepository = (*UsageRepository)(nil)
+
+// NewUsageRepository 创建模型用量仓储
+func NewUsageRepository(db *gorm.DB) *UsageRepository {
+	return &UsageRepository{
+		modelPriceDao:  &dao.AiModelPriceDao{DB: db},
+		usageLedgerDao: &dao.AiUsageLedgerDao{DB: db},
+	}
+}
+
+// QueryModelPrice 查询模型价格，未配置时返回 nil
+func (r *UsageRepository) QueryModelPrice(modelId int64) (*valobj.AiModelPriceVO, error) {
+	price, err := r.modelPriceDao.QueryPriceByModelId(modelId)
+	if err != nil {
+		log.Printf("查询模型价格失败 modelId=%d: %v", modelId, err)
+		return nil, err
+	}
+	if price == nil {
+		return nil, nil
+	}
+	return &valobj.AiModelPriceVO{
+		ModelID:          price.ModelID,
+		InputPrice:       price.InputPrice,
+		OutputPrice:      price.OutputPrice,
+		CachedInputPrice: price.CachedInputPrice,
+		Currency:         price.Currency,
+	}, nil
+}
+
+// SaveUsageLedger 保存一条用量流水
+func (r *UsageRepository) SaveUsageLedger(ledger *entity.AiUsageLedgerEntity) error {
+	return r.usageLedgerDao.Insert(&po.AiUsageLedger{
+		ModelID:          ledger.ModelID,
+		ModelName:        ledger.ModelName,
+		ClientID:         ledger.ClientID,
+		ConversationID:   ledger.ConversationID,
+		PromptTokens:     ledger.PromptTokens,
+		CompletionTokens: ledger.CompletionTokens,
+		CachedTokens:     ledger.CachedTokens,
+		TotalTokens:      ledger.TotalTokens,
+		Cost:             ledger.Cost,
+		Currency:         ledger.Currency,
+		CreateTime:       ledger.CreateTime,
+	})
+}
+
+// QueryUsageSummary 按维度聚合用量
+func (r *UsageRepository) QueryUsageSummary(query *entity.UsageQueryEntity) ([]valobj.UsageSummaryVO, error) {
+	list, err := r.usageLedgerDao.QuerySummary(query.GroupBy, query.StartTime, query.EndTime, query.ClientID, query.ModelID)
+	if err != nil {
+		log.Printf("查询用量汇总失败: %v", err)
+		return nil, err
+	}
+
+	result := make([]valobj.UsageSummaryVO, 0, len(list))
+	for _, item := range list {
+		result = append(result, valobj.UsageSummaryVO{
+			Day:              item.Day,
+			ClientID:         item.ClientID,
+			ModelID:          item.ModelID,
+			ModelName:        item.ModelName,
+			ConversationID:   item.ConversationID,
+			Requests:         item.Requests,
+			PromptTokens:     item.PromptTokens,
+			CompletionTokens: item.CompletionTokens,
+			CachedTokens:     item.CachedTokens,
+			TotalTokens:      item.TotalTokens,
+			Cost:             item.Cost,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// AiChatMemoryDao 对话记忆数据访问对象
+type AiChatMemoryDao struct {
+	DB *gorm.DB
+}
+
+// QueryByConversationId 按写入顺序查询会话消息
+func (dao *AiChatMemoryDao) QueryByConversationId(conversationId string) ([]po.AiChatMemory, error) {
+	var result []po.AiChatMemory
+	if err := dao.DB.Where("conversation_id = ?", conversationId).Order("id ASC").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BatchInsert 批量插入会话消息
+func (dao *AiChatMemoryDao) BatchInsert(list []po.AiChatMemory) error {
+	if len(list) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range list {
+		list[i].CreateTime = now
+	}
+	return dao.DB.Create(&list).Error
+}
+
+// DeleteByConversationId 删除会话全部消息
+func (dao *AiChatMemoryDao) DeleteByConversationId(conversationId string) error {
+	return dao.DB.Where("conversation_id = ?", conversationId).Delete(&po.AiChatMemory{}).Error
+}
+
+// ReplaceByConversationId 在同一事务内删除并重新写入会话消息
+func (dao *AiChatMemoryDao) ReplaceByConversationId(conversationId string, list []po.AiChatMemory) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		txDao := &AiChatMemoryDao{DB: tx}
+		if err := txDao.DeleteByConversationId(conversationId); err != nil {
+			return err
+		}
+		return txDao.BatchInsert(list)
+	})
+}
@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// AiClientAdvisorDao 顾问配置数据访问对象
+type AiClientAdvisorDao struct {
+	DB *gorm.DB
+}
+
+// QueryAllAdvisorConfig 查询所有顾问配置
+func (dao *AiClientAdvisorDao) QueryAllAdvisorConfig() ([]po.AiClientAdvisor, error) {
+	var result []po.AiClientAdvisor
+	if err := dao.DB.Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryAdvisorConfigById 根据ID查询顾问配置
+func (dao *AiClientAdvisorDao) QueryAdvisorConfigById(id int64) (*po.AiClientAdvisor, error) {
+	var m po.AiClientAdvisor
+	if err := dao.DB.First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Insert 插入顾问配置
+func (dao *AiClientAdvisorDao) Insert(m *po.AiClientAdvisor) error {
+	now := time.Now()
+	m.CreateTime = now
+	m.UpdateTime = now
+	return dao.DB.Create(m).Error
+}
+
+// Update 更新顾问配置
+func (dao *AiClientAdvisorDao) Update(m *po.AiClientAdvisor) error {
+	m.UpdateTime = time.Now()
+	return dao.DB.Save(m).Error
+}
+
+// DeleteById 根据ID删除顾问配置
+func (dao *AiClientAdvisorDao) DeleteById(id int64) error {
+	return dao.DB.Delete(&po.AiClientAdvisor{}, id).Error
+}
+
+// QueryAdvisorConfigByClientIds 根据客户端ID列表查询启用的顾问配置，按执行顺序排列
+func (dao *AiClientAdvisorDao) QueryAdvisorConfigByClientIds(clientIds []int64) ([]po.AiClientAdvisor, error) {
+	if len(clientIds) == 0 {
+		return nil, nil
+	}
+
+	var result []po.AiClientAdvisor
+	if err := dao.DB.Where("client_id IN ? AND status = ?", clientIds, 1).Order("order_num ASC, id ASC").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// AiClientDao 客户端配置数据访问对象
+type AiClientDao struct {
+	DB *gorm.DB
+}
+
+// QueryAllClientConfig 查询所有客户端配置
+func (dao *AiClientDao) QueryAllClientConfig() ([]po.AiClient, error) {
+	var result []po.AiClient
+	if err := dao.DB.Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryClientConfigById 根据ID查询客户端配置
+func (dao *AiClientDao) QueryClientConfigById(id int64) (*po.AiClient, error) {
+	var m po.AiClient
+	if err := dao.DB.First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Insert 插入客户端配置
+func (dao *AiClientDao) Insert(m *po.AiClient) error {
+	now := time.Now()
+	m.CreateTime = now
+	m.UpdateTime = now
+	return dao.DB.Create(m).Error
+}
+
+// Update 更新客户端配置
+func (dao *AiClientDao) Update(m *po.AiClient) error {
+	m.UpdateTime = time.Now()
+	return dao.DB.Save(m).Error
+}
+
+// DeleteById 根据ID删除客户端配置
+func (dao *AiClientDao) DeleteById(id int64) error {
+	return dao.DB.Delete(&po.AiClient{}, id).Error
+}
+
+// QueryClientConfigByIds 根据客户端ID列表查询启用的客户端配置
+func (dao *AiClientDao) QueryClientConfigByIds(clientIds []int64) ([]po.AiClient, error) {
+	if len(clientIds) == 0 {
+		return nil, nil
+	}
+
+	var result []po.AiClient
+	if err := dao.DB.Where("id IN ? AND status = ?", clientIds, 1).Order("id ASC").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryEnabledClientIds 查询所有启用的客户端ID
+func (dao *AiClientDao) QueryEnabledClientIds() ([]int64, error) {
+	var ids []int64
+	if err := dao.DB.Model(&po.AiClient{}).Where("status = ?", 1).Order("id ASC").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
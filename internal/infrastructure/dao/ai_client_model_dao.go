@@ -1,6 +1,8 @@
 package dao
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 	"smart-weaver/internal/infrastructure/dao/po"
 )
@@ -51,10 +53,22 @@ func (d *AiClientModelDao) DeleteById(id int64) error {
 	return d.DB.Delete(&po.AiClientModel{}, id).Error
 }
 
-// QueryModelConfigByClientIds 根据客户端ID列表查询模型配置
+// QueryModelConfigByClientIds 根据客户端ID列表查询启用客户端所引用的模型配置，包含模型组中的模型
 func (d *AiClientModelDao) QueryModelConfigByClientIds(clientIds []int64) ([]po.AiClientModel, error) {
 	var models []po.AiClientModel
-	err := d.DB.Where("id IN ?", clientIds).Find(&models).Error
+	modelIds := d.DB.Model(&po.AiClient{}).Select("model_id").Where("id IN ? AND status = ?", clientIds, 1)
+	relModelIds := d.DB.Model(&po.AiClientModelRel{}).Select("model_id").Where("client_id IN ? AND status = ?", clientIds, 1)
+	err := d.DB.Where("id IN (?) OR id IN (?)", modelIds, relModelIds).Find(&models).Error
+	return models, err
+}
+
+// QueryModelConfigByIds 根据ID列表查询模型配置
+func (d *AiClientModelDao) QueryModelConfigByIds(ids []int64) ([]po.AiClientModel, error) {
+	var models []po.AiClientModel
+	if len(ids) == 0 {
+		return models, nil
+	}
+	err := d.DB.Where("id IN ?", ids).Find(&models).Error
 	return models, err
 }
 
@@ -78,3 +92,20 @@ func (d *AiClientModelDao) QueryClientModelList(filter *po.AiClientModel) ([]po.
 	err := query.Find(&models).Error
 	return models, err
 }
+
+// QueryUpdateTimeByIds 根据ID列表查询模型配置的更新时间，用于配置变更检测
+func (dao *AiClientModelDao) QueryUpdateTimeByIds(ids []int64) (map[int64]time.Time, error) {
+	result := make(map[int64]time.Time, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []po.AiClientModel
+	if err := dao.DB.Select("id", "update_time").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ID] = row.UpdateTime
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// AiClientModelRelDao 客户端模型关联数据访问对象
+type AiClientModelRelDao struct {
+	DB *gorm.DB
+}
+
+// QueryRelByClientIds 根据客户端ID列表查询启用的模型关联，按客户端、优先级和ID排列
+func (dao *AiClientModelRelDao) QueryRelByClientIds(clientIds []int64) ([]po.AiClientModelRel, error) {
+	if len(clientIds) == 0 {
+		return nil, nil
+	}
+
+	var result []po.AiClientModelRel
+	if err := dao.DB.Where("client_id IN ? AND status = ?", clientIds, 1).Order("client_id ASC, priority ASC, id ASC").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Insert 插入模型关联
+func (dao *AiClientModelRelDao) Insert(m *po.AiClientModelRel) error {
+	now := time.Now()
+	m.CreateTime = now
+	m.UpdateTime = now
+	return dao.DB.Create(m).Error
+}
+
+// Update 更新模型关联
+func (dao *AiClientModelRelDao) Update(m *po.AiClientModelRel) error {
+	m.UpdateTime = time.Now()
+	return dao.DB.Save(m).Error
+}
+
+// DeleteById 根据ID删除模型关联
+func (dao *AiClientModelRelDao) DeleteById(id int64) error {
+	return dao.DB.Delete(&po.AiClientModelRel{}, id).Error
+}
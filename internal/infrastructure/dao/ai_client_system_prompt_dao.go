@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// AiClientSystemPromptDao 系统提示词数据访问对象
+type AiClientSystemPromptDao struct {
+	DB *gorm.DB
+}
+
+// QueryActivePromptByClientIds 根据客户端ID列表查询生效的提示词，按客户端和ID排列
+func (dao *AiClientSystemPromptDao) QueryActivePromptByClientIds(clientIds []int64) ([]po.AiClientSystemPrompt, error) {
+	if len(clientIds) == 0 {
+		return nil, nil
+	}
+
+	var result []po.AiClientSystemPrompt
+	if err := dao.DB.Where("client_id IN ? AND status = ?", clientIds, 1).Order("client_id ASC, id ASC").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryPromptVersions 查询客户端同名提示词的全部版本，新版本在前
+func (dao *AiClientSystemPromptDao) QueryPromptVersions(clientId int64, promptName string) ([]po.AiClientSystemPrompt, error) {
+	var result []po.AiClientSystemPrompt
+	if err := dao.DB.Where("client_id = ? AND prompt_name = ?", clientId, promptName).Order("version DESC").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InsertNewVersion 新增提示词版本并设为生效，原生效版本转为历史版本
+func (dao *AiClientSystemPromptDao) InsertNewVersion(m *po.AiClientSystemPrompt) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		var maxVersion int
+		if err := tx.Model(&po.AiClientSystemPrompt{}).
+			Where("client_id = ? AND prompt_name = ?", m.ClientID, m.PromptName).
+			Select("COALESCE(MAX(version), 0)").Scan(&maxVersion).Error; err != nil {
+			return err
+		}
+		if err := deactivatePrompt(tx, m.ClientID, m.PromptName); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		m.ID = 0
+		m.Version = maxVersion + 1
+		m.Status = 1
+		m.CreateTime = now
+		m.UpdateTime = now
+		return tx.Create(m).Error
+	})
+}
+
+// ActivateVersion 将指定版本设为生效，用于回滚
+func (dao *AiClientSystemPromptDao) ActivateVersion(clientId int64, promptName string, version int) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		var target po.AiClientSystemPrompt
+		if err := tx.Where("client_id = ? AND prompt_name = ? AND version = ?", clientId, promptName, version).First(&target).Error; err != nil {
+			return err
+		}
+		if err := deactivatePrompt(tx, clientId, promptName); err != nil {
+			return err
+		}
+		return tx.Model(&target).Updates(map[string]any{"status": 1, "update_time": time.Now()}).Error
+	})
+}
+
+// deactivatePrompt 将客户端同名提示词的生效版本转为历史版本
+func deactivatePrompt(tx *gorm.DB, clientId int64, promptName string) error {
+	return tx.Model(&po.AiClientSystemPrompt{}).
+		Where("client_id = ? AND prompt_name = ? AND status = ?", clientId, promptName, 1).
+		Updates(map[string]any{"status": 0, "update_time": time.Now()}).Error
+}
@@ -58,15 +58,32 @@ func (dao *AiClientToolMcpDao) DeleteById(id int64) error {
 	return dao.DB.Delete(&po.AiClientToolMcp{}, id).Error
 }
 
-// QueryMcpConfigByClientIds 根据客户端ID列表查询MCP配置
-func (dao *AiClientToolMcpDao) QueryMcpConfigByClientIds(clientIds []int64) ([]po.AiClientToolMcp, error) {
-	if len(clientIds) == 0 {
+// QueryMcpConfigByIds 根据ID列表查询启用的MCP配置
+func (dao *AiClientToolMcpDao) QueryMcpConfigByIds(ids []int64) ([]po.AiClientToolMcp, error) {
+	if len(ids) == 0 {
 		return nil, nil
 	}
 
 	var result []po.AiClientToolMcp
-	if err := dao.DB.Where("client_id IN ?", clientIds).Find(&result).Error; err != nil {
+	if err := dao.DB.Where("id IN ? AND status = ?", ids, 1).Find(&result).Error; err != nil {
 		return nil, err
 	}
 	return result, nil
 }
+
+// QueryUpdateTimeByIds 根据ID列表查询MCP配置的更新时间，用于配置变更检测
+func (dao *AiClientToolMcpDao) QueryUpdateTimeByIds(ids []int64) (map[int64]time.Time, error) {
+	result := make(map[int64]time.Time, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []po.AiClientToolMcp
+	if err := dao.DB.Select("id", "update_time").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ID] = row.UpdateTime
+	}
+	return result, nil
+}
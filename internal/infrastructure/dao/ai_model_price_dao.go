@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// AiModelPriceDao 模型价格数据访问对象
+type AiModelPriceDao struct {
+	DB *gorm.DB
+}
+
+// QueryPriceByModelId 查询模型启用的价格，未配置时返回 nil
+func (dao *AiModelPriceDao) QueryPriceByModelId(modelId int64) (*po.AiModelPrice, error) {
+	var result []po.AiModelPrice
+	if err := dao.DB.Where("model_id = ? AND status = ?", modelId, 1).Limit(1).Find(&result).Error; err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return &result[0], nil
+}
+
+// Insert 插入模型价格
+func (dao *AiModelPriceDao) Insert(m *po.AiModelPrice) error {
+	now := time.Now()
+	m.CreateTime = now
+	m.UpdateTime = now
+	return dao.DB.Create(m).Error
+}
+
+// Update 更新模型价格
+func (dao *AiModelPriceDao) Update(m *po.AiModelPrice) error {
+	m.UpdateTime = time.Now()
+	return dao.DB.Save(m).Error
+}
@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"smart-weaver/internal/infrastructure/dao/po"
+)
+
+// usageSummaryDimensions 用量聚合维度 -> 查询列
+var usageSummaryDimensions = map[string][]string{
+	"day":          {"DATE_FORMAT(create_time, '%Y-%m-%d') AS day"},
+	"client":       {"client_id"},
+	"model":        {"model_id", "MAX(model_name) AS model_name"},
+	"conversation": {"conversation_id"},
+}
+
+// usageSummaryGroupColumns 用量聚合维度 -> 分组列
+var usageSummaryGroupColumns = map[string]string{
+	"day":          "day",
+	"client":       "client_id",
+	"model":        "model_id",
+	"conversation": "conversation_id",
+}
+
+// AiUsageLedgerDao 用量流水数据访问对象
+type AiUsageLedgerDao struct {
+	DB *gorm.DB
+}
+
+// Insert 插入用量流水，未设置创建时间时取当前时间
+func (dao *AiUsageLedgerDao) Insert(m *po.AiUsageLedger) error {
+	if m.CreateTime.IsZero() {
+		m.CreateTime = time.Now()
+	}
+	return dao.DB.Create(m).Error
+}
+
+// QuerySummary 按维度聚合 [startTime, endTime) 内的用量，维度为 day、client、model、conversation，clientId、modelId 为0时不过滤
+func (dao *AiUsageLedgerDao) QuerySummary(groupBy []string, startTime, endTime time.Time, clientId, modelId int64) ([]po.AiUsageSummary, error) {
+	var selects, groups []string
+	for _, dimension := range groupBy {
+		columns, ok := usageSummaryDimensions[dimension]
+		if !ok {
+			return nil, fmt.Errorf("不支持的聚合维度 %s", dimension)
+		}
+		selects = append(selects, columns...)
+		groups = append(groups, usageSummaryGroupColumns[dimension])
+	}
+	selects = append(selects,
+		"COUNT(*) AS requests",
+		"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens",
+		"COALESCE(SUM(completion_tokens), 0) AS completion_tokens",
+		"COALESCE(SUM(cached_tokens), 0) AS cached_tokens",
+		"COALESCE(SUM(total_tokens), 0) AS total_tokens",
+		"COALESCE(SUM(cost), 0) AS cost",
+	)
+
+	query := dao.DB.Model(&po.AiUsageLedger{}).
+		Select(strings.Join(selects, ", ")).
+		Where("create_time >= ? AND create_time < ?", startTime, endTime)
+	if clientId > 0 {
+		query = query.Where("client_id = ?", clientId)
+	}
+	if modelId > 0 {
+		query = query.Where("model_id = ?", modelId)
+	}
+	if len(groups) > 0 {
+		query = query.Group(strings.Join(groups, ", ")).Order(strings.Join(groups, ", "))
+	}
+
+	var result []po.AiUsageSummary
+	if err := query.Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,35 @@
+package po
+
+import "time"
+
+// AiChatMemory 对话记忆消息表
+type AiChatMemory struct {
+	// 主键ID，自增，同一会话按ID顺序读取
+	ID int64 `json:"id" gorm:"primaryKey;autoIncrement"`
+
+	// 会话ID
+	ConversationID string `json:"conversation_id" gorm:"size:128;index"`
+
+	// 角色(system/user/assistant/tool)
+	Role string `json:"role" gorm:"size:32"`
+
+	// 消息内容
+	Content string `json:"content" gorm:"type:mediumtext"`
+
+	// 工具调用(JSON)
+	ToolCalls string `json:"tool_calls" gorm:"type:text"`
+
+	// 工具调用ID
+	ToolCallID string `json:"tool_call_id" gorm:"size:128"`
+
+	// 工具名称
+	Name string `json:"name" gorm:"size:128"`
+
+	// 创建时间
+	CreateTime time.Time `json:"create_time"`
+}
+
+// TableName 表名
+func (AiChatMemory) TableName() string {
+	return "ai_chat_memory"
+}
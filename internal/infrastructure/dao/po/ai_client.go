@@ -0,0 +1,38 @@
+package po
+
+import "time"
+
+// AiClient 客户端配置表
+type AiClient struct {
+	// 主键ID，即客户端ID
+	ID int64 `json:"id"`
+
+	// 客户端名称
+	ClientName string `json:"client_name"`
+
+	// 对话模型ID
+	ModelID int64 `json:"model_id"`
+
+	// 客户端级 MCP 工具ID列表，逗号分隔，如 1,2
+	McpIdList string `json:"mcp_id_list"`
+
+	// MCP 工具命名策略(on_conflict/always/never)，多个 MCP 服务存在同名工具时使用，为空时为 on_conflict
+	ToolNamePrefix string `json:"tool_name_prefix"`
+
+	// 描述
+	Description string `json:"description"`
+
+	// 状态(0:禁用,1:启用)
+	Status int `json:"status"`
+
+	// 创建时间
+	CreateTime time.Time `json:"create_time"`
+
+	// 更新时间
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// TableName 表名
+func (AiClient) TableName() string {
+	return "ai_client"
+}
@@ -0,0 +1,38 @@
+package po
+
+import "time"
+
+// AiClientAdvisor 客户端顾问配置表
+type AiClientAdvisor struct {
+	// 主键ID
+	ID int64 `json:"id"`
+
+	// 客户端ID
+	ClientID int64 `json:"client_id"`
+
+	// 顾问名称
+	AdvisorName string `json:"advisor_name"`
+
+	// 顾问类型(RagAnswer/ChatMemory)
+	AdvisorType string `json:"advisor_type"`
+
+	// 执行顺序，越小越先执行
+	OrderNum int `json:"order_num"`
+
+	// 扩展参数(JSON)
+	ExtParam string `json:"ext_param"`
+
+	// 状态(0:禁用,1:启用)
+	Status int `json:"status"`
+
+	// 创建时间
+	CreateTime time.Time `json:"create_time"`
+
+	// 更新时间
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// TableName 表名
+func (AiClientAdvisor) TableName() string {
+	return "ai_client_advisor"
+}
@@ -18,6 +18,11 @@ type AiClientModel struct {
 	ModelType       string    `json:"model_type"`
 	ModelVersion    string    `json:"model_version"`
 	Timeout         int       `json:"timeout"`
+	RpmLimit        int       `json:"rpm_limit"`        // 每分钟请求数上限，0 表示不限制
+	TpmLimit        int       `json:"tpm_limit"`        // 每分钟 token 数上限，0 表示不限制
+	MaxConcurrency  int       `json:"max_concurrency"`  // 最大并发请求数，0 表示不限制
+	QueueTimeout    int       `json:"queue_timeout"`    // 超过限制时排队等待的秒数，0 表示使用默认值
+	ToolNamePrefix  string    `json:"tool_name_prefix"` // MCP 工具命名策略 on_conflict / always / never，为空时为 on_conflict
 	Status          int       `json:"status"`
 	CreateTime      time.Time `json:"create_time"`
 	UpdateTime      time.Time `json:"update_time"`
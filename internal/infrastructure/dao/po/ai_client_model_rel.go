@@ -0,0 +1,35 @@
+package po
+
+import "time"
+
+// AiClientModelRel 客户端模型关联表，一个客户端关联多个模型组成模型组
+type AiClientModelRel struct {
+	// 主键ID
+	ID int64 `json:"id"`
+
+	// 客户端ID
+	ClientID int64 `json:"client_id" gorm:"index:idx_client_model_rel"`
+
+	// 模型ID
+	ModelID int64 `json:"model_id"`
+
+	// 优先级，越小越优先，高优先级的模型全部不可用时才使用低优先级
+	Priority int `json:"priority"`
+
+	// 权重，同优先级的模型按权重轮询，小于等于0时按1处理
+	Weight int `json:"weight"`
+
+	// 状态(0:禁用,1:启用)
+	Status int `json:"status"`
+
+	// 创建时间
+	CreateTime time.Time `json:"create_time"`
+
+	// 更新时间
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// TableName 表名
+func (AiClientModelRel) TableName() string {
+	return "ai_client_model_rel"
+}
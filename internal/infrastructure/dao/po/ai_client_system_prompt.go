@@ -0,0 +1,38 @@
+package po
+
+import "time"
+
+// AiClientSystemPrompt 客户端系统提示词表，同一客户端同名提示词每次修改新增一个版本
+type AiClientSystemPrompt struct {
+	// 主键ID
+	ID int64 `json:"id"`
+
+	// 客户端ID
+	ClientID int64 `json:"client_id" gorm:"index:idx_client_prompt_version"`
+
+	// 提示词名称
+	PromptName string `json:"prompt_name" gorm:"size:64;index:idx_client_prompt_version"`
+
+	// 提示词内容，支持 {{变量}} 占位符
+	PromptContent string `json:"prompt_content" gorm:"type:text"`
+
+	// 版本号，从 1 开始递增
+	Version int `json:"version" gorm:"index:idx_client_prompt_version"`
+
+	// 描述
+	Description string `json:"description"`
+
+	// 状态(0:历史版本,1:生效版本)，同一客户端同名提示词只有一个生效版本
+	Status int `json:"status"`
+
+	// 创建时间
+	CreateTime time.Time `json:"create_time"`
+
+	// 更新时间
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// TableName 表名
+func (AiClientSystemPrompt) TableName() string {
+	return "ai_client_system_prompt"
+}
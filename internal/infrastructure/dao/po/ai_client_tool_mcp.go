@@ -10,7 +10,7 @@ type AiClientToolMcp struct {
 	// MCP名称
 	McpName string `json:"mcp_name"`
 
-	// 传输类型(sse/stdio)
+	// 传输类型(sse/stdio/streamable_http)
 	TransportType string `json:"transport_type"`
 
 	// 传输配置
@@ -0,0 +1,38 @@
+package po
+
+import "time"
+
+// AiModelPrice 模型价格表，单价按每百万 token 计
+type AiModelPrice struct {
+	// 主键ID
+	ID int64 `json:"id"`
+
+	// 模型ID
+	ModelID int64 `json:"model_id" gorm:"uniqueIndex"`
+
+	// 输入单价
+	InputPrice float64 `json:"input_price" gorm:"type:decimal(20,8)"`
+
+	// 输出单价
+	OutputPrice float64 `json:"output_price" gorm:"type:decimal(20,8)"`
+
+	// 缓存命中的输入单价，为0时按输入单价计
+	CachedInputPrice float64 `json:"cached_input_price" gorm:"type:decimal(20,8)"`
+
+	// 币种
+	Currency string `json:"currency" gorm:"size:16"`
+
+	// 状态(0:禁用,1:启用)
+	Status int `json:"status"`
+
+	// 创建时间
+	CreateTime time.Time `json:"create_time"`
+
+	// 更新时间
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// TableName 表名
+func (AiModelPrice) TableName() string {
+	return "ai_model_price"
+}
@@ -0,0 +1,47 @@
+package po
+
+import "time"
+
+// AiUsageLedger 模型用量流水表，每次模型调用一条
+type AiUsageLedger struct {
+	// 主键ID
+	ID int64 `json:"id" gorm:"primaryKey;autoIncrement"`
+
+	// 模型ID
+	ModelID int64 `json:"model_id" gorm:"index"`
+
+	// 模型名称，取模型响应中的名称
+	ModelName string `json:"model_name" gorm:"size:128"`
+
+	// 客户端ID
+	ClientID int64 `json:"client_id" gorm:"index"`
+
+	// 会话ID
+	ConversationID string `json:"conversation_id" gorm:"size:128;index"`
+
+	// 输入 token 数
+	PromptTokens int `json:"prompt_tokens"`
+
+	// 输出 token 数
+	CompletionTokens int `json:"completion_tokens"`
+
+	// 缓存命中的输入 token 数，包含在输入 token 数中
+	CachedTokens int `json:"cached_tokens"`
+
+	// 总 token 数
+	TotalTokens int `json:"total_tokens"`
+
+	// 费用，模型未配置价格时为0
+	Cost float64 `json:"cost" gorm:"type:decimal(20,8)"`
+
+	// 币种
+	Currency string `json:"currency" gorm:"size:16"`
+
+	// 创建时间
+	CreateTime time.Time `json:"create_time" gorm:"index"`
+}
+
+// TableName 表名
+func (AiUsageLedger) TableName() string {
+	return "ai_usage_ledger"
+}
@@ -0,0 +1,37 @@
+package po
+
+// AiUsageSummary 用量流水聚合结果，未参与分组的维度为零值
+type AiUsageSummary struct {
+	// 日期(2006-01-02)
+	Day string `json:"day"`
+
+	// 客户端ID
+	ClientID int64 `json:"client_id"`
+
+	// 模型ID
+	ModelID int64 `json:"model_id"`
+
+	// 模型名称
+	ModelName string `json:"model_name"`
+
+	// 会话ID
+	ConversationID string `json:"conversation_id"`
+
+	// 请求次数
+	Requests int64 `json:"requests"`
+
+	// 输入 token 数
+	PromptTokens int64 `json:"prompt_tokens"`
+
+	// 输出 token 数
+	CompletionTokens int64 `json:"completion_tokens"`
+
+	// 缓存命中的输入 token 数
+	CachedTokens int64 `json:"cached_tokens"`
+
+	// 总 token 数
+	TotalTokens int64 `json:"total_tokens"`
+
+	// 费用
+	Cost float64 `json:"cost"`
+}
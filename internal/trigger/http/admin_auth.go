@@ -0,0 +1,34 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"smart-weaver/internal/api/dto/response"
+	"smart-weaver/internal/types/common"
+)
+
+// adminTokenHeader 管理接口令牌请求头，也可使用 Authorization: Bearer <token>
+const adminTokenHeader = "X-Admin-Token"
+
+// AdminAuth 管理接口鉴权，请求需携带与配置一致的令牌；未配置令牌时拒绝全部管理请求
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.Error[any](common.ResponseUnauthorized.Code, "未配置管理接口令牌，管理接口不可用"))
+			return
+		}
+
+		provided := c.GetHeader(adminTokenHeader)
+		if provided == "" {
+			provided = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.Error[any](common.ResponseUnauthorized.Code, "管理接口令牌无效"))
+			return
+		}
+		c.Next()
+	}
+}
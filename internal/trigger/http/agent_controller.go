@@ -0,0 +1,150 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"smart-weaver/internal/api/dto/response"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/service"
+	"smart-weaver/internal/domain/agent/service/armory/node"
+	"smart-weaver/internal/domain/agent/service/chat"
+	"smart-weaver/internal/types/common"
+)
+
+// ChatStreamDelta 流式对话片段
+type ChatStreamDelta struct {
+	Content string `json:"content"`
+}
+
+// AgentController 智能体接口
+type AgentController struct {
+	agentService service.IAgentService
+	chatService  chat.IChatService
+}
+
+// NewAgentController 创建智能体接口
+func NewAgentController(agentService service.IAgentService, chatService chat.IChatService) *AgentController {
+	return &AgentController{agentService: agentService, chatService: chatService}
+}
+
+// RegisterRoutes 注册对话路由
+func (ctl *AgentController) RegisterRoutes(api *gin.RouterGroup) {
+	api.POST("/agent/chat", ctl.Chat)
+	api.GET("/agent/chat/stream", ctl.ChatStream)
+}
+
+// RegisterAdminRoutes 注册构建及Bean查询路由，admin 为需要管理令牌的路由组
+func (ctl *AgentController) RegisterAdminRoutes(admin *gin.RouterGroup) {
+	admin.POST("/agent/armory", ctl.Armory)
+	admin.GET("/agent/beans", ctl.Beans)
+}
+
+// Armory 运行时（重新）构建客户端，请求体 {"client_id_list":[1,2]}，返回构建成功和失败的Bean
+func (ctl *AgentController) Armory(c *gin.Context) {
+	var req entity.AiAgentEngineStarterEntity
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+	if len(req.ClientIDList) == 0 {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, "client_id_list不能为空"))
+		return
+	}
+
+	report, err := ctl.agentService.DoArmory(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, response.Error[any](common.ResponseUnError.Code, err.Error()))
+		return
+	}
+	if len(report.Failed) > 0 {
+		c.JSON(http.StatusOK, response.NewResponse(common.ResponseUnError.Code, "部分Bean构建失败", report))
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(report))
+}
+
+// Beans 列出已注册的Bean名称和类型
+func (ctl *AgentController) Beans(c *gin.Context) {
+	c.JSON(http.StatusOK, response.Success(ctl.agentService.ListBeans()))
+}
+
+// Chat 同步对话，请求体 {"clientId":1,"conversationId":"c1","message":"你好","variables":{"name":"小明"}}
+func (ctl *AgentController) Chat(c *gin.Context) {
+	var req chat.ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+
+	result, err := ctl.chatService.Chat(c.Request.Context(), &req)
+	if err != nil {
+		ctl.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// ChatStream 流式对话，参数通过 query 传递，提示词变量以 variables[name]=value 传递，以 SSE 返回
+// 事件 message 为内容片段，done 为结束标记，error 为异常
+func (ctl *AgentController) ChatStream(c *gin.Context) {
+	var req chat.ChatRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+	if variables := c.QueryMap("variables"); len(variables) > 0 {
+		req.Variables = make(map[string]any, len(variables))
+		for name, value := range variables {
+			req.Variables[name] = value
+		}
+	}
+
+	chunks, err := ctl.chatService.ChatStream(c.Request.Context(), &req)
+	if err != nil {
+		ctl.writeError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		chunk, ok := <-chunks
+		if !ok {
+			c.SSEvent("done", response.Success[any](nil))
+			return false
+		}
+		if chunk.Err != nil {
+			c.SSEvent("error", response.Error[any](errorCode(chunk.Err), chunk.Err.Error()))
+			return false
+		}
+		if content := chunk.Response.GetText(); content != "" {
+			c.SSEvent("message", ChatStreamDelta{Content: content})
+		}
+		return true
+	})
+}
+
+// writeError 输出对话错误，客户端未构建视为参数错误，限流返回 429
+func (ctl *AgentController) writeError(c *gin.Context, err error) {
+	if errors.Is(err, chat.ErrChatClientNotFound) {
+		c.JSON(http.StatusNotFound, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+	if errors.Is(err, node.ErrRateLimited) {
+		c.JSON(http.StatusTooManyRequests, response.Error[any](common.ResponseRateLimited.Code, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, response.Error[any](common.ResponseUnError.Code, err.Error()))
+}
+
+// errorCode 对话错误对应的响应码
+func errorCode(err error) string {
+	if errors.Is(err, node.ErrRateLimited) {
+		return common.ResponseRateLimited.Code
+	}
+	return common.ResponseUnError.Code
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"smart-weaver/internal/api/dto/response"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/prompt"
+	"smart-weaver/internal/types/common"
+)
+
+// PublishPromptRequest 发布提示词版本请求
+type PublishPromptRequest struct {
+	ClientID      int64  `json:"clientId" binding:"required"`
+	PromptName    string `json:"promptName" binding:"required"`
+	PromptContent string `json:"promptContent" binding:"required"`
+	Description   string `json:"description"`
+}
+
+// ActivatePromptRequest 切换提示词版本请求
+type ActivatePromptRequest struct {
+	ClientID   int64  `json:"clientId" binding:"required"`
+	PromptName string `json:"promptName" binding:"required"`
+	Version    int    `json:"version" binding:"required"`
+}
+
+// PromptController 系统提示词版本管理接口
+type PromptController struct {
+	promptService prompt.IPromptService
+}
+
+// NewPromptController 创建系统提示词版本管理接口
+func NewPromptController(promptService prompt.IPromptService) *PromptController {
+	return &PromptController{promptService: promptService}
+}
+
+// RegisterRoutes 注册路由，admin 为需要鉴权的管理接口分组
+func (ctl *PromptController) RegisterRoutes(admin *gin.RouterGroup) {
+	admin.GET("/prompts", ctl.QueryVersions)
+	admin.POST("/prompts", ctl.Publish)
+	admin.POST("/prompts/activate", ctl.Activate)
+}
+
+// QueryVersions 查询提示词全部版本，参数 clientId、promptName 必填
+func (ctl *PromptController) QueryVersions(c *gin.Context) {
+	clientId, err := strconv.ParseInt(c.Query("clientId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, "clientId应为整数"))
+		return
+	}
+
+	result, err := ctl.promptService.QueryPromptVersions(clientId, c.Query("promptName"))
+	if err != nil {
+		ctl.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// Publish 发布新版本并设为生效，请求体 {"clientId":1,"promptName":"default","promptContent":"你是{{role}}","description":"..."}
+func (ctl *PromptController) Publish(c *gin.Context) {
+	var req PublishPromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+
+	result, err := ctl.promptService.PublishPromptVersion(&valobj.AiClientSystemPromptVersionVO{
+		ClientID:      req.ClientID,
+		PromptName:    req.PromptName,
+		PromptContent: req.PromptContent,
+		Description:   req.Description,
+	})
+	if err != nil {
+		ctl.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// Activate 将指定版本设为生效，用于回滚，请求体 {"clientId":1,"promptName":"default","version":2}
+func (ctl *PromptController) Activate(c *gin.Context) {
+	var req ActivatePromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+
+	if err := ctl.promptService.ActivatePromptVersion(req.ClientID, req.PromptName, req.Version); err != nil {
+		ctl.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success[any](nil))
+}
+
+// writeError 输出提示词管理错误，参数错误返回 400，版本不存在返回 404
+func (ctl *PromptController) writeError(c *gin.Context, err error) {
+	if errors.Is(err, prompt.ErrInvalidPromptRequest) {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+	if errors.Is(err, prompt.ErrPromptVersionNotFound) {
+		c.JSON(http.StatusNotFound, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, response.Error[any](common.ResponseUnError.Code, err.Error()))
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"smart-weaver/internal/api/dto/response"
+	"smart-weaver/internal/domain/agent/service/rag"
+	"smart-weaver/internal/types/common"
+)
+
+// maxUploadFileSize 单个上传文件大小上限
+const maxUploadFileSize = 20 << 20
+
+// RagUploadFileResult 单个文件上传结果
+type RagUploadFileResult struct {
+	FileName  string `json:"fileName"`
+	Documents int    `json:"documents"`
+	Chunks    int    `json:"chunks"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RagController 知识库接口
+type RagController struct {
+	ragService rag.IRagService
+}
+
+// NewRagController 创建知识库接口
+func NewRagController(ragService rag.IRagService) *RagController {
+	return &RagController{ragService: ragService}
+}
+
+// RegisterRoutes 注册路由，admin 为需要管理令牌的路由组
+func (ctl *RagController) RegisterRoutes(admin *gin.RouterGroup) {
+	admin.POST("/rag/upload", ctl.Upload)
+	admin.POST("/rag/git", ctl.IngestGitRepository)
+}
+
+// Upload 上传文件到知识库，表单字段 ragTag 为知识库标签，file 可传多个
+func (ctl *RagController) Upload(c *gin.Context) {
+	ragTag := c.PostForm("ragTag")
+	if ragTag == "" {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, "ragTag不能为空"))
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, "file不能为空"))
+		return
+	}
+
+	results := make([]RagUploadFileResult, 0, len(form.File["file"]))
+	succeeded := 0
+	for _, fileHeader := range form.File["file"] {
+		result := RagUploadFileResult{FileName: fileHeader.Filename}
+
+		content, err := readUploadFile(fileHeader)
+		if err == nil {
+			var ingestResult *rag.IngestResult
+			if ingestResult, err = ctl.ragService.UploadFile(c.Request.Context(), ragTag, fileHeader.Filename, content); err == nil {
+				result.Documents = ingestResult.Documents
+				result.Chunks = ingestResult.Chunks
+				succeeded++
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	if succeeded == 0 {
+		c.JSON(http.StatusOK, response.NewResponse(common.ResponseUnError.Code, "文件上传失败", results))
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(results))
+}
+
+// IngestGitRepository 导入代码仓库到知识库，重复导入时只处理变更文件
+func (ctl *RagController) IngestGitRepository(c *gin.Context) {
+	var req rag.GitIngestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+
+	result, err := ctl.ragService.IngestGitRepository(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, response.Error[any](common.ResponseUnError.Code, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// readUploadFile 读取上传文件内容并校验大小
+func readUploadFile(fileHeader *multipart.FileHeader) ([]byte, error) {
+	if fileHeader.Size > maxUploadFileSize {
+		return nil, fmt.Errorf("文件大小超过上限 %dMB", maxUploadFileSize>>20)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, maxUploadFileSize))
+}
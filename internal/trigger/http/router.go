@@ -8,10 +8,15 @@ import (
 	"github.com/patrickmn/go-cache"
 	"gorm.io/gorm"
 	"smart-weaver/internal/config"
+	"smart-weaver/internal/domain/agent/service"
+	"smart-weaver/internal/domain/agent/service/chat"
+	"smart-weaver/internal/domain/agent/service/prompt"
+	"smart-weaver/internal/domain/agent/service/rag"
+	"smart-weaver/internal/domain/agent/service/usage"
 )
 
-// SetupRouter 设置路由
-func SetupRouter(db *gorm.DB, cache *cache.Cache, threadPool *config.ThreadPoolExecutor) *gin.Engine {
+// SetupRouter 设置路由，/api/v1/admin 下的管理接口需携带 adminToken
+func SetupRouter(db *gorm.DB, cache *cache.Cache, threadPool *config.ThreadPoolExecutor, adminToken string, ragService rag.IRagService, agentService service.IAgentService, chatService chat.IChatService, promptService prompt.IPromptService, usageService usage.IUsageService) *gin.Engine {
 	router := gin.Default()
 
 	// 健康检查
@@ -58,6 +63,31 @@ func SetupRouter(db *gorm.DB, cache *cache.Cache, threadPool *config.ThreadPoolE
 			}
 			c.JSON(http.StatusOK, gin.H{"key": key, "value": value})
 		})
+
+		// 管理接口
+		admin := api.Group("/admin", AdminAuth(adminToken))
+
+		// 知识库入库接口，向量存储不可用时不注册
+		if ragService != nil {
+			NewRagController(ragService).RegisterRoutes(admin)
+		}
+
+		// 智能体对话接口，构建及Bean查询接口属于管理接口
+		if agentService != nil && chatService != nil {
+			agentController := NewAgentController(agentService, chatService)
+			agentController.RegisterRoutes(api)
+			agentController.RegisterAdminRoutes(admin)
+		}
+
+		// 系统提示词版本管理接口
+		if promptService != nil {
+			NewPromptController(promptService).RegisterRoutes(admin)
+		}
+
+		// 模型用量管理接口
+		if usageService != nil {
+			NewUsageController(usageService).RegisterRoutes(admin)
+		}
 	}
 
 	return router
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"smart-weaver/internal/api/dto/response"
+	"smart-weaver/internal/domain/agent/model/entity"
+	"smart-weaver/internal/domain/agent/model/valobj"
+	"smart-weaver/internal/domain/agent/service/usage"
+	"smart-weaver/internal/types/common"
+)
+
+// usageDateLayout 用量查询日期格式
+const usageDateLayout = "2006-01-02"
+
+// defaultUsageDays 未指定开始日期时查询的天数
+const defaultUsageDays = 7
+
+// UsageController 模型用量管理接口
+type UsageController struct {
+	usageService usage.IUsageService
+}
+
+// NewUsageController 创建模型用量管理接口
+func NewUsageController(usageService usage.IUsageService) *UsageController {
+	return &UsageController{usageService: usageService}
+}
+
+// RegisterRoutes 注册路由，admin 为需要鉴权的管理接口分组
+func (ctl *UsageController) RegisterRoutes(admin *gin.RouterGroup) {
+	admin.GET("/usage", ctl.QueryUsage)
+}
+
+// QueryUsage 聚合查询用量
+// 参数 groupBy 为逗号分隔的 day、client、model、conversation，默认 day；startDate、endDate 格式 2006-01-02，包含结束日期，默认最近7天；clientId、modelId 可选
+func (ctl *UsageController) QueryUsage(c *gin.Context) {
+	query, err := parseUsageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+		return
+	}
+
+	result, err := ctl.usageService.QueryUsage(query)
+	if err != nil {
+		if errors.Is(err, usage.ErrInvalidUsageQuery) {
+			c.JSON(http.StatusBadRequest, response.Error[any](common.ResponseIllegalParam.Code, err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, response.Error[any](common.ResponseUnError.Code, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// parseUsageQuery 解析用量查询参数，日期按服务器时区计算
+func parseUsageQuery(c *gin.Context) (*entity.UsageQueryEntity, error) {
+	query := &entity.UsageQueryEntity{GroupBy: []string{valobj.UsageGroupByDay}}
+	if groupBy := strings.TrimSpace(c.Query("groupBy")); groupBy != "" {
+		query.GroupBy = nil
+		for _, item := range strings.Split(groupBy, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				query.GroupBy = append(query.GroupBy, item)
+			}
+		}
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	endDate := today
+	if value := c.Query("endDate"); value != "" {
+		date, err := time.ParseInLocation(usageDateLayout, value, time.Local)
+		if err != nil {
+			return nil, errors.New("endDate格式应为2006-01-02")
+		}
+		endDate = date
+	}
+	startDate := endDate.AddDate(0, 0, 1-defaultUsageDays)
+	if value := c.Query("startDate"); value != "" {
+		date, err := time.ParseInLocation(usageDateLayout, value, time.Local)
+		if err != nil {
+			return nil, errors.New("startDate格式应为2006-01-02")
+		}
+		startDate = date
+	}
+	query.StartTime = startDate
+	query.EndTime = endDate.AddDate(0, 0, 1)
+
+	var err error
+	if value := c.Query("clientId"); value != "" {
+		if query.ClientID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, errors.New("clientId应为整数")
+		}
+	}
+	if value := c.Query("modelId"); value != "" {
+		if query.ModelID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, errors.New("modelId应为整数")
+		}
+	}
+	return query, nil
+}
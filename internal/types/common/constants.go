@@ -17,4 +17,6 @@ var (
 	ResponseIndexException = ResponseCode{"0003", "Unique index conflict"}
 	ResponseUpdateZero     = ResponseCode{"0004", "Update record is 0"}
 	ResponseHttpException  = ResponseCode{"0005", "HTTP interface call exception"}
+	ResponseRateLimited    = ResponseCode{"0006", "Rate limit exceeded"}
+	ResponseUnauthorized   = ResponseCode{"0007", "Unauthorized"}
 )